@@ -0,0 +1,46 @@
+// Command backfill-placeholders computes dominant colors and blurhashes of images
+// uploaded before placeholders were computed on upload, including images of pins created before the media registry.
+package main
+
+import (
+	"flag"
+	"log"
+	"pinset/configs/s3"
+	"pinset/internal/app/db"
+	mediarepository "pinset/internal/app/repository/media_repository"
+	"pinset/internal/app/usecase"
+	"pinset/pkg/logger"
+	"pinset/pkg/mediaurl"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 100, "number of images read from the registry at once")
+	flag.Parse()
+
+	logger, err := logger.NewLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	postgresDB := db.InitDB(logger)
+	defer postgresDB.Close()
+
+	minioParams, storageParams := s3.NewMinioParams(), s3.NewStorageParams()
+	objectStore, _, err := mediarepository.NewObjectStore(minioParams, storageParams)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	mediaUrls := mediaurl.NewBuilder(storageParams.MediaBaseUrl(minioParams), minioParams.Buckets()...)
+	mediaRepo := mediarepository.NewMediaRepository(postgresDB, logger, objectStore, mediaUrls)
+
+	result, err := usecase.NewPlaceholderBackfill(mediaRepo, *batchSize).Run()
+	logger.WithFields(logrus.Fields{
+		"updated": result.Updated,
+		"failed":  result.Failed,
+	}).Info("placeholder backfill finished")
+	if err != nil {
+		logger.WithError(err).Fatal("placeholder backfill failed")
+	}
+}
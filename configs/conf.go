@@ -2,7 +2,9 @@ package configs
 
 import (
 	"os"
+	"pinset/pkg/utils"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,14 +25,35 @@ func LookUpBoolEnvVar(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func LookUpInt64EnvVar(key string, defaultValue int64) int64 {
+	valStr := LookUpStringEnvVar(key, "")
+	if val, err := strconv.ParseInt(valStr, 10, 64); err == nil {
+		return val
+	}
+
+	return defaultValue
+}
+
+func LookUpDurationEnvVar(key string, defaultValue time.Duration) time.Duration {
+	valStr := LookUpStringEnvVar(key, "")
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+
+	return defaultValue
+}
+
 type internalParams struct {
 	MainServerPort string
+	// ShutdownTimeout bounds waiting for running requests on shutdown
+	ShutdownTimeout time.Duration
 }
 
 func NewInternalParams() internalParams {
 	internalParams := internalParams{}
 
 	internalParams.MainServerPort = ":8080"
+	internalParams.ShutdownTimeout = LookUpDurationEnvVar("SHUTDOWN_TIMEOUT", 15*time.Second)
 
 	return internalParams
 }
@@ -47,6 +70,47 @@ func NewAuthParams() AuthParams {
 	}
 }
 
+// UploadParams limits media uploads. Sizes are in bytes.
+type UploadParams struct {
+	MaxImageSize int64
+	MaxVideoSize int64
+	MaxAudioSize int64
+
+	// MaxRequestSize bounds the whole multipart request body
+	MaxRequestSize int64
+	// MultipartMemory is kept in RAM by ParseMultipartForm, the rest of the files is spooled to disk
+	MultipartMemory int64
+
+	// PresignExpiration is the lifetime of a direct-to-storage upload URL
+	PresignExpiration time.Duration
+}
+
+func NewUploadParams() UploadParams {
+	return UploadParams{
+		MaxImageSize:    LookUpInt64EnvVar("MEDIA_MAX_IMAGE_SIZE", 20*utils.MiB),
+		MaxVideoSize:    LookUpInt64EnvVar("MEDIA_MAX_VIDEO_SIZE", 512*utils.MiB),
+		MaxAudioSize:    LookUpInt64EnvVar("MEDIA_MAX_AUDIO_SIZE", 50*utils.MiB),
+		MaxRequestSize:  LookUpInt64EnvVar("MEDIA_MAX_REQUEST_SIZE", 600*utils.MiB),
+		MultipartMemory: LookUpInt64EnvVar("MEDIA_MULTIPART_MEMORY", 8*utils.MiB),
+
+		PresignExpiration: LookUpDurationEnvVar("MEDIA_PRESIGN_EXPIRATION", 15*time.Minute),
+	}
+}
+
+// MaxSizeForContentType returns the size limit for the sniffed content type, 0 for unknown types.
+func (up UploadParams) MaxSizeForContentType(contentType string) int64 {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return up.MaxImageSize
+	case strings.HasPrefix(contentType, "video/"):
+		return up.MaxVideoSize
+	case strings.HasPrefix(contentType, "audio/"):
+		return up.MaxAudioSize
+	default:
+		return 0
+	}
+}
+
 const (
 	loggerfilePath = "./logs/pinset.log"
 )
@@ -64,3 +128,99 @@ func NewLoggerParams() LoggerParams {
 type ctxUserIDKeyType string
 
 const UserIdKey ctxUserIDKeyType = "user_id"
+
+// MediaGCParams configures the removal of media objects nothing refers to.
+type MediaGCParams struct {
+	Interval time.Duration
+	// GracePeriod protects freshly uploaded media which is not attached to a pin yet
+	GracePeriod time.Duration
+	BatchSize   int
+}
+
+func NewMediaGCParams() MediaGCParams {
+	return MediaGCParams{
+		Interval:    LookUpDurationEnvVar("MEDIA_GC_INTERVAL", time.Hour),
+		GracePeriod: LookUpDurationEnvVar("MEDIA_GC_GRACE_PERIOD", 24*time.Hour),
+		BatchSize:   int(LookUpInt64EnvVar("MEDIA_GC_BATCH_SIZE", 100)),
+	}
+}
+
+// LinkPreviewParams configures fetching of pages pins are saved from.
+type LinkPreviewParams struct {
+	Timeout time.Duration
+	// MaxPageSize bounds the part of the page read in search of its metadata
+	MaxPageSize int64
+}
+
+func NewLinkPreviewParams() LinkPreviewParams {
+	return LinkPreviewParams{
+		Timeout:     LookUpDurationEnvVar("LINK_PREVIEW_TIMEOUT", 10*time.Second),
+		MaxPageSize: LookUpInt64EnvVar("LINK_PREVIEW_MAX_PAGE_SIZE", 2<<20),
+	}
+}
+
+// PinSchedulerParams configures publishing of scheduled pins.
+type PinSchedulerParams struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewPinSchedulerParams() PinSchedulerParams {
+	return PinSchedulerParams{
+		Interval:  LookUpDurationEnvVar("PIN_SCHEDULER_INTERVAL", time.Minute),
+		BatchSize: int(LookUpInt64EnvVar("PIN_SCHEDULER_BATCH_SIZE", 100)),
+	}
+}
+
+// BookmarkReconcilerParams configures recounting of bookmark counters of pins.
+type BookmarkReconcilerParams struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewBookmarkReconcilerParams() BookmarkReconcilerParams {
+	return BookmarkReconcilerParams{
+		Interval:  LookUpDurationEnvVar("BOOKMARK_RECONCILE_INTERVAL", time.Hour),
+		BatchSize: int(LookUpInt64EnvVar("BOOKMARK_RECONCILE_BATCH_SIZE", 500)),
+	}
+}
+
+// PinCounterParams configures deduplication and buffering of events on pins.
+// A viewer is counted once per pin within DedupWindow, at most MaxTracked viewers are remembered.
+type PinCounterParams struct {
+	DedupWindow   time.Duration
+	FlushInterval time.Duration
+	MaxTracked    int
+}
+
+func NewViewCounterParams() PinCounterParams {
+	return PinCounterParams{
+		DedupWindow:   LookUpDurationEnvVar("VIEW_DEDUP_WINDOW", 30*time.Minute),
+		FlushInterval: LookUpDurationEnvVar("VIEW_FLUSH_INTERVAL", 10*time.Second),
+		MaxTracked:    int(LookUpInt64EnvVar("VIEW_MAX_TRACKED", 1_000_000)),
+	}
+}
+
+func NewLinkClickCounterParams() PinCounterParams {
+	return PinCounterParams{
+		DedupWindow:   LookUpDurationEnvVar("LINK_CLICK_DEDUP_WINDOW", 30*time.Minute),
+		FlushInterval: LookUpDurationEnvVar("LINK_CLICK_FLUSH_INTERVAL", 10*time.Second),
+		MaxTracked:    int(LookUpInt64EnvVar("LINK_CLICK_MAX_TRACKED", 1_000_000)),
+	}
+}
+
+// AnalyticsAggregatorParams configures rollups of daily analytics.
+// Every run recounts the last LookbackDays days, the first run after start recounts BackfillDays days.
+type AnalyticsAggregatorParams struct {
+	Interval     time.Duration
+	LookbackDays int
+	BackfillDays int
+}
+
+func NewAnalyticsAggregatorParams() AnalyticsAggregatorParams {
+	return AnalyticsAggregatorParams{
+		Interval:     LookUpDurationEnvVar("ANALYTICS_AGGREGATE_INTERVAL", 15*time.Minute),
+		LookbackDays: int(LookUpInt64EnvVar("ANALYTICS_LOOKBACK_DAYS", 2)),
+		BackfillDays: int(LookUpInt64EnvVar("ANALYTICS_BACKFILL_DAYS", 366)),
+	}
+}
@@ -0,0 +1,25 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookUpInt64EnvVar(t *testing.T) {
+	t.Setenv("TEST_INT64", "8589934592")
+	assert.Equal(t, int64(8589934592), LookUpInt64EnvVar("TEST_INT64", 1))
+
+	t.Setenv("TEST_INT64", "8GiB")
+	assert.Equal(t, int64(1), LookUpInt64EnvVar("TEST_INT64", 1), "malformed values fall back to the default")
+	assert.Equal(t, int64(1), LookUpInt64EnvVar("TEST_INT64_MISSING", 1))
+}
+
+func TestUploadParamsMaxSizeForContentType(t *testing.T) {
+	params := UploadParams{MaxImageSize: 1, MaxVideoSize: 2, MaxAudioSize: 3}
+
+	assert.Equal(t, int64(1), params.MaxSizeForContentType("image/png"))
+	assert.Equal(t, int64(2), params.MaxSizeForContentType("video/mp4"))
+	assert.Equal(t, int64(3), params.MaxSizeForContentType("audio/mpeg"))
+	assert.Zero(t, params.MaxSizeForContentType("text/plain; charset=utf-8"), "unknown types can't be uploaded")
+}
@@ -1,27 +1,50 @@
 package s3
 
-import "pinset/configs"
-
-const MinioEndPoint = "localhost:9000"
+import (
+	"pinset/configs"
+	"time"
+)
 
 type MinioParams struct {
 	Endpoint        string
+	PublicEndpoint  string // clients reach the storage at it, presigned URLs are signed for this host
 	AccessKeyID     string
 	SecretAccessKey string
 	UseSSL          bool
 	ImageBucketName string
 	VideoBucketName string
 	AudioBucketName string
+
+	// PublicBaseUrl prefixes media keys in public URLs, e.g. a CDN in front of the storage.
+	// The local backends are served from StorageParams.LocalBaseUrl instead, see MediaBaseUrl
+	PublicBaseUrl string
+	// SignedUrlExpiration is the lifetime of presigned URLs of private media
+	SignedUrlExpiration time.Duration
 }
 
 func NewMinioParams() MinioParams {
-	return MinioParams{
+	params := MinioParams{
 		Endpoint:        configs.LookUpStringEnvVar("MINIO_S3_ENDPOINT", "minio:9000"),
+		PublicEndpoint:  configs.LookUpStringEnvVar("MINIO_S3_PUBLIC_ENDPOINT", "localhost:9000"),
 		AccessKeyID:     configs.LookUpStringEnvVar("MINIO_S3_ACCESS_KEY", "minioadmin"),
 		SecretAccessKey: configs.LookUpStringEnvVar("MINIO_S3_SECRET_ACCESS_KEY", "minioadmin"),
 		UseSSL:          configs.LookUpBoolEnvVar("MINIO_S3_USE_SSL", false),
 		ImageBucketName: configs.LookUpStringEnvVar("MINIO_IMG_BUCKET_NAME", "images"),
 		VideoBucketName: configs.LookUpStringEnvVar("MINIO_VID_BUCKET_NAME", "videos"),
 		AudioBucketName: configs.LookUpStringEnvVar("MINIO_AUD_BUCKET_NAME", "audios"),
+
+		SignedUrlExpiration: configs.LookUpDurationEnvVar("MINIO_SIGNED_URL_EXPIRATION", time.Hour),
+	}
+
+	scheme := "http://"
+	if params.UseSSL {
+		scheme = "https://"
 	}
+	params.PublicBaseUrl = configs.LookUpStringEnvVar("MEDIA_PUBLIC_BASE_URL", scheme+params.PublicEndpoint)
+
+	return params
+}
+
+func (p MinioParams) Buckets() []string {
+	return []string{p.ImageBucketName, p.VideoBucketName, p.AudioBucketName}
 }
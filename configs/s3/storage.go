@@ -0,0 +1,48 @@
+package s3
+
+import (
+	"pinset/configs"
+)
+
+const (
+	StorageBackendMinio  = "minio"
+	StorageBackendFS     = "fs"
+	StorageBackendMemory = "memory"
+
+	// LocalStoragePath is where the main server serves the objects of the local backends
+	LocalStoragePath = "/media"
+)
+
+// StorageParams selects the object storage. The local backends let the service run without MinIO,
+// their objects are served and presigned uploads are accepted by the main server.
+type StorageParams struct {
+	Backend string
+	// LocalRoot is the directory of the fs backend
+	LocalRoot string
+	// LocalBaseUrl is the address of LocalStoragePath of the main server as clients see it
+	LocalBaseUrl string
+	// SigningKey signs URLs of the local backends, a random one is used when empty
+	SigningKey []byte
+}
+
+func NewStorageParams() StorageParams {
+	return StorageParams{
+		Backend:      configs.LookUpStringEnvVar("OBJECT_STORE_BACKEND", StorageBackendMinio),
+		LocalRoot:    configs.LookUpStringEnvVar("OBJECT_STORE_LOCAL_ROOT", "./data/media"),
+		LocalBaseUrl: configs.LookUpStringEnvVar("OBJECT_STORE_LOCAL_BASE_URL", "http://localhost"+configs.NewInternalParams().MainServerPort+LocalStoragePath),
+		SigningKey:   []byte(configs.LookUpStringEnvVar("OBJECT_STORE_SIGNING_KEY", "")),
+	}
+}
+
+func (p StorageParams) IsLocal() bool {
+	return p.Backend != StorageBackendMinio
+}
+
+// MediaBaseUrl is the base of public media URLs: the main server for the local backends,
+// PublicBaseUrl of MinIO otherwise.
+func (p StorageParams) MediaBaseUrl(config MinioParams) string {
+	if p.IsLocal() {
+		return p.LocalBaseUrl
+	}
+	return config.PublicBaseUrl
+}
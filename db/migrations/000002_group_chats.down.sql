@@ -0,0 +1,16 @@
+DELETE FROM msg WHERE message_type = 'system';
+
+ALTER TABLE msg
+    DROP COLUMN IF EXISTS target_id,
+    DROP COLUMN IF EXISTS message_type;
+
+ALTER TABLE user_chat
+    DROP COLUMN IF EXISTS joined_at,
+    DROP COLUMN IF EXISTS role;
+
+ALTER TABLE chat
+    DROP COLUMN IF EXISTS creation_time,
+    DROP COLUMN IF EXISTS creator_id,
+    DROP COLUMN IF EXISTS is_group,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS title;
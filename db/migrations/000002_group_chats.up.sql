@@ -0,0 +1,33 @@
+-- Chat table:
+-- Групповые чаты получают название, аватар и создателя.
+-- Личные чаты остаются с is_group = false.
+ALTER TABLE chat
+    ADD COLUMN IF NOT EXISTS title TEXT
+        CONSTRAINT chat_title_length CHECK (CHAR_LENGTH(title) <= 255),
+    ADD COLUMN IF NOT EXISTS avatar_url TEXT,
+    ADD COLUMN IF NOT EXISTS is_group BOOLEAN
+        NOT NULL
+        DEFAULT false,
+    ADD COLUMN IF NOT EXISTS creator_id INT REFERENCES "user" (user_id)
+        ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS creation_time TIMESTAMPTZ DEFAULT NOW();
+
+-- User Chat table:
+-- Роль участника в чате и время вступления.
+ALTER TABLE user_chat
+    ADD COLUMN IF NOT EXISTS role TEXT
+        NOT NULL
+        DEFAULT 'member'
+        CONSTRAINT user_chat_role CHECK (role IN ('member', 'admin')),
+    ADD COLUMN IF NOT EXISTS joined_at TIMESTAMPTZ DEFAULT NOW();
+
+-- Message table:
+-- Системные сообщения об изменении состава чата.
+-- target_id - пользователь, над которым было выполнено действие.
+ALTER TABLE msg
+    ADD COLUMN IF NOT EXISTS message_type TEXT
+        NOT NULL
+        DEFAULT 'text'
+        CONSTRAINT msg_type CHECK (message_type IN ('text', 'system')),
+    ADD COLUMN IF NOT EXISTS target_id INT REFERENCES "user" (user_id)
+        ON DELETE SET NULL;
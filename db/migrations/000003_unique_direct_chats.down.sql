@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS direct_chat_users_unique;
+
+ALTER TABLE chat
+    DROP CONSTRAINT IF EXISTS direct_chat_users_order,
+    DROP COLUMN IF EXISTS second_user_id,
+    DROP COLUMN IF EXISTS first_user_id;
@@ -0,0 +1,47 @@
+-- Chat table:
+-- Участники личного чата, first_user_id < second_user_id.
+-- Для групповых чатов оба поля равны NULL.
+ALTER TABLE chat
+    ADD COLUMN IF NOT EXISTS first_user_id INT REFERENCES "user" (user_id)
+        ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS second_user_id INT REFERENCES "user" (user_id)
+        ON DELETE SET NULL,
+    ADD CONSTRAINT direct_chat_users_order CHECK (first_user_id < second_user_id);
+
+-- Личные чаты и пары их участников.
+CREATE TEMP TABLE direct_chat_pair ON COMMIT DROP AS
+SELECT uc.chat_id,
+       MIN(uc.user_id) AS first_user_id,
+       MAX(uc.user_id) AS second_user_id
+FROM user_chat uc
+    JOIN chat c ON c.chat_id = uc.chat_id
+WHERE c.is_group = false
+GROUP BY uc.chat_id
+HAVING COUNT(*) = 2;
+
+-- Для каждой пары остается самый старый чат, остальные сливаются в него.
+CREATE TEMP TABLE direct_chat_merge ON COMMIT DROP AS
+SELECT chat_id,
+       MIN(chat_id) OVER (PARTITION BY first_user_id, second_user_id) AS target_chat_id,
+       first_user_id,
+       second_user_id
+FROM direct_chat_pair;
+
+UPDATE msg
+SET chat_id = m.target_chat_id
+FROM direct_chat_merge m
+WHERE msg.chat_id = m.chat_id
+    AND m.chat_id <> m.target_chat_id;
+
+DELETE FROM chat
+USING direct_chat_merge m
+WHERE chat.chat_id = m.chat_id
+    AND m.chat_id <> m.target_chat_id;
+
+UPDATE chat
+SET first_user_id = m.first_user_id,
+    second_user_id = m.second_user_id
+FROM direct_chat_merge m
+WHERE chat.chat_id = m.chat_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS direct_chat_users_unique ON chat (first_user_id, second_user_id);
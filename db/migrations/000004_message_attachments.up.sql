@@ -0,0 +1,26 @@
+-- Message attachment table:
+-- Таблица-хранилище вложений сообщений: пины, доски и загруженные изображения.
+-- Для каждого типа вложения заполнено только соответствующее поле.
+CREATE TABLE IF NOT EXISTS msg_attachment (
+    attachment_id INT
+        GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    message_id INT REFERENCES msg (message_id)
+        ON DELETE CASCADE
+        NOT NULL,
+    attachment_type TEXT
+        NOT NULL
+        CONSTRAINT msg_attachment_type CHECK (attachment_type IN ('pin', 'board', 'image')),
+    pin_id INT REFERENCES pin (pin_id)
+        ON DELETE CASCADE,
+    board_id INT REFERENCES board (board_id)
+        ON DELETE CASCADE,
+    media_url TEXT,
+    CONSTRAINT msg_attachment_target CHECK (
+        (attachment_type = 'pin' AND pin_id IS NOT NULL AND board_id IS NULL AND media_url IS NULL) OR
+        (attachment_type = 'board' AND board_id IS NOT NULL AND pin_id IS NULL AND media_url IS NULL) OR
+        (attachment_type = 'image' AND media_url IS NOT NULL AND pin_id IS NULL AND board_id IS NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS msg_attachment_message_id ON msg_attachment (message_id);
+CREATE INDEX IF NOT EXISTS msg_attachment_board_id ON msg_attachment (board_id) WHERE board_id IS NOT NULL;
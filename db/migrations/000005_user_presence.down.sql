@@ -0,0 +1,3 @@
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS hide_presence,
+    DROP COLUMN IF EXISTS last_seen;
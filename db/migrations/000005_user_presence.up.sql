@@ -0,0 +1,8 @@
+-- User table:
+-- last_seen - время последней активности пользователя в чатах.
+-- hide_presence - настройка приватности, скрывающая статус в сети и last_seen.
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS hide_presence BOOLEAN
+        NOT NULL
+        DEFAULT false;
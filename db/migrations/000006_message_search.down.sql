@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS msg_chat_message_idx;
+DROP INDEX IF EXISTS msg_content_tsv_idx;
+
+ALTER TABLE msg
+    DROP COLUMN IF EXISTS content_tsv;
@@ -0,0 +1,11 @@
+-- Msg table:
+-- content_tsv - полнотекстовое представление сообщения для поиска по истории чатов.
+-- Конфигурация simple не зависит от языка сообщения.
+ALTER TABLE msg
+    ADD COLUMN IF NOT EXISTS content_tsv TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS msg_content_tsv_idx ON msg USING GIN (content_tsv);
+
+-- Постраничная загрузка истории чата по message_id
+CREATE INDEX IF NOT EXISTS msg_chat_message_idx ON msg (chat_id, message_id);
@@ -0,0 +1,14 @@
+-- User Block table:
+-- Черный список пользователя. Заблокированный пользователь не может писать
+-- заблокировавшему, а его пины и комментарии скрыты от заблокировавшего.
+CREATE TABLE IF NOT EXISTS user_block (
+    blocker_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    blocked_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT user_block_not_self CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_block_blocked_idx ON user_block (blocked_id);
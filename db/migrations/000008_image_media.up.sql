@@ -0,0 +1,14 @@
+-- Image Media table:
+-- Обработанные изображения: размеры оригинала после поворота по EXIF
+-- и адреса уменьшенных вариантов (исходный формат и WebP) для сетки ленты.
+CREATE TABLE IF NOT EXISTS image_media (
+    media_url TEXT PRIMARY KEY,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    variants JSONB
+        NOT NULL
+        DEFAULT '[]',
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+-- Media Upload table:
+-- Загрузки напрямую в хранилище по presigned URL. Запись создается при выдаче
+-- ссылки и подтверждается после проверки размера и реального типа объекта.
+CREATE TABLE IF NOT EXISTS media_upload (
+    upload_id UUID PRIMARY KEY,
+    user_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    bucket_name TEXT NOT NULL,
+    object_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    media_url TEXT,
+    status TEXT
+        NOT NULL
+        DEFAULT 'pending',
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT media_upload_status_check CHECK (status IN ('pending', 'completed'))
+);
+
+CREATE INDEX IF NOT EXISTS media_upload_user_idx ON media_upload (user_id);
@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS msg_attachment_media_ref ON msg_attachment;
+DROP TRIGGER IF EXISTS chat_avatar_media_ref ON chat;
+DROP TRIGGER IF EXISTS user_avatar_media_ref ON "user";
+DROP TRIGGER IF EXISTS pin_media_ref ON pin;
+
+DROP FUNCTION IF EXISTS media_ref_trigger();
+DROP FUNCTION IF EXISTS media_move_ref(INT, INT);
+
+ALTER TABLE msg_attachment DROP COLUMN IF EXISTS media_id;
+ALTER TABLE chat DROP COLUMN IF EXISTS avatar_media_id;
+ALTER TABLE "user" DROP COLUMN IF EXISTS avatar_media_id;
+ALTER TABLE pin DROP COLUMN IF EXISTS media_id;
+
+DROP TABLE IF EXISTS media;
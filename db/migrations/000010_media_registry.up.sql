@@ -0,0 +1,93 @@
+-- Media table:
+-- Реестр объектов хранилища. Пины, аватары и вложения ссылаются на медиа по id,
+-- ref_count поддерживается триггерами, в том числе при каскадном удалении.
+-- Объекты без ссылок удаляются фоновой задачей после истечения grace-периода,
+-- отсчитываемого от unreferenced_since. Объекты, загруженные до появления реестра, не отслеживаются.
+CREATE TABLE IF NOT EXISTS media (
+    media_id INT
+        GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    owner_id INT REFERENCES "user" (user_id) ON DELETE SET NULL,
+    bucket_name TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    variant_keys TEXT[]
+        NOT NULL
+        DEFAULT '{}',
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    checksum TEXT,
+    media_url TEXT NOT NULL UNIQUE,
+    ref_count INT
+        NOT NULL
+        DEFAULT 0
+        CONSTRAINT media_ref_count_check CHECK (ref_count >= 0),
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW(),
+    unreferenced_since TIMESTAMPTZ
+        DEFAULT NOW(),
+    UNIQUE (bucket_name, object_key)
+);
+
+CREATE INDEX IF NOT EXISTS media_unreferenced_idx ON media (unreferenced_since) WHERE ref_count = 0;
+
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS avatar_media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+ALTER TABLE chat ADD COLUMN IF NOT EXISTS avatar_media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+ALTER TABLE msg_attachment ADD COLUMN IF NOT EXISTS media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+
+-- Переносит ссылку с одного медиа на другое
+CREATE OR REPLACE FUNCTION media_move_ref(old_media_id INT, new_media_id INT) RETURNS VOID AS $$
+BEGIN
+    IF old_media_id IS NOT DISTINCT FROM new_media_id THEN
+        RETURN;
+    END IF;
+
+    IF old_media_id IS NOT NULL THEN
+        UPDATE media
+        SET ref_count = ref_count - 1,
+            unreferenced_since = CASE WHEN ref_count = 1 THEN NOW() ELSE unreferenced_since END
+        WHERE media_id = old_media_id;
+    END IF;
+
+    IF new_media_id IS NOT NULL THEN
+        UPDATE media
+        SET ref_count = ref_count + 1,
+            unreferenced_since = NULL
+        WHERE media_id = new_media_id;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Триггерная функция, имя колонки со ссылкой передается аргументом
+CREATE OR REPLACE FUNCTION media_ref_trigger() RETURNS TRIGGER AS $$
+DECLARE
+    old_media_id INT;
+    new_media_id INT;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_media_id := (to_jsonb(OLD) ->> TG_ARGV[0])::INT;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_media_id := (to_jsonb(NEW) ->> TG_ARGV[0])::INT;
+    END IF;
+
+    PERFORM media_move_ref(old_media_id, new_media_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pin_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF media_id ON pin
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('media_id');
+
+CREATE TRIGGER user_avatar_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF avatar_media_id ON "user"
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('avatar_media_id');
+
+CREATE TRIGGER chat_avatar_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF avatar_media_id ON chat
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('avatar_media_id');
+
+CREATE TRIGGER msg_attachment_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF media_id ON msg_attachment
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('media_id');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS media_checksum_idx;
+
+ALTER TABLE media DROP COLUMN IF EXISTS phash;
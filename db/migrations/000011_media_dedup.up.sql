@@ -0,0 +1,5 @@
+-- Дедупликация медиа: повторная загрузка файла с тем же SHA-256 переиспользует объект,
+-- а перцептивный хеш (dHash) изображений позволяет находить похожие пины.
+ALTER TABLE media ADD COLUMN IF NOT EXISTS phash BIGINT;
+
+CREATE INDEX IF NOT EXISTS media_checksum_idx ON media (bucket_name, checksum) WHERE checksum IS NOT NULL;
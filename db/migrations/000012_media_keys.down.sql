@@ -0,0 +1,33 @@
+-- Ключи превращаются обратно в URL со старым адресом хранилища
+UPDATE image_media SET
+    media_key = 'http://localhost:9000/' || media_key,
+    variants = COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'width', v -> 'width',
+            'height', v -> 'height',
+            'url', 'http://localhost:9000/' || (v ->> 'key'),
+            'webp_url', 'http://localhost:9000/' || (v ->> 'webp_key')
+        ) ORDER BY ordinality)
+        FROM jsonb_array_elements(variants) WITH ORDINALITY AS e(v, ordinality)
+    ), '[]');
+ALTER TABLE image_media RENAME COLUMN media_key TO media_url;
+
+UPDATE media_upload SET media_key = 'http://localhost:9000/' || media_key WHERE media_key IS NOT NULL;
+ALTER TABLE media_upload RENAME COLUMN media_key TO media_url;
+
+UPDATE msg_attachment SET media_url = 'http://localhost:9000/' || media_url
+WHERE media_url IS NOT NULL AND media_url <> '' AND media_url !~ '^https?://';
+UPDATE chat SET avatar_url = 'http://localhost:9000/' || avatar_url
+WHERE avatar_url IS NOT NULL AND avatar_url <> '' AND avatar_url !~ '^https?://';
+UPDATE "user" SET avatar_url = 'http://localhost:9000/' || avatar_url
+WHERE avatar_url IS NOT NULL AND avatar_url <> '' AND avatar_url !~ '^https?://';
+
+UPDATE pin SET media_key = 'http://localhost:9000/' || media_key WHERE media_key <> '' AND media_key !~ '^https?://';
+ALTER TABLE pin RENAME COLUMN media_key TO media_url;
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS media_url TEXT;
+UPDATE media SET media_url = 'http://localhost:9000/' || media_key;
+ALTER TABLE media ALTER COLUMN media_url SET NOT NULL;
+ALTER TABLE media ADD CONSTRAINT media_media_url_key UNIQUE (media_url);
+ALTER TABLE media DROP CONSTRAINT IF EXISTS media_key_unique;
+ALTER TABLE media DROP COLUMN IF EXISTS media_key;
@@ -0,0 +1,44 @@
+-- Медиа хранятся ключами <bucket>/<object> вместо URL с адресом хранилища внутри.
+-- URL собираются при ответе из конфигурации: публичный адрес или CDN, для медиа закрытых досок - presigned URL.
+-- Внешние медиа по-прежнему хранятся абсолютными URL.
+-- Колонки avatar_url и msg_attachment.media_url сохраняют имена, но содержат ключи.
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS media_key TEXT
+    GENERATED ALWAYS AS (bucket_name || '/' || object_key) STORED;
+ALTER TABLE media ADD CONSTRAINT media_key_unique UNIQUE (media_key);
+ALTER TABLE media DROP COLUMN IF EXISTS media_url;
+
+ALTER TABLE pin RENAME COLUMN media_url TO media_key;
+UPDATE pin p SET media_key = m.media_key FROM media m WHERE m.media_id = p.media_id;
+UPDATE pin SET media_key = regexp_replace(media_key, '^https?://[^/]+/', '')
+WHERE media_key ~ '^https?://[^/]+/(images|videos|audios)/';
+
+UPDATE "user" u SET avatar_url = m.media_key FROM media m WHERE m.media_id = u.avatar_media_id;
+UPDATE "user" SET avatar_url = regexp_replace(avatar_url, '^https?://[^/]+/', '')
+WHERE avatar_url ~ '^https?://[^/]+/(images|videos|audios)/';
+
+UPDATE chat c SET avatar_url = m.media_key FROM media m WHERE m.media_id = c.avatar_media_id;
+UPDATE chat SET avatar_url = regexp_replace(avatar_url, '^https?://[^/]+/', '')
+WHERE avatar_url ~ '^https?://[^/]+/(images|videos|audios)/';
+
+UPDATE msg_attachment a SET media_url = m.media_key FROM media m WHERE m.media_id = a.media_id;
+UPDATE msg_attachment SET media_url = regexp_replace(media_url, '^https?://[^/]+/', '')
+WHERE media_url ~ '^https?://[^/]+/(images|videos|audios)/';
+
+ALTER TABLE media_upload RENAME COLUMN media_url TO media_key;
+UPDATE media_upload SET media_key = regexp_replace(media_key, '^https?://[^/]+/', '')
+WHERE media_key ~ '^https?://[^/]+/';
+
+-- Варианты изображений: {width, height, url, webp_url} -> {width, height, key, webp_key}
+ALTER TABLE image_media RENAME COLUMN media_url TO media_key;
+UPDATE image_media SET
+    media_key = regexp_replace(media_key, '^https?://[^/]+/', ''),
+    variants = COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'width', v -> 'width',
+            'height', v -> 'height',
+            'key', regexp_replace(v ->> 'url', '^https?://[^/]+/', ''),
+            'webp_key', regexp_replace(v ->> 'webp_url', '^https?://[^/]+/', '')
+        ) ORDER BY ordinality)
+        FROM jsonb_array_elements(variants) WITH ORDINALITY AS e(v, ordinality)
+    ), '[]');
@@ -0,0 +1,23 @@
+DROP TRIGGER IF EXISTS pin_poster_media_ref ON pin;
+DROP TRIGGER IF EXISTS media_poster_media_ref ON media;
+
+-- Постеры больше не считаются ссылками
+UPDATE media m SET
+    ref_count = m.ref_count - r.refs,
+    unreferenced_since = CASE WHEN m.ref_count = r.refs THEN NOW() ELSE m.unreferenced_since END
+FROM (
+    SELECT poster_media_id AS media_id, COUNT(*) AS refs FROM (
+        SELECT poster_media_id FROM media WHERE poster_media_id IS NOT NULL
+        UNION ALL
+        SELECT poster_media_id FROM pin WHERE poster_media_id IS NOT NULL
+    ) posters GROUP BY poster_media_id
+) r
+WHERE m.media_id = r.media_id;
+
+ALTER TABLE pin DROP COLUMN IF EXISTS poster_media_id;
+ALTER TABLE pin DROP COLUMN IF EXISTS poster_key;
+
+ALTER TABLE media DROP COLUMN IF EXISTS poster_media_id;
+ALTER TABLE media DROP COLUMN IF EXISTS duration_ms;
+ALTER TABLE media DROP COLUMN IF EXISTS height;
+ALTER TABLE media DROP COLUMN IF EXISTS width;
@@ -0,0 +1,18 @@
+-- Видео и аудио: длительность и размер кадра читаются из moov-бокса MP4 при загрузке.
+-- Постер видео - изображение из реестра медиа: обложка, встроенная в файл, или загруженное пользователем.
+-- Постер файла хранится у медиа, постер пина переопределяет его.
+ALTER TABLE media ADD COLUMN IF NOT EXISTS width INT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS height INT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS duration_ms BIGINT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS poster_media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS poster_key TEXT;
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS poster_media_id INT REFERENCES media (media_id) ON DELETE SET NULL;
+
+CREATE TRIGGER media_poster_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF poster_media_id ON media
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('poster_media_id');
+
+CREATE TRIGGER pin_poster_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF poster_media_id ON pin
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('poster_media_id');
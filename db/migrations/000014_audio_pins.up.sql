@@ -0,0 +1,3 @@
+-- Аудио: длительность и огибающая (массив пиков от 0 до 100) считаются при загрузке.
+-- Обложка из тегов файла сохраняется постером медиа, как у видео.
+ALTER TABLE media ADD COLUMN IF NOT EXISTS waveform JSONB;
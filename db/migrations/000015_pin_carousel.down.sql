@@ -0,0 +1,5 @@
+-- Удаление строк снимает ссылки с медиа через триггер
+DELETE FROM pin_media;
+
+DROP TRIGGER IF EXISTS pin_media_media_ref ON pin_media;
+DROP TABLE IF EXISTS pin_media;
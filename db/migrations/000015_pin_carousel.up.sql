@@ -0,0 +1,16 @@
+-- Карусели: пин хранит до нескольких медиа (изображения и видео) в заданном порядке.
+-- Первое медиа карусели - медиа самого пина (pin.media_key), оно служит обложкой в превью.
+-- Остальные хранятся здесь с position от 1, при редактировании список перезаписывается целиком.
+CREATE TABLE IF NOT EXISTS pin_media (
+    pin_id INT NOT NULL REFERENCES pin (pin_id) ON DELETE CASCADE,
+    position INT
+        NOT NULL
+        CONSTRAINT pin_media_position_check CHECK (position > 0),
+    media_key TEXT NOT NULL,
+    media_id INT REFERENCES media (media_id) ON DELETE SET NULL,
+    PRIMARY KEY (pin_id, position)
+);
+
+CREATE TRIGGER pin_media_media_ref
+    AFTER INSERT OR DELETE OR UPDATE OF media_id ON pin_media
+    FOR EACH ROW EXECUTE FUNCTION media_ref_trigger('media_id');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS media_without_placeholder_idx;
+
+ALTER TABLE media DROP COLUMN IF EXISTS blurhash;
+ALTER TABLE media DROP COLUMN IF EXISTS dominant_color;
@@ -0,0 +1,8 @@
+-- Заглушки изображений: доминирующий цвет (#rrggbb) и blurhash показываются в ленте до загрузки картинки.
+-- Считаются при загрузке изображения, для загруженных раньше - командой cmd/backfill-placeholders.
+-- У видео и аудио своих заглушек нет, используются заглушки постера.
+ALTER TABLE media ADD COLUMN IF NOT EXISTS dominant_color TEXT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS blurhash TEXT;
+
+CREATE INDEX IF NOT EXISTS media_without_placeholder_idx ON media (media_id)
+    WHERE blurhash IS NULL AND content_type LIKE 'image/%';
@@ -0,0 +1,9 @@
+-- Неопубликованные пины нельзя оставить без статуса, они удаляются
+DELETE FROM pin WHERE status <> 'published';
+
+DROP INDEX IF EXISTS pin_unpublished_author_idx;
+DROP INDEX IF EXISTS pin_scheduled_idx;
+
+ALTER TABLE pin DROP CONSTRAINT IF EXISTS pin_scheduled_publish_at_check;
+ALTER TABLE pin DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE pin DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+-- Черновики и отложенная публикация.
+-- Черновики и запланированные пины видит только автор, в ленту попадают только опубликованные.
+-- Запланированные пины публикует фоновая задача, запущенная в каждом экземпляре сервера:
+-- пины забираются через FOR UPDATE SKIP LOCKED, поэтому экземпляры не мешают друг другу.
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS status TEXT
+    NOT NULL
+    DEFAULT 'published'
+    CONSTRAINT pin_status_check CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE pin ADD CONSTRAINT pin_scheduled_publish_at_check CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS pin_scheduled_idx ON pin (publish_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS pin_unpublished_author_idx ON pin (author_id) WHERE status <> 'published';
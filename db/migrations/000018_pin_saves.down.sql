@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification;
+ALTER TABLE pin DROP COLUMN IF EXISTS saves;
+DROP TABLE IF EXISTS pin_save;
@@ -0,0 +1,37 @@
+-- Pin save table:
+-- Сохранения чужих пинов в доски с указанием, откуда пин был сохранен.
+-- from_board_id - доска, в которой пользователь нашел пин, from_user_id - ее владелец на момент сохранения.
+-- По цепочке from_board_id восстанавливается путь пина от автора до доски.
+-- Строка удаляется вместе с пином из доски, счетчик сохранений пина при этом не уменьшается.
+CREATE TABLE IF NOT EXISTS pin_save (
+    board_id INT NOT NULL,
+    pin_id INT NOT NULL,
+    saver_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    from_board_id INT REFERENCES board (board_id) ON DELETE SET NULL,
+    from_user_id INT REFERENCES "user" (user_id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW(),
+    PRIMARY KEY (board_id, pin_id),
+    FOREIGN KEY (board_id, pin_id) REFERENCES saved_pin_to_board (board_id, pin_id) ON DELETE CASCADE,
+    CONSTRAINT pin_save_not_same_board CHECK (from_board_id <> board_id)
+);
+
+ALTER TABLE pin ADD COLUMN IF NOT EXISTS saves INT NOT NULL DEFAULT 0;
+
+-- Notification table:
+-- Уведомления пользователя о действиях других пользователей с его пинами.
+CREATE TABLE IF NOT EXISTS notification (
+    notification_id INT
+        GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    recipient_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    actor_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    type TEXT NOT NULL,
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE,
+    board_id INT REFERENCES board (board_id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notification_recipient_idx ON notification (recipient_id, notification_id DESC);
@@ -0,0 +1,27 @@
+-- Pin reaction table:
+-- Реакции пользователей на пины, у пользователя одна реакция на пин.
+CREATE TABLE IF NOT EXISTS pin_reaction (
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE NOT NULL,
+    user_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    type TEXT
+        NOT NULL
+        CONSTRAINT pin_reaction_type_check CHECK (type IN ('like', 'love', 'wow', 'haha', 'sad', 'angry')),
+    created_at TIMESTAMPTZ
+        NOT NULL
+        DEFAULT NOW(),
+    PRIMARY KEY (pin_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS pin_reaction_user_idx ON pin_reaction (user_id);
+
+-- Pin reaction count table:
+-- Счетчики реакций пина по типам. Меняются триггером pin_reaction_count в одной транзакции с реакцией.
+CREATE TABLE IF NOT EXISTS pin_reaction_count (
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE NOT NULL,
+    type TEXT NOT NULL,
+    count INT
+        NOT NULL
+        DEFAULT 0
+        CONSTRAINT pin_reaction_count_check CHECK (count >= 0),
+    PRIMARY KEY (pin_id, type)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS bookmark_owner_pin_idx;
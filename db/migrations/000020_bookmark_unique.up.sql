@@ -0,0 +1,8 @@
+-- Пользователь может добавить пин в закладки только один раз.
+-- Повторные закладки удаляются, счетчики закладок пересчитываются по оставшимся.
+DELETE FROM bookmark b USING bookmark d
+WHERE b.owner_id = d.owner_id AND b.pin_id = d.pin_id AND b.bookmark_id > d.bookmark_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS bookmark_owner_pin_idx ON bookmark (owner_id, pin_id);
+
+UPDATE pin p SET bookmarks = (SELECT COUNT(*) FROM bookmark b WHERE b.pin_id = p.pin_id);
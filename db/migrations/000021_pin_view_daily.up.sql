@@ -0,0 +1,12 @@
+-- Pin view daily table:
+-- Просмотры пина по дням для аналитики. Просмотры накапливаются в памяти
+-- и записываются пачками вместе с общим счетчиком pin.views.
+CREATE TABLE IF NOT EXISTS pin_view_daily (
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE NOT NULL,
+    day DATE NOT NULL,
+    views INT
+        NOT NULL
+        DEFAULT 0
+        CONSTRAINT pin_view_daily_views_check CHECK (views >= 0),
+    PRIMARY KEY (pin_id, day)
+);
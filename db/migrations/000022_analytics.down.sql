@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS pin_save_created_at_idx;
+DROP INDEX IF EXISTS comment_creation_time_idx;
+DROP INDEX IF EXISTS bookmark_time_idx;
+DROP INDEX IF EXISTS pin_author_idx;
+
+DROP TABLE IF EXISTS user_follower_daily;
+DROP TABLE IF EXISTS pin_stats_daily;
+DROP TABLE IF EXISTS pin_link_click_daily;
@@ -0,0 +1,41 @@
+-- Pin link click daily table:
+-- Переходы по related_link пина по дням.
+CREATE TABLE IF NOT EXISTS pin_link_click_daily (
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE NOT NULL,
+    day DATE NOT NULL,
+    clicks INT
+        NOT NULL
+        DEFAULT 0
+        CONSTRAINT pin_link_click_daily_clicks_check CHECK (clicks >= 0),
+    PRIMARY KEY (pin_id, day)
+);
+
+-- Pin stats daily table:
+-- Дневная сводка статистики пина для аналитики автора. Пересчитывается фоновым агрегатором
+-- за последние дни из просмотров, закладок, сохранений, комментариев и переходов по ссылке.
+CREATE TABLE IF NOT EXISTS pin_stats_daily (
+    pin_id INT REFERENCES pin (pin_id) ON DELETE CASCADE NOT NULL,
+    day DATE NOT NULL,
+    views INT NOT NULL DEFAULT 0,
+    bookmarks INT NOT NULL DEFAULT 0,
+    saves INT NOT NULL DEFAULT 0,
+    comments INT NOT NULL DEFAULT 0,
+    link_clicks INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (pin_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS pin_stats_daily_day_idx ON pin_stats_daily (day);
+
+-- User follower daily table:
+-- Число подписчиков пользователя на конец дня, снимается фоновым агрегатором.
+CREATE TABLE IF NOT EXISTS user_follower_daily (
+    user_id INT REFERENCES "user" (user_id) ON DELETE CASCADE NOT NULL,
+    day DATE NOT NULL,
+    followers INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS pin_author_idx ON pin (author_id);
+CREATE INDEX IF NOT EXISTS bookmark_time_idx ON bookmark (bookmark_time);
+CREATE INDEX IF NOT EXISTS comment_creation_time_idx ON comment (creation_time);
+CREATE INDEX IF NOT EXISTS pin_save_created_at_idx ON pin_save (created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE image_media DROP COLUMN IF EXISTS blurhash;
+ALTER TABLE image_media DROP COLUMN IF EXISTS dominant_color;
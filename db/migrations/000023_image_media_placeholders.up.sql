@@ -0,0 +1,4 @@
+-- Заглушки изображений по ключу: пины, созданные до реестра медиа, ссылаются на ключи без записи в media.
+-- Заполняются при загрузке изображения и командой cmd/backfill-placeholders для всех ключей пинов и каруселей.
+ALTER TABLE image_media ADD COLUMN IF NOT EXISTS dominant_color TEXT;
+ALTER TABLE image_media ADD COLUMN IF NOT EXISTS blurhash TEXT;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS pin_reaction_count ON pin_reaction;
+DROP FUNCTION IF EXISTS pin_reaction_count_trigger();
+DROP FUNCTION IF EXISTS pin_reaction_count_decrease(INT, TEXT);
+DROP FUNCTION IF EXISTS pin_reaction_count_increase(INT, TEXT);
@@ -0,0 +1,43 @@
+-- Счетчики реакций меняет только триггер, в том числе при каскадном удалении пользователя,
+-- которое обходит код приложения. При замене типа строки счетчиков блокируются в порядке типов,
+-- поэтому параллельные замены в противоположных направлениях не взаимоблокируются.
+CREATE OR REPLACE FUNCTION pin_reaction_count_increase(pin INT, reaction_type TEXT) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO pin_reaction_count (pin_id, type, count) VALUES (pin, reaction_type, 1)
+    ON CONFLICT (pin_id, type) DO UPDATE SET count = pin_reaction_count.count + 1;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION pin_reaction_count_decrease(pin INT, reaction_type TEXT) RETURNS VOID AS $$
+BEGIN
+    UPDATE pin_reaction_count SET count = count - 1 WHERE pin_id = pin AND type = reaction_type;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION pin_reaction_count_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pin_reaction_count_increase(NEW.pin_id, NEW.type);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM pin_reaction_count_decrease(OLD.pin_id, OLD.type);
+    ELSIF OLD.type = NEW.type THEN
+        RETURN NULL;
+    ELSIF OLD.type < NEW.type THEN
+        PERFORM pin_reaction_count_decrease(OLD.pin_id, OLD.type);
+        PERFORM pin_reaction_count_increase(NEW.pin_id, NEW.type);
+    ELSE
+        PERFORM pin_reaction_count_increase(NEW.pin_id, NEW.type);
+        PERFORM pin_reaction_count_decrease(OLD.pin_id, OLD.type);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pin_reaction_count
+    AFTER INSERT OR DELETE OR UPDATE OF type ON pin_reaction
+    FOR EACH ROW EXECUTE FUNCTION pin_reaction_count_trigger();
+
+-- Счетчики, разошедшиеся с реакциями до появления триггера, пересчитываются
+UPDATE pin_reaction_count c SET count = (
+    SELECT COUNT(*) FROM pin_reaction r WHERE r.pin_id = c.pin_id AND r.type = c.type
+);
@@ -0,0 +1,213 @@
+package delivery
+
+import (
+	"fmt"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"strconv"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/gorilla/mux"
+)
+
+type PinLinkClickResponse struct {
+	RelatedLink string `json:"related_link"`
+}
+
+// ClickPinLink handles POST /pins/{pin_id}/link-click, the client follows the returned link
+func (mdc *MediaDeliveryController) ClickPinLink(w http.ResponseWriter, r *http.Request) {
+	pinID, err := strconv.ParseUint(mux.Vars(r)["pin_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadPinInputData,
+		})
+		return
+	}
+
+	currUserID, _ := r.Context().Value(configs.UserIdKey).(uint64)
+	link, err := mdc.Usecase.RecordPinLinkClick(pinID, currUserID, pinViewer(r, currUserID))
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, PinLinkClickResponse{RelatedLink: link})
+}
+
+// GetPinAnalytics handles GET /me/analytics/pins/{pin_id}?from=&to=&days=
+func (mdc *MediaDeliveryController) GetPinAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	pinID, err := strconv.ParseUint(mux.Vars(r)["pin_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadPinInputData,
+		})
+		return
+	}
+	statsRange, err := parseAnalyticsRange(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrAnalyticsParamsInvalid,
+		})
+		return
+	}
+
+	analytics, err := mdc.Usecase.GetPinAnalytics(pinID, userID, statsRange)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, analytics)
+}
+
+// GetBoardAnalytics handles GET /me/analytics/boards/{board_id}?from=&to=&days=
+func (mdc *MediaDeliveryController) GetBoardAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	boardID, err := strconv.ParseUint(mux.Vars(r)["board_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadBoardInputData,
+		})
+		return
+	}
+	statsRange, err := parseAnalyticsRange(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrAnalyticsParamsInvalid,
+		})
+		return
+	}
+
+	analytics, err := mdc.Usecase.GetBoardAnalytics(boardID, userID, statsRange)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, analytics)
+}
+
+// GetTopPins handles GET /me/analytics/top-pins?from=&to=&days=&metric=&limit=
+func (mdc *MediaDeliveryController) GetTopPins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	params, err := parseTopPinsParams(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrAnalyticsParamsInvalid,
+		})
+		return
+	}
+	params.AuthorID = userID
+
+	pins, err := mdc.Usecase.GetTopPins(params)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, pins)
+}
+
+// GetFollowerGrowth handles GET /me/analytics/followers?from=&to=&days=
+func (mdc *MediaDeliveryController) GetFollowerGrowth(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	statsRange, err := parseAnalyticsRange(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrAnalyticsParamsInvalid,
+		})
+		return
+	}
+
+	growth, err := mdc.Usecase.GetFollowerGrowth(userID, statsRange)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, growth)
+}
+
+// parseAnalyticsRange takes the range either from the from and to days or as the last days ending today,
+// the last 30 days by default.
+func parseAnalyticsRange(r *http.Request) (models.AnalyticsRange, error) {
+	query := r.URL.Query()
+
+	rawFrom, rawTo := query.Get("from"), query.Get("to")
+	if rawFrom == "" && rawTo == "" {
+		days := models.DefaultAnalyticsRangeDays
+		if rawDays := query.Get("days"); rawDays != "" {
+			var err error
+			if days, err = strconv.Atoi(rawDays); err != nil {
+				return models.AnalyticsRange{}, fmt.Errorf("parse days: %w", err)
+			}
+			if days <= 0 {
+				return models.AnalyticsRange{}, fmt.Errorf("days must be positive, got %d", days)
+			}
+		}
+		return models.NewAnalyticsRange(days), nil
+	}
+
+	from, err := time.Parse(models.AnalyticsDayLayout, rawFrom)
+	if err != nil {
+		return models.AnalyticsRange{}, fmt.Errorf("parse from: %w", err)
+	}
+	to, err := time.Parse(models.AnalyticsDayLayout, rawTo)
+	if err != nil {
+		return models.AnalyticsRange{}, fmt.Errorf("parse to: %w", err)
+	}
+	return models.AnalyticsRange{From: from, To: to}, nil
+}
+
+func parseTopPinsParams(r *http.Request) (*models.TopPinsParams, error) {
+	statsRange, err := parseAnalyticsRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = models.AnalyticsMetricViews
+	}
+
+	limit := models.DefaultTopPins
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		if limit, err = strconv.Atoi(rawLimit); err != nil {
+			return nil, fmt.Errorf("parse limit: %w", err)
+		}
+	}
+
+	return &models.TopPinsParams{Range: statsRange, Metric: metric, Limit: limit}, nil
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models/response"
+	internal_errors "pinset/internal/errors"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	successfullBlockMessage   = "user successfully blocked"
+	successfullUnblockMessage = "user successfully unblocked"
+)
+
+func (udc *UserDeliveryController) BlockUser(w http.ResponseWriter, r *http.Request) {
+	currUserID, userID, ok := udc.parseBlockRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := udc.Usecase.BlockUser(currUserID, userID); err != nil {
+		udc.sendUsecaseError(w, err)
+		return
+	}
+
+	SendInfoResponse(w, udc.Logger, response.ResponseInfo{
+		Message: successfullBlockMessage,
+	})
+}
+
+func (udc *UserDeliveryController) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	currUserID, userID, ok := udc.parseBlockRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := udc.Usecase.UnblockUser(currUserID, userID); err != nil {
+		udc.sendUsecaseError(w, err)
+		return
+	}
+
+	SendInfoResponse(w, udc.Logger, response.ResponseInfo{
+		Message: successfullUnblockMessage,
+	})
+}
+
+func (udc *UserDeliveryController) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	currUserID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	blockedUsers, err := udc.Usecase.GetBlockedUsers(currUserID)
+	if err != nil {
+		udc.sendUsecaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(blockedUsers); err != nil {
+		internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInternalServerError,
+		})
+		return
+	}
+}
+
+// parseBlockRequest extracts the current user and the {user_id} route variable.
+// The error response is already sent when ok is false.
+func (udc *UserDeliveryController) parseBlockRequest(w http.ResponseWriter, r *http.Request) (currUserID, userID uint64, ok bool) {
+	currUserID, ok = r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return 0, 0, false
+	}
+
+	userID, err := strconv.ParseUint(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return 0, 0, false
+	}
+
+	return currUserID, userID, true
+}
+
+func (udc *UserDeliveryController) sendUsecaseError(w http.ResponseWriter, err error) {
+	if internal_errors.IsInternal(err) {
+		internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+
+	internal_errors.SendErrorResponse(w, udc.Logger, internal_errors.ErrorInfo{
+		General: err, Internal: internal_errors.ErrInternalServerError,
+	})
+}
@@ -0,0 +1,330 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"pinset/internal/app/models/response"
+	internal_errors "pinset/internal/errors"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	successfullChatUpdateMessage  = "chat successfully updated"
+	successfullMemberAddMessage   = "user successfully added to chat"
+	successfullMemberDelMessage   = "user successfully removed from chat"
+	successfullChatLeaveMessage   = "chat successfully left"
+	successfullMemberRoleMessage  = "chat member role successfully updated"
+	successfullChatCreatedMessage = "chat successfully created"
+)
+
+func (mdc *MessageDelieveryController) CreateGroupChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	var req models.GroupChatCreateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+
+	req.Sanitize()
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+	req.CreatorID = userID
+
+	chatInfo, systemMessage, err := mdc.Usecase.CreateGroupChat(&req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	if err := mdc.broadcastChatMessage(systemMessage); err != nil {
+		mdc.Logger.Printf("failed to notify chat members %v", err)
+	}
+
+	mdc.Logger.WithField("chat_id", chatInfo.ChatID).Info(successfullChatCreatedMessage)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(chatInfo); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInternalServerError,
+		})
+		return
+	}
+}
+
+func (mdc *MessageDelieveryController) GetChatInfo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	chatInfo, err := mdc.Usecase.GetChatInfo(userID, chatID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(chatInfo); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInternalServerError,
+		})
+		return
+	}
+}
+
+func (mdc *MessageDelieveryController) UpdateChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	var req models.ChatUpdateRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+
+	req.Sanitize()
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+	req.ChatID = chatID
+	req.UserID = userID
+
+	systemMessage, err := mdc.Usecase.UpdateChatInfo(&req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	if err := mdc.broadcastChatMessage(systemMessage); err != nil {
+		mdc.Logger.Printf("failed to notify chat members %v", err)
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullChatUpdateMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) AddChatMember(w http.ResponseWriter, r *http.Request) {
+	actorID, chatID, userID, ok := mdc.parseChatMemberRequest(w, r)
+	if !ok {
+		return
+	}
+
+	systemMessage, err := mdc.Usecase.AddChatMember(actorID, chatID, userID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	if err := mdc.broadcastChatMessage(systemMessage); err != nil {
+		mdc.Logger.Printf("failed to notify chat members %v", err)
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullMemberAddMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) RemoveChatMember(w http.ResponseWriter, r *http.Request) {
+	actorID, chatID, userID, ok := mdc.parseChatMemberRequest(w, r)
+	if !ok {
+		return
+	}
+
+	systemMessage, err := mdc.Usecase.RemoveChatMember(actorID, chatID, userID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	if err := mdc.broadcastChatMessage(systemMessage, userID); err != nil {
+		mdc.Logger.Printf("failed to notify chat members %v", err)
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullMemberDelMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) LeaveChat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	systemMessages, err := mdc.Usecase.LeaveChat(userID, chatID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	for _, systemMessage := range systemMessages {
+		if err := mdc.broadcastChatMessage(systemMessage, userID); err != nil {
+			mdc.Logger.Printf("failed to notify chat members %v", err)
+		}
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullChatLeaveMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) AddChatAdmin(w http.ResponseWriter, r *http.Request) {
+	mdc.setChatMemberRole(w, r, models.ChatRoleAdmin)
+}
+
+func (mdc *MessageDelieveryController) RemoveChatAdmin(w http.ResponseWriter, r *http.Request) {
+	mdc.setChatMemberRole(w, r, models.ChatRoleMember)
+}
+
+func (mdc *MessageDelieveryController) setChatMemberRole(w http.ResponseWriter, r *http.Request, role string) {
+	actorID, chatID, userID, ok := mdc.parseChatMemberRequest(w, r)
+	if !ok {
+		return
+	}
+
+	systemMessage, err := mdc.Usecase.SetChatMemberRole(actorID, chatID, userID, role)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	// Role hasn't changed, nothing to tell about
+	if systemMessage != nil {
+		if err := mdc.broadcastChatMessage(systemMessage); err != nil {
+			mdc.Logger.Printf("failed to notify chat members %v", err)
+		}
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullMemberRoleMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) UploadChatAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	if errInfo := parseUploadForm(w, r, mdc.UploadParams); errInfo.Internal != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, errInfo)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	// fileHeaders are accessible only after ParseMultipartForm is called
+	files := r.MultipartForm.File["file"]
+	mediaUrls, err := mdc.Usecase.UploadChatAttachments(userID, chatID, files)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	SendMediaUploadResponse(w, mdc.Logger, response.MediaUploadResponse{
+		Urls:    mediaUrls,
+		Message: successfullUploadMessage,
+	})
+}
+
+// parseChatMemberRequest extracts the current user and the {chat_id}, {user_id} route variables.
+// The error response is already sent when ok is false.
+func (mdc *MessageDelieveryController) parseChatMemberRequest(w http.ResponseWriter, r *http.Request) (actorID, chatID, userID uint64, ok bool) {
+	actorID, ok = r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return 0, 0, 0, false
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return 0, 0, 0, false
+	}
+
+	userID, err = strconv.ParseUint(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return 0, 0, 0, false
+	}
+
+	return actorID, chatID, userID, true
+}
@@ -1,7 +1,9 @@
 package delivery
 
 import (
+	"context"
 	"mime/multipart"
+	"pinset/configs"
 	"pinset/internal/app/models"
 	"pinset/internal/app/models/request"
 	"pinset/internal/app/models/response"
@@ -21,39 +23,58 @@ type (
 		GetUserInfo(*models.User, uint64) (*models.UserProfile, error)
 		GetUserInfoPublic(uint64) (*response.UserProfileResponse, error)
 		UpdateUserInfo(*models.User) error
-		GetUsersByParams(*models.UserSearchParams) ([]*models.UserInfo, error)
+		GetUsersByParams(callerID uint64, userParams *models.UserSearchParams) ([]*models.UserInfo, error)
 		GetCompanionsForUser(uint64, *models.UserSearchParams) ([]*models.UserInfo, error)
+
+		BlockUser(blockerID, blockedID uint64) error
+		UnblockUser(blockerID, blockedID uint64) error
+		GetBlockedUsers(blockerID uint64) ([]*models.BlockedUser, error)
 	}
 
 	MediaUsecase interface {
-		UploadMedia(files []*multipart.FileHeader) ([]string, error)
+		UploadMedia(ownerID uint64, files []*multipart.FileHeader) ([]string, error)
+		PresignUpload(userID uint64, req *models.UploadPresignRequest) (*models.PresignedUpload, error)
+		CompleteUpload(userID uint64, uploadID string) (*models.Upload, error)
+		PreviewLink(ctx context.Context, pageUrl string) (*models.LinkPreview, error)
+		CreatePinDraftFromUrl(ctx context.Context, authorID uint64, req *models.PinFromUrlRequest) (*models.PinDraft, error)
 
 		Feed(uint64) ([]*models.Pin, error)
-		GetPinPreviewInfo(pinID uint64) (*models.Pin, error)
-		GetPinPageInfo(pinID uint64) (*models.Pin, error)
+		GetDrafts(userID uint64) ([]*models.Pin, error)
+		GetPinPreviewInfo(pinID uint64, currUserID uint64) (*models.Pin, error)
+		GetPinPageInfo(pinID uint64, currUserID uint64) (*models.Pin, error)
 		GetPinAuthorNickNameByUserID(userID uint64) (*models.UserPin, error)
-		GetAllCommentaries(pinID uint64) ([]*models.Comment, error)
+		GetAllCommentaries(pinID uint64, currUserID uint64) ([]*models.Comment, error)
 		CreatePin(pin *models.Pin) error
+		GetSimilarPins(pinID uint64) ([]uint64, error)
 		UpdatePinInfo(pin *models.Pin) error
-		UpdatePinViewsNumber(pinID uint64) error
+		RecordPinView(pinID, currUserID uint64, viewer string) error
+		RecordPinLinkClick(pinID, currUserID uint64, viewer string) (string, error)
+		GetPinAnalytics(pinID, userID uint64, statsRange models.AnalyticsRange) (*models.PinAnalytics, error)
+		GetBoardAnalytics(boardID, userID uint64, statsRange models.AnalyticsRange) (*models.BoardAnalytics, error)
+		GetTopPins(params *models.TopPinsParams) ([]*models.TopPin, error)
+		GetFollowerGrowth(userID uint64, statsRange models.AnalyticsRange) (*models.FollowerGrowth, error)
 		DeletePinByPinID(pinID uint64) error
 
-		GetBoardPins(boardID uint64) ([]*models.Pin, error)
+		GetBoardPins(boardID uint64, currUserID uint64) ([]*models.Pin, error)
 		AddPinToBoard(boardID uint64, pinID uint64) error
+		SavePin(req *models.PinSaveRequest) (*models.PinSave, error)
+		GetPinSavedFrom(pinID, boardID, currUserID uint64) ([]*models.PinSaveLink, error)
+		TogglePinReaction(pinID, userID uint64, req *models.PinReactionRequest) (*models.PinReactions, error)
 		DeletePinFromBoard(boardID uint64, pinID uint64) error
 
 		GetBookmarkOnUserPin(ownerID, pinID uint64) (uint64, error)
+		GetBookmarks(params *models.BookmarksPageParams) (*models.BookmarksPage, error)
 		CreatePinBookmark(bookmark *models.Bookmark) error
 		GetPinBookmarksNumber(pinID uint64) (uint64, error)
 		DeletePinBookmarkByOwnerIDAndPinID(bookmark models.Bookmark) error
-		UpdateBookmarksCountIncrease(pinID uint64) error
-		UpdateBookmarksCountDecrease(pinID uint64) error
 
 		GetAllUserBoards(ownerID uint64, currUserID uint64) ([]*models.Board, error)
-		GetBoard(boardID uint64) (*models.Board, error)
+		GetBoard(boardID uint64, currUserID uint64) (*models.Board, error)
 		CreateBoard(board *models.Board) error
 		UpdateBoard(board *models.Board) error
 		DeleteBoard(boardID uint64) error
+
+		GetNotifications(userID uint64) ([]*models.Notification, error)
 	}
 
 	MessageUsecase interface {
@@ -63,12 +84,29 @@ type (
 		DeleteOnlineUser(userID uint64)
 		NumUsersOnline() int
 
-		GetChatMessages(chatID uint64) ([]*models.MessageInfo, error)
+		ConnectUser(user *models.ChatUser) error
+		DisconnectUser(user *models.ChatUser) (bool, error)
+		GetPresenceReceivers(userID uint64) (*models.Presence, []uint64, error)
+		GetUsersPresence(viewerID uint64, userIDs []uint64) ([]*models.Presence, error)
+		UpdatePresenceSettings(userID uint64, settings *models.PresenceSettings) error
+
+		GetChatMessages(userID, chatID uint64, params *models.MessagesPageParams) ([]*models.MessageInfo, error)
+		GetChatMessagesAround(userID, chatID, messageID uint64, limit int) ([]*models.MessageInfo, error)
+		SearchMessages(params *models.MessageSearchParams) ([]*models.MessageInfo, error)
 		AddChatMessage(message *models.Message) (*models.MessageCreateInfo, error)
+		UploadChatAttachments(userID, chatID uint64, files []*multipart.FileHeader) ([]string, error)
 		GetChatUsers(chatID uint64) ([]uint64, error)
+		GetMessageReceivers(message *models.MessageCreateInfo) ([]uint64, error)
 		GetUserChats(userID uint64) ([]*models.ChatInfo, error)
 
 		CreateChat(req *models.ChatCreateRequest) (*models.ChatInfo, error)
+		CreateGroupChat(req *models.GroupChatCreateRequest) (*models.ChatInfo, *models.MessageCreateInfo, error)
+		GetChatInfo(userID, chatID uint64) (*models.ChatInfo, error)
+		UpdateChatInfo(req *models.ChatUpdateRequest) (*models.MessageCreateInfo, error)
+		AddChatMember(actorID, chatID, userID uint64) (*models.MessageCreateInfo, error)
+		RemoveChatMember(actorID, chatID, userID uint64) (*models.MessageCreateInfo, error)
+		LeaveChat(userID, chatID uint64) ([]*models.MessageCreateInfo, error)
+		SetChatMemberRole(actorID, chatID, userID uint64, role string) (*models.MessageCreateInfo, error)
 	}
 )
 
@@ -80,13 +118,15 @@ type (
 	}
 
 	MediaDeliveryController struct {
-		Usecase MediaUsecase
-		Logger  *logrus.Logger
+		Usecase      MediaUsecase
+		Logger       *logrus.Logger
+		UploadParams configs.UploadParams
 	}
 
 	MessageDelieveryController struct {
-		Usecase  MessageUsecase
-		Logger   *logrus.Logger
-		Upgrader websocket.Upgrader
+		Usecase      MessageUsecase
+		Logger       *logrus.Logger
+		Upgrader     websocket.Upgrader
+		UploadParams configs.UploadParams
 	}
 )
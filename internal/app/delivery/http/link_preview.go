@@ -0,0 +1,75 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PreviewLink handles POST /pins/from-url/preview
+func (mdc *MediaDeliveryController) PreviewLink(w http.ResponseWriter, r *http.Request) {
+	var req models.LinkPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+
+	preview, err := mdc.Usecase.PreviewLink(r.Context(), req.Url)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, preview)
+}
+
+// CreatePinDraftFromUrl handles POST /pins/from-url
+func (mdc *MediaDeliveryController) CreatePinDraftFromUrl(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	var req models.PinFromUrlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+
+	draft, err := mdc.Usecase.CreatePinDraftFromUrl(r.Context(), userID, &req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.Logger.WithFields(logrus.Fields{
+		"related_link": draft.RelatedLink,
+		"media_url":    draft.MediaUrl,
+	}).Info("Pin draft saved from url")
+
+	mdc.sendJSON(w, draft)
+}
@@ -1,13 +1,14 @@
 package delivery
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"mime"
+	"net"
 	"net/http"
 	"pinset/configs"
-	"pinset/pkg/utils"
 	"strconv"
 	"strings"
 
@@ -39,18 +40,18 @@ const (
 var lastUploadedMediaUrl string
 
 func (mdc *MediaDeliveryController) UploadMedia(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseMultipartForm(32 * utils.MiB)
-	if err != nil {
-		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrInternalServerError,
-		})
+	if errInfo := parseUploadForm(w, r, mdc.UploadParams); errInfo.Internal != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, errInfo)
 		return
 	}
+	defer r.MultipartForm.RemoveAll()
+
+	// Anonymous uploads are registered without an owner
+	userID, _ := r.Context().Value(configs.UserIdKey).(uint64)
 
 	// fileHeaders are accessible only after ParseMultipartForm is called
 	files := r.MultipartForm.File["file"]
-	mediaUrls, err := mdc.Usecase.UploadMedia(files)
-	lastUploadedMediaUrl = mediaUrls[len(mediaUrls)-1]
+	mediaUrls, err := mdc.Usecase.UploadMedia(userID, files)
 	if err != nil {
 		if internal_errors.IsInternal(err) {
 			internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
@@ -64,6 +65,10 @@ func (mdc *MediaDeliveryController) UploadMedia(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if len(mediaUrls) > 0 {
+		lastUploadedMediaUrl = mediaUrls[len(mediaUrls)-1]
+	}
+
 	mdc.Logger.WithFields(logrus.Fields{
 		"files": mediaUrls,
 	}).Info("Upload successfull")
@@ -101,6 +106,25 @@ func (mdc *MediaDeliveryController) Feed(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// GetDrafts handles GET /me/drafts
+func (mdc *MediaDeliveryController) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	drafts, err := mdc.Usecase.GetDrafts(userID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, drafts)
+}
+
 func (mdc *MediaDeliveryController) CreatePin(w http.ResponseWriter, r *http.Request) {
 	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
@@ -124,6 +148,9 @@ func (mdc *MediaDeliveryController) CreatePin(w http.ResponseWriter, r *http.Req
 		}
 
 		pin.Sanitize()
+		if userID, ok := r.Context().Value(configs.UserIdKey).(uint64); ok {
+			pin.AuthorID = userID
+		}
 		// pin.MediaUrl = lastUploadedMediaUrl
 		// pin.RelatedLink = lastUploadedMediaUrl
 
@@ -133,9 +160,7 @@ func (mdc *MediaDeliveryController) CreatePin(w http.ResponseWriter, r *http.Req
 
 		err = mdc.Usecase.CreatePin(&pin)
 		if err != nil {
-			internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-				General: err, Internal: fmt.Errorf("usecase CreatePin: %w", err),
-			})
+			mdc.sendUsecaseError(w, err)
 			return
 		}
 
@@ -143,9 +168,16 @@ func (mdc *MediaDeliveryController) CreatePin(w http.ResponseWriter, r *http.Req
 			"pin_id": pin.PinID,
 		}).Info("Pin created successfully")
 
+		// The pin is already created, near-duplicates are only a hint
+		similarPinIDs, err := mdc.Usecase.GetSimilarPins(pin.PinID)
+		if err != nil {
+			mdc.Logger.WithError(err).Error("failed to find similar pins")
+		}
+
 		SendPinCreatedResponse(w, mdc.Logger, response.PinCreatedResponse{
-			PinID:   pin.PinID,
-			Message: successfullPinCreationMessage,
+			PinID:         pin.PinID,
+			SimilarPinIDs: similarPinIDs,
+			Message:       successfullPinCreationMessage,
 		})
 	}
 }
@@ -161,7 +193,12 @@ func (mdc *MediaDeliveryController) GetPinPreview(w http.ResponseWriter, r *http
 		return
 	}
 
-	pin, err := mdc.Usecase.GetPinPreviewInfo(pinID)
+	currUserID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		currUserID = 0
+	}
+
+	pin, err := mdc.Usecase.GetPinPreviewInfo(pinID, currUserID)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			Internal: err,
@@ -196,8 +233,19 @@ func (mdc *MediaDeliveryController) GetPinPreview(w http.ResponseWriter, r *http
 		AuthorAvatarUrl:       *author.AvatarUrl,
 		AuthorFollowersNumber: 0,
 		MediaUrl:              *pin.MediaUrl,
+		MediaType:             pin.MediaType,
+		MediaWidth:            pin.MediaWidth,
+		MediaHeight:           pin.MediaHeight,
+		MediaDuration:         pin.MediaDuration,
+		MediaWaveform:         pin.MediaWaveform,
+		MediaVariants:         pin.MediaVariants,
+		MediaColor:            pin.MediaColor,
+		MediaBlurhash:         pin.MediaBlurhash,
+		PosterUrl:             pin.PosterUrl,
+		MediaCount:            pin.MediaCount,
 		ViewsNumber:           pin.Views,
 		BookmarksNumber:       bookmarksNumber,
+		IsBookmarked:          pin.IsBookmarked,
 	})
 }
 
@@ -212,7 +260,12 @@ func (mdc *MediaDeliveryController) GetPinPage(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	pin, err := mdc.Usecase.GetPinPageInfo(pinID)
+	currUserID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		currUserID = 0
+	}
+
+	pin, err := mdc.Usecase.GetPinPageInfo(pinID, currUserID)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			Internal: err,
@@ -220,6 +273,23 @@ func (mdc *MediaDeliveryController) GetPinPage(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// The attribution is shown when the pin is opened from a board
+	var savedFrom []*models.PinSaveLink
+	if boardIDStr := r.URL.Query().Get("board_id"); boardIDStr != "" {
+		boardID, err := strconv.ParseUint(boardIDStr, 10, 64)
+		if err != nil {
+			internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+				General: err, Internal: internal_errors.ErrBadBoardInputData,
+			})
+			return
+		}
+		savedFrom, err = mdc.Usecase.GetPinSavedFrom(pinID, boardID, currUserID)
+		if err != nil {
+			mdc.sendUsecaseError(w, err)
+			return
+		}
+	}
+
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
@@ -240,10 +310,25 @@ func (mdc *MediaDeliveryController) GetPinPage(w http.ResponseWriter, r *http.Re
 		AuthorAvatarUrl:       *author.AvatarUrl,
 		AuthorFollowersNumber: 0,
 		MediaUrl:              *pin.MediaUrl,
+		MediaType:             pin.MediaType,
+		MediaWidth:            pin.MediaWidth,
+		MediaHeight:           pin.MediaHeight,
+		MediaDuration:         pin.MediaDuration,
+		MediaWaveform:         pin.MediaWaveform,
+		MediaVariants:         pin.MediaVariants,
+		MediaColor:            pin.MediaColor,
+		MediaBlurhash:         pin.MediaBlurhash,
+		PosterUrl:             pin.PosterUrl,
+		Carousel:              carouselResponse(pin.Carousel),
 		Title:                 *pin.Title,
 		Description:           *pin.Description,
 		RelatedLink:           *pin.RelatedLink,
 		Geolocation:           *pin.Geolocation,
+		Saves:                 pin.Saves,
+		IsBookmarked:          pin.IsBookmarked,
+		Reactions:             pin.Counts,
+		MyReaction:            pin.MyReaction,
+		SavedFrom:             savedFromResponse(savedFrom),
 		CreationTime:          pin.CreationTime,
 	})
 }
@@ -258,7 +343,12 @@ func (mdc *MediaDeliveryController) GetBoardPins(w http.ResponseWriter, r *http.
 		return
 	}
 
-	pins, err := mdc.Usecase.GetBoardPins(boardID)
+	currUserID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		currUserID = 0
+	}
+
+	pins, err := mdc.Usecase.GetBoardPins(boardID, currUserID)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			Internal: err,
@@ -336,20 +426,43 @@ func (mdc *MediaDeliveryController) UpdatePin(w http.ResponseWriter, r *http.Req
 			return
 		}
 
-		err = mdc.Usecase.UpdatePinInfo(&models.Pin{
+		pin := &models.Pin{
 			PinID:       req.PinID,
 			Title:       &req.Title,
 			Description: &req.Description,
 			RelatedLink: &req.RelatedLink,
 			BoardID:     req.BoardID,
 			Geolocation: &req.Geolocation,
-			MediaUrl:    &lastUploadedMediaUrl,
-		})
+			Status:      req.Status,
+			PublishAt:   req.PublishAt,
+			PinMedia: models.PinMedia{
+				MediaUrl:  &lastUploadedMediaUrl,
+				PosterUrl: req.PosterUrl,
+			},
+		}
+		// Only the author may update the pin, uploads of the carousel are resolved for them
+		if userID, ok := r.Context().Value(configs.UserIdKey).(uint64); ok {
+			pin.AuthorID = userID
+		}
+		if req.Carousel != nil {
+			pin.Carousel = make([]*models.PinMedia, 0, len(req.Carousel))
+			for _, item := range req.Carousel {
+				if item == nil {
+					internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+						Internal: internal_errors.ErrPinDataInvalid,
+					})
+					return
+				}
+				media := &models.PinMedia{MediaUrl: item.MediaUrl, UploadID: item.UploadID}
+				media.Sanitize()
+				pin.Carousel = append(pin.Carousel, media)
+			}
+		}
+
+		err = mdc.Usecase.UpdatePinInfo(pin)
 
 		if err != nil {
-			internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-				General: err, Internal: internal_errors.ErrInternalServerError,
-			})
+			mdc.sendUsecaseError(w, err)
 			return
 		}
 
@@ -382,12 +495,12 @@ func (mdc *MediaDeliveryController) DeletePin(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// GetBookmark handles GET /bookmark/{pin_id}, the bookmark of the caller on the pin
 func (mdc *MediaDeliveryController) GetBookmark(w http.ResponseWriter, r *http.Request) {
-	userIDStr := mux.Vars(r)["owner_id"]
-	ownerID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
+	ownerID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			Internal: internal_errors.ErrUserIsNotAuthorized,
 		})
 		return
 	}
@@ -396,16 +509,14 @@ func (mdc *MediaDeliveryController) GetBookmark(w http.ResponseWriter, r *http.R
 	pinID, err := strconv.ParseUint(pinIDStr, 10, 64)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			General: err, Internal: internal_errors.ErrBadBookmarkInputData,
 		})
 		return
 	}
 
 	bookmarkID, err := mdc.Usecase.GetBookmarkOnUserPin(ownerID, pinID)
 	if err != nil {
-		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
-		})
+		mdc.sendUsecaseError(w, err)
 		return
 	}
 
@@ -414,7 +525,16 @@ func (mdc *MediaDeliveryController) GetBookmark(w http.ResponseWriter, r *http.R
 	})
 }
 
+// CreateBookmark handles POST /create-bookmark, the bookmark is created for the caller
 func (mdc *MediaDeliveryController) CreateBookmark(w http.ResponseWriter, r *http.Request) {
+	ownerID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
 	var bookmark models.Bookmark
 	err := json.NewDecoder(r.Body).Decode(&bookmark)
 	if err != nil {
@@ -423,68 +543,141 @@ func (mdc *MediaDeliveryController) CreateBookmark(w http.ResponseWriter, r *htt
 		})
 		return
 	}
-
-	err = mdc.Usecase.CreatePinBookmark(&bookmark)
-	if err != nil && !errors.Is(err, internal_errors.ErrBookmarkDoesntExists) {
+	if bookmark.PinID == 0 {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			Internal: internal_errors.ErrBadBookmarkInputData,
 		})
 		return
 	}
+	bookmark.OwnerID = ownerID
+
+	err = mdc.Usecase.CreatePinBookmark(&bookmark)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
 
 	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
 		Message: successfullBookmarkCreateMessage,
 	})
 }
 
+// DeleteBookmark handles DELETE /bookmark/delete/{pin_id}, the bookmark of the caller on the pin
 func (mdc *MediaDeliveryController) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
-	var bookmark models.Bookmark
-	err := json.NewDecoder(r.Body).Decode(&bookmark)
-	if err != nil {
+	ownerID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+			Internal: internal_errors.ErrUserIsNotAuthorized,
 		})
 		return
 	}
 
-	err = mdc.Usecase.DeletePinBookmarkByOwnerIDAndPinID(bookmark)
+	pinID, err := strconv.ParseUint(mux.Vars(r)["pin_id"], 10, 64)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			General: err, Internal: internal_errors.ErrBadBookmarkInputData,
 		})
 		return
 	}
 
+	err = mdc.Usecase.DeletePinBookmarkByOwnerIDAndPinID(models.Bookmark{OwnerID: ownerID, PinID: pinID})
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
 	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
 		Message: successfullBookmarkDeletionMessage,
 	})
 }
 
-func (mdc *MediaDeliveryController) ViewPin(w http.ResponseWriter, r *http.Request) {
-	pinIDStr := mux.Vars(r)["pin_id"]
-	pinID, err := strconv.ParseUint(pinIDStr, 10, 64)
+// GetMyBookmarks handles GET /me/bookmarks?cursor=&limit=, cursor is next_cursor of the previous page
+func (mdc *MediaDeliveryController) GetMyBookmarks(w http.ResponseWriter, r *http.Request) {
+	ownerID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
 
-	fmt.Println("ViewPin", err)
+	params, err := parseBookmarksPageParams(r)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			General: err, Internal: internal_errors.ErrBookmarksPageInvalid,
 		})
 		return
 	}
+	params.OwnerID = ownerID
 
-	err = mdc.Usecase.UpdatePinViewsNumber(pinID)
+	page, err := mdc.Usecase.GetBookmarks(params)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, page)
+}
+
+func parseBookmarksPageParams(r *http.Request) (*models.BookmarksPageParams, error) {
+	query := r.URL.Query()
+	params := &models.BookmarksPageParams{Limit: models.DefaultBookmarksPageSize}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		before, err := models.ParseBookmarksCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.Before = before
+	}
+
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil {
+			return nil, fmt.Errorf("parse limit: %w", err)
+		}
+		params.Limit = limit
+	}
+
+	return params, nil
+}
+
+func (mdc *MediaDeliveryController) ViewPin(w http.ResponseWriter, r *http.Request) {
+	pinIDStr := mux.Vars(r)["pin_id"]
+	pinID, err := strconv.ParseUint(pinIDStr, 10, 64)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			General: err, Internal: internal_errors.ErrBadPinInputData,
 		})
 		return
 	}
 
+	currUserID, _ := r.Context().Value(configs.UserIdKey).(uint64)
+	if err := mdc.Usecase.RecordPinView(pinID, currUserID, pinViewer(r, currUserID)); err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
 	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
 		Message: succesfullViewedPin,
 	})
 }
 
+// pinViewer identifies who viewed a pin or clicked its link: the user if authorized,
+// otherwise a fingerprint of the client address and user agent.
+func pinViewer(r *http.Request, currUserID uint64) string {
+	if currUserID != 0 {
+		return "user:" + strconv.FormatUint(currUserID, 10)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	fingerprint := sha256.Sum256([]byte(host + "\n" + r.UserAgent()))
+	return "anon:" + hex.EncodeToString(fingerprint[:16])
+}
+
 /////////////////// BOARDS ///////////////////
 
 func (mdc *MediaDeliveryController) GetUserBoards(w http.ResponseWriter, r *http.Request) {
@@ -531,7 +724,12 @@ func (mdc *MediaDeliveryController) GetBoard(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	board, err := mdc.Usecase.GetBoard(boardID)
+	currUserID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		currUserID = 0
+	}
+
+	board, err := mdc.Usecase.GetBoard(boardID, currUserID)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			Internal: err,
@@ -704,3 +902,43 @@ func (mdc *MediaDeliveryController) DeletePinFromBoard(w http.ResponseWriter, r
 		Message: successfullUpdateMessage,
 	})
 }
+
+func carouselResponse(carousel []*models.PinMedia) []*response.PinMedia {
+	if len(carousel) == 0 {
+		return nil
+	}
+
+	items := make([]*response.PinMedia, 0, len(carousel))
+	for _, item := range carousel {
+		items = append(items, &response.PinMedia{
+			MediaUrl:      *item.MediaUrl,
+			MediaType:     item.MediaType,
+			MediaWidth:    item.MediaWidth,
+			MediaHeight:   item.MediaHeight,
+			MediaDuration: item.MediaDuration,
+			MediaWaveform: item.MediaWaveform,
+			MediaVariants: item.MediaVariants,
+			MediaColor:    item.MediaColor,
+			MediaBlurhash: item.MediaBlurhash,
+			PosterUrl:     item.PosterUrl,
+		})
+	}
+	return items
+}
+
+func savedFromResponse(links []*models.PinSaveLink) []*response.PinSaveLink {
+	if len(links) == 0 {
+		return nil
+	}
+
+	items := make([]*response.PinSaveLink, 0, len(links))
+	for _, link := range links {
+		items = append(items, &response.PinSaveLink{
+			UserID:    link.UserID,
+			NickName:  link.NickName,
+			BoardID:   link.BoardID,
+			BoardName: link.BoardName,
+		})
+	}
+	return items
+}
@@ -0,0 +1,167 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetChatMessagesAround handles GET /chat/{chat_id}/messages/{message_id}/around?limit=
+func (mdc *MessageDelieveryController) GetChatMessagesAround(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	chatID, err := strconv.ParseUint(mux.Vars(r)["chat_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	messageID, err := strconv.ParseUint(mux.Vars(r)["message_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	messages, err := mdc.Usecase.GetChatMessagesAround(userID, chatID, messageID, limit)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendMessages(w, messages)
+}
+
+// SearchMessages handles GET /messages/search?q=&chat_id=&before_id=&limit=
+func (mdc *MessageDelieveryController) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	params, err := parseMessageSearchParams(r)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	params.UserID = userID
+
+	messages, err := mdc.Usecase.SearchMessages(params)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendMessages(w, messages)
+}
+
+func (mdc *MessageDelieveryController) sendMessages(w http.ResponseWriter, messages []*models.MessageInfo) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(messages); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInternalServerError,
+		})
+		return
+	}
+}
+
+// parseMessagesPageParams reads before_id, after_id and limit query parameters.
+func parseMessagesPageParams(r *http.Request) (*models.MessagesPageParams, error) {
+	beforeID, err := parseOptionalID(r, "before_id")
+	if err != nil {
+		return nil, err
+	}
+
+	afterID, err := parseOptionalID(r, "after_id")
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MessagesPageParams{BeforeID: beforeID, AfterID: afterID, Limit: limit}, nil
+}
+
+// parseMessageSearchParams reads q, chat_id, before_id and limit query parameters.
+func parseMessageSearchParams(r *http.Request) (*models.MessageSearchParams, error) {
+	chatID, err := parseOptionalID(r, "chat_id")
+	if err != nil {
+		return nil, err
+	}
+
+	beforeID, err := parseOptionalID(r, "before_id")
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MessageSearchParams{
+		Query:    r.URL.Query().Get("q"),
+		ChatID:   chatID,
+		BeforeID: beforeID,
+		Limit:    limit,
+	}, nil
+}
+
+func parseOptionalID(r *http.Request, name string) (*uint64, error) {
+	rawID := r.URL.Query().Get(name)
+	if rawID == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return &id, nil
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	rawLimit := r.URL.Query().Get("limit")
+	if rawLimit == "" {
+		return models.DefaultMessagesPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil {
+		return 0, fmt.Errorf("parse limit: %w", err)
+	}
+	return limit, nil
+}
@@ -15,19 +15,20 @@ import (
 
 func (mdc *MessageDelieveryController) HandShake(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("handshake started")
-	conn, err := mdc.Upgrader.Upgrade(w, r, nil)
-	if err != nil {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrBadRequest,
+			General: internal_errors.ErrUserIsNotAuthorized, Internal: internal_errors.ErrUserIsNotAuthorized,
 		})
 		return
 	}
 
-	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
-	if !ok {
+	conn, err := mdc.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: internal_errors.ErrUserIsNotAuthorized, Internal: internal_errors.ErrUserIsNotAuthorized,
+			General: err, Internal: internal_errors.ErrBadRequest,
 		})
+		return
 	}
 
 	fmt.Println("last ID connected ", userID)
@@ -35,7 +36,10 @@ func (mdc *MessageDelieveryController) HandShake(w http.ResponseWriter, r *http.
 
 	newChatUser := &models.ChatUser{ID: userID, Connection: conn}
 
-	mdc.Usecase.AddOnlineUser(newChatUser)
+	if err := mdc.Usecase.ConnectUser(newChatUser); err != nil {
+		mdc.Logger.Printf("failed to update user last seen %v", err)
+	}
+	mdc.broadcastPresence(userID)
 
 	go mdc.HandleConn(newChatUser)
 }
@@ -46,13 +50,12 @@ func (mdc *MessageDelieveryController) GetUserChats(w http.ResponseWriter, r *ht
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			General: internal_errors.ErrUserIsNotAuthorized, Internal: internal_errors.ErrUserIsNotAuthorized,
 		})
+		return
 	}
 	chats, err := mdc.Usecase.GetUserChats(userID)
-	fmt.Println(chats)
 	if err != nil {
-		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrInternalServerError,
-		})
+		mdc.sendUsecaseError(w, err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -66,21 +69,35 @@ func (mdc *MessageDelieveryController) GetUserChats(w http.ResponseWriter, r *ht
 }
 
 func (mdc *MessageDelieveryController) GetAllChatMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: internal_errors.ErrUserIsNotAuthorized, Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
 
 	chatIDStr := mux.Vars(r)["chat_id"]
 	chatID, err := strconv.ParseUint(chatIDStr, 10, 64)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			Internal: err,
+			General: err, Internal: internal_errors.ErrBadRequest,
 		})
 		return
 	}
 
-	messages, err := mdc.Usecase.GetChatMessages(chatID)
+	params, err := parseMessagesPageParams(r)
 	if err != nil {
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrInternalServerError,
+			General: err, Internal: internal_errors.ErrBadRequest,
 		})
+		return
+	}
+
+	messages, err := mdc.Usecase.GetChatMessages(userID, chatID, params)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -109,6 +126,7 @@ func (mdc *MessageDelieveryController) CreateChat(w http.ResponseWriter, r *http
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			General: internal_errors.ErrUserIsNotAuthorized, Internal: internal_errors.ErrUserIsNotAuthorized,
 		})
+		return
 	}
 
 	if userID == companionID {
@@ -116,15 +134,15 @@ func (mdc *MessageDelieveryController) CreateChat(w http.ResponseWriter, r *http
 		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
 			General: err, Internal: internal_errors.ErrBadRequest,
 		})
+		return
 	}
 
 	chatCreateRequest := &models.ChatCreateRequest{UserID: userID, CompanionID: companionID}
 
 	chatInfo, err := mdc.Usecase.CreateChat(chatCreateRequest)
 	if err != nil {
-		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
-			General: err, Internal: internal_errors.ErrInternalServerError,
-		})
+		mdc.sendUsecaseError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -138,7 +156,7 @@ func (mdc *MessageDelieveryController) CreateChat(w http.ResponseWriter, r *http
 }
 
 func (mdc *MessageDelieveryController) HandleConn(user *models.ChatUser) {
-	defer mdc.Usecase.DeleteOnlineUser(user.ID)
+	defer mdc.disconnectUser(user)
 	defer user.Connection.Close()
 
 	for {
@@ -162,27 +180,47 @@ func (mdc *MessageDelieveryController) HandleConn(user *models.ChatUser) {
 		messageInfo, err := mdc.Usecase.AddChatMessage(&mes)
 		if err != nil {
 			mdc.Logger.Printf("failed to add message to chat %v", err)
-			user.Connection.WriteJSON(models.WebSocketResponse{Type: "error", Data: "failed to add message to chat"})
+			user.WriteJSON(models.WebSocketResponse{Type: models.WebSocketErrorType, Data: "failed to add message to chat"})
 			continue
 		}
 
-		chatID := mes.ChatID
-		chatUserIDs, err := mdc.Usecase.GetChatUsers(chatID)
-		if err != nil {
+		fmt.Println("messageInfo", messageInfo)
+		if err := mdc.broadcastChatMessage(messageInfo); err != nil {
 			mdc.Logger.Printf("failed get chat users %v", err)
-			user.Connection.WriteJSON(models.WebSocketResponse{Type: "error", Data: "failed to get chat users"})
+			user.WriteJSON(models.WebSocketResponse{Type: models.WebSocketErrorType, Data: "failed to get chat users"})
 			continue
 		}
+	}
 
-		fmt.Println("messageInfo", messageInfo)
-		for _, reseiverID := range chatUserIDs {
-			if mdc.Usecase.IsOnlineUser(reseiverID) {
-				reseiver := mdc.Usecase.GetOnlineUser(reseiverID)
-				reseiver.Connection.WriteJSON(models.WebSocketResponse{Type: "message", Data: messageInfo})
+}
+
+// broadcastChatMessage sends the message to every online chat member
+// and to the additional receivers, e.g. the user who was just removed.
+func (mdc *MessageDelieveryController) broadcastChatMessage(messageInfo *models.MessageCreateInfo, extraReceiverIDs ...uint64) error {
+	chatUserIDs, err := mdc.Usecase.GetMessageReceivers(messageInfo)
+	if err != nil {
+		return err
+	}
 
-			}
+	for _, reseiverID := range append(chatUserIDs, extraReceiverIDs...) {
+		if mdc.Usecase.IsOnlineUser(reseiverID) {
+			reseiver := mdc.Usecase.GetOnlineUser(reseiverID)
+			reseiver.WriteJSON(models.WebSocketResponse{Type: models.WebSocketMessageType, Data: messageInfo})
 		}
+	}
+
+	return nil
+}
 
+func (mdc *MessageDelieveryController) sendUsecaseError(w http.ResponseWriter, err error) {
+	if internal_errors.IsInternal(err) {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
 	}
 
+	internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+		General: err, Internal: internal_errors.ErrInternalServerError,
+	})
 }
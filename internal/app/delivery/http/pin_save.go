@@ -0,0 +1,77 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"strconv"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// SavePin handles POST /pins/{pin_id}/save
+func (mdc *MediaDeliveryController) SavePin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	pinID, err := strconv.ParseUint(mux.Vars(r)["pin_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadPinInputData,
+		})
+		return
+	}
+
+	var req models.PinSaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+	req.PinID = pinID
+	req.SaverID = userID
+
+	save, err := mdc.Usecase.SavePin(&req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.Logger.WithFields(logrus.Fields{"pin_id": save.PinID, "board_id": save.BoardID}).Info("pin saved")
+	mdc.sendJSON(w, save)
+}
+
+// GetNotifications handles GET /me/notifications
+func (mdc *MediaDeliveryController) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	notifications, err := mdc.Usecase.GetNotifications(userID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, notifications)
+}
@@ -0,0 +1,155 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"pinset/internal/app/models/response"
+	internal_errors "pinset/internal/errors"
+	"strconv"
+	"strings"
+)
+
+const successfullPresenceSettingsMessage = "presence settings successfully updated"
+
+// GetUsersPresence handles GET /presence?user_ids=1,2,3
+func (mdc *MessageDelieveryController) GetUsersPresence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	userIDs, err := parseUserIDs(r.URL.Query().Get("user_ids"))
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadRequest,
+		})
+		return
+	}
+
+	presences, err := mdc.Usecase.GetUsersPresence(userID, userIDs)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(presences); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInternalServerError,
+		})
+		return
+	}
+}
+
+func (mdc *MessageDelieveryController) UpdatePresenceSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	var settings models.PresenceSettings
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+
+	// Companions are told the user went offline before the presence gets hidden
+	if settings.HidePresence {
+		mdc.broadcastPresenceValue(userID, &models.Presence{UserID: userID})
+	}
+
+	if err := mdc.Usecase.UpdatePresenceSettings(userID, &settings); err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	if !settings.HidePresence {
+		mdc.broadcastPresence(userID)
+	}
+
+	SendInfoResponse(w, mdc.Logger, response.ResponseInfo{
+		Message: successfullPresenceSettingsMessage,
+	})
+}
+
+func (mdc *MessageDelieveryController) disconnectUser(user *models.ChatUser) {
+	wentOffline, err := mdc.Usecase.DisconnectUser(user)
+	if err != nil {
+		mdc.Logger.Printf("failed to update user last seen %v", err)
+	}
+	if wentOffline {
+		mdc.broadcastPresence(user.ID)
+	}
+}
+
+// broadcastPresence sends the current presence of the user to the online companions.
+// Nothing is sent when the user hides presence.
+func (mdc *MessageDelieveryController) broadcastPresence(userID uint64) {
+	presence, receiverIDs, err := mdc.Usecase.GetPresenceReceivers(userID)
+	if err != nil {
+		mdc.Logger.Printf("failed to get presence receivers %v", err)
+		return
+	}
+	if presence == nil {
+		return
+	}
+
+	mdc.sendPresence(presence, receiverIDs)
+}
+
+// broadcastPresenceValue sends the given presence regardless of the user privacy setting.
+func (mdc *MessageDelieveryController) broadcastPresenceValue(userID uint64, presence *models.Presence) {
+	_, receiverIDs, err := mdc.Usecase.GetPresenceReceivers(userID)
+	if err != nil {
+		mdc.Logger.Printf("failed to get presence receivers %v", err)
+		return
+	}
+
+	mdc.sendPresence(presence, receiverIDs)
+}
+
+func (mdc *MessageDelieveryController) sendPresence(presence *models.Presence, receiverIDs []uint64) {
+	for _, receiverID := range receiverIDs {
+		receiver := mdc.Usecase.GetOnlineUser(receiverID)
+		if receiver == nil {
+			continue
+		}
+		if err := receiver.WriteJSON(models.WebSocketResponse{Type: models.WebSocketPresenceType, Data: presence}); err != nil {
+			mdc.Logger.Printf("failed to send presence %v", err)
+		}
+	}
+}
+
+func parseUserIDs(rawUserIDs string) ([]uint64, error) {
+	if rawUserIDs == "" {
+		return nil, fmt.Errorf("user_ids parameter is required")
+	}
+
+	parts := strings.Split(rawUserIDs, ",")
+	if len(parts) > models.MaxPresenceBatchSize {
+		return nil, fmt.Errorf("too many user_ids, max is %d", models.MaxPresenceBatchSize)
+	}
+
+	userIDs := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		userID, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse user_ids: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
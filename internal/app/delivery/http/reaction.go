@@ -0,0 +1,54 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"strconv"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/gorilla/mux"
+)
+
+// TogglePinReaction handles POST /pins/{pin_id}/reactions
+func (mdc *MediaDeliveryController) TogglePinReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	pinID, err := strconv.ParseUint(mux.Vars(r)["pin_id"], 10, 64)
+	if err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrBadPinInputData,
+		})
+		return
+	}
+
+	var req models.PinReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+	if err := req.Valid(); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+
+	reactions, err := mdc.Usecase.TogglePinReaction(pinID, userID, &req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, reactions)
+}
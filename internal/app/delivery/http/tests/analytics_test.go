@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"testing"
+	"time"
+
+	delivery "pinset/internal/app/delivery/http"
+	internal_errors "pinset/internal/errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAnalyticsUsecase records the range of the follower growth request.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeAnalyticsUsecase struct {
+	delivery.MediaUsecase
+
+	statsRange *models.AnalyticsRange
+}
+
+func (u *fakeAnalyticsUsecase) GetFollowerGrowth(userID uint64, statsRange models.AnalyticsRange) (*models.FollowerGrowth, error) {
+	u.statsRange = &statsRange
+	return &models.FollowerGrowth{}, nil
+}
+
+func TestAnalyticsRangeParams(t *testing.T) {
+	today := models.ViewDay(time.Now())
+
+	tests := []struct {
+		name  string
+		query string
+		from  time.Time
+		to    time.Time
+		code  int
+	}{
+		{name: "last 30 days by default", from: today.AddDate(0, 0, -29), to: today, code: http.StatusOK},
+		{name: "last days", query: "?days=7", from: today.AddDate(0, 0, -6), to: today, code: http.StatusOK},
+		{
+			name:  "from and to",
+			query: "?from=2024-01-01&to=2024-01-31",
+			from:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			code:  http.StatusOK,
+		},
+		{name: "days are not a number", query: "?days=week", code: http.StatusBadRequest},
+		{name: "no days", query: "?days=0", code: http.StatusBadRequest},
+		{name: "only from", query: "?from=2024-01-01", code: http.StatusBadRequest},
+		{name: "bad day", query: "?from=2024-01-01&to=31.01.2024", code: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usecase := &fakeAnalyticsUsecase{}
+			mdc := &delivery.MediaDeliveryController{Usecase: usecase, Logger: logrus.New()}
+
+			req := httptest.NewRequest(http.MethodGet, "/me/analytics/followers"+test.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), configs.UserIdKey, uint64(1)))
+			rec := httptest.NewRecorder()
+			mdc.GetFollowerGrowth(rec, req)
+
+			assert.Equal(t, test.code, rec.Code)
+			if test.code != http.StatusOK {
+				assert.Nil(t, usecase.statsRange)
+				return
+			}
+			if assert.NotNil(t, usecase.statsRange) {
+				assert.Equal(t, test.from, usecase.statsRange.From)
+				assert.Equal(t, test.to, usecase.statsRange.To)
+			}
+		})
+	}
+}
+
+func TestAnalyticsRequireUser(t *testing.T) {
+	usecase := &fakeAnalyticsUsecase{}
+	mdc := &delivery.MediaDeliveryController{Usecase: usecase, Logger: logrus.New()}
+
+	rec := httptest.NewRecorder()
+	mdc.GetFollowerGrowth(rec, httptest.NewRequest(http.MethodGet, "/me/analytics/followers", nil))
+	assert.Equal(t, internal_errors.ErrorMapping[internal_errors.ErrUserIsNotAuthorized].HttpCode, rec.Code)
+	assert.Nil(t, usecase.statsRange)
+}
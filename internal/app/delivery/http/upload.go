@@ -0,0 +1,110 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// parseUploadForm bounds the request body and parses the multipart form.
+// Only params.MultipartMemory bytes are kept in RAM, larger files are spooled to temporary files,
+// which the caller removes with r.MultipartForm.RemoveAll.
+func parseUploadForm(w http.ResponseWriter, r *http.Request, params configs.UploadParams) internal_errors.ErrorInfo {
+	r.Body = http.MaxBytesReader(w, r.Body, params.MaxRequestSize)
+
+	err := r.ParseMultipartForm(params.MultipartMemory)
+	if err == nil {
+		return internal_errors.ErrorInfo{}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return internal_errors.ErrorInfo{General: err, Internal: internal_errors.ErrMediaTooLarge}
+	}
+	return internal_errors.ErrorInfo{General: err, Internal: internal_errors.ErrExpectedMultipartContentType}
+}
+
+// PresignUpload handles POST /uploads/presign
+func (mdc *MediaDeliveryController) PresignUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	var req models.UploadPresignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+
+	presignedUpload, err := mdc.Usecase.PresignUpload(userID, &req)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.sendJSON(w, presignedUpload)
+}
+
+// CompleteUpload handles POST /uploads/complete
+func (mdc *MediaDeliveryController) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(configs.UserIdKey).(uint64)
+	if !ok {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: internal_errors.ErrUserIsNotAuthorized,
+		})
+		return
+	}
+
+	var req models.UploadCompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			General: err, Internal: internal_errors.ErrInvalidOrMissingRequestBody,
+		})
+		return
+	}
+
+	upload, err := mdc.Usecase.CompleteUpload(userID, req.UploadID)
+	if err != nil {
+		mdc.sendUsecaseError(w, err)
+		return
+	}
+
+	mdc.Logger.WithFields(logrus.Fields{
+		"upload_id": upload.UploadID,
+		"media_url": upload.MediaUrl,
+	}).Info("Upload completed")
+
+	mdc.sendJSON(w, upload)
+}
+
+func (mdc *MediaDeliveryController) sendJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		mdc.Logger.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func (mdc *MediaDeliveryController) sendUsecaseError(w http.ResponseWriter, err error) {
+	if internal_errors.IsInternal(err) {
+		internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+			Internal: err,
+		})
+		return
+	}
+
+	internal_errors.SendErrorResponse(w, mdc.Logger, internal_errors.ErrorInfo{
+		General: err, Internal: internal_errors.ErrInternalServerError,
+	})
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"pinset/internal/errors"
+	"time"
+)
+
+const (
+	// AnalyticsDayLayout formats days of analytics series
+	AnalyticsDayLayout = "2006-01-02"
+
+	DefaultAnalyticsRangeDays = 30
+	MaxAnalyticsRangeDays     = 366
+
+	DefaultTopPins = 10
+	MaxTopPins     = 50
+)
+
+// Metrics pins can be ranked by
+const (
+	AnalyticsMetricViews      = "views"
+	AnalyticsMetricBookmarks  = "bookmarks"
+	AnalyticsMetricSaves      = "saves"
+	AnalyticsMetricComments   = "comments"
+	AnalyticsMetricLinkClicks = "link_clicks"
+)
+
+// AnalyticsRange is the range of UTC days from From to To inclusive, it can't end after today
+type AnalyticsRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// NewAnalyticsRange returns the range of the last days ending today.
+func NewAnalyticsRange(days int) AnalyticsRange {
+	to := ViewDay(time.Now())
+	return AnalyticsRange{From: to.AddDate(0, 0, 1-days), To: to}
+}
+
+func (ar AnalyticsRange) Days() int {
+	return int(ar.To.Sub(ar.From)/(24*time.Hour)) + 1
+}
+
+func (ar AnalyticsRange) Valid() error {
+	if ar.To.Before(ar.From) || ar.To.After(ViewDay(time.Now())) || ar.Days() > MaxAnalyticsRangeDays {
+		return errors.ErrAnalyticsParamsInvalid
+	}
+	return nil
+}
+
+func IsAnalyticsMetric(metric string) bool {
+	switch metric {
+	case AnalyticsMetricViews, AnalyticsMetricBookmarks, AnalyticsMetricSaves, AnalyticsMetricComments, AnalyticsMetricLinkClicks:
+		return true
+	}
+	return false
+}
+
+type PinStats struct {
+	Views      uint64 `json:"views"`
+	Bookmarks  uint64 `json:"bookmarks"`
+	Saves      uint64 `json:"saves"`
+	Comments   uint64 `json:"comments"`
+	LinkClicks uint64 `json:"link_clicks"`
+}
+
+func (ps *PinStats) Add(other PinStats) {
+	ps.Views += other.Views
+	ps.Bookmarks += other.Bookmarks
+	ps.Saves += other.Saves
+	ps.Comments += other.Comments
+	ps.LinkClicks += other.LinkClicks
+}
+
+type DailyPinStats struct {
+	Day string `json:"day"`
+	PinStats
+}
+
+// StatsSeries is the daily series of a pin or a board, days without activity are included with zero stats
+type StatsSeries struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Totals PinStats         `json:"totals"`
+	Series []*DailyPinStats `json:"series"`
+}
+
+type PinAnalytics struct {
+	PinID uint64 `json:"pin_id"`
+	StatsSeries
+}
+
+// BoardAnalytics sums stats of pins of the owner in the board
+type BoardAnalytics struct {
+	BoardID uint64 `json:"board_id"`
+	StatsSeries
+}
+
+type TopPinsParams struct {
+	AuthorID uint64
+	Range    AnalyticsRange
+	Metric   string
+	Limit    int
+}
+
+func (tpp TopPinsParams) Valid() error {
+	if err := tpp.Range.Valid(); err != nil {
+		return err
+	}
+	if !IsAnalyticsMetric(tpp.Metric) {
+		return errors.ErrAnalyticsMetricInvalid
+	}
+	if tpp.Limit <= 0 || tpp.Limit > MaxTopPins {
+		return errors.ErrAnalyticsParamsInvalid
+	}
+	return nil
+}
+
+type TopPin struct {
+	PinID uint64   `json:"pin_id"`
+	Title string   `json:"title"`
+	Stats PinStats `json:"stats"`
+}
+
+type DailyFollowers struct {
+	Day       string `json:"day"`
+	Followers uint64 `json:"followers"`
+	// Change is the difference with the previous day, unfollows included
+	Change int64 `json:"change"`
+}
+
+// FollowerSnapshot is the number of followers of a user at the end of the day
+type FollowerSnapshot struct {
+	Day       time.Time
+	Followers uint64
+}
+
+type FollowerGrowth struct {
+	From   string            `json:"from"`
+	To     string            `json:"to"`
+	Growth int64             `json:"growth"`
+	Series []*DailyFollowers `json:"series"`
+}
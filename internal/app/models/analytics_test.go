@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAnalyticsRange(t *testing.T) {
+	statsRange := NewAnalyticsRange(7)
+	assert.Equal(t, 7, statsRange.Days())
+	assert.Equal(t, ViewDay(time.Now()), statsRange.To, "the range ends today")
+	assert.NoError(t, statsRange.Valid())
+}
+
+func TestAnalyticsRangeValid(t *testing.T) {
+	today := ViewDay(time.Now())
+
+	tests := []struct {
+		name       string
+		statsRange AnalyticsRange
+		valid      bool
+	}{
+		{name: "one day", statsRange: AnalyticsRange{From: today, To: today}, valid: true},
+		{name: "longest range", statsRange: AnalyticsRange{From: today.AddDate(0, 0, 1-MaxAnalyticsRangeDays), To: today}, valid: true},
+		{name: "too long", statsRange: AnalyticsRange{From: today.AddDate(0, 0, -MaxAnalyticsRangeDays), To: today}},
+		{name: "reversed", statsRange: AnalyticsRange{From: today, To: today.AddDate(0, 0, -1)}},
+		{name: "ends in the future", statsRange: AnalyticsRange{From: today, To: today.AddDate(0, 0, 1)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.valid {
+				assert.NoError(t, test.statsRange.Valid())
+			} else {
+				assert.ErrorIs(t, test.statsRange.Valid(), errors.ErrAnalyticsParamsInvalid)
+			}
+		})
+	}
+}
+
+func TestTopPinsParamsValid(t *testing.T) {
+	params := TopPinsParams{Range: NewAnalyticsRange(DefaultAnalyticsRangeDays), Metric: AnalyticsMetricLinkClicks, Limit: DefaultTopPins}
+	assert.NoError(t, params.Valid())
+
+	unknownMetric := params
+	unknownMetric.Metric = "likes"
+	assert.ErrorIs(t, unknownMetric.Valid(), errors.ErrAnalyticsMetricInvalid)
+
+	for _, limit := range []int{0, MaxTopPins + 1} {
+		badLimit := params
+		badLimit.Limit = limit
+		assert.ErrorIs(t, badLimit.Valid(), errors.ErrAnalyticsParamsInvalid, limit)
+	}
+
+	badRange := params
+	badRange.Range.From = badRange.Range.To.AddDate(0, 0, 1)
+	assert.ErrorIs(t, badRange.Valid(), errors.ErrAnalyticsParamsInvalid)
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type BlockedUser struct {
+	UserID    uint64    `json:"user_id"`
+	UserName  *string   `json:"user_name"`
+	NickName  string    `json:"nick_name"`
+	AvatarUrl *string   `json:"avatar_url"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
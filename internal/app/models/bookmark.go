@@ -1,6 +1,18 @@
 package models
 
-import "time"
+import (
+	"encoding/base64"
+	"fmt"
+	"pinset/internal/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBookmarksPageSize = 30
+	MaxBookmarksPageSize     = 100
+)
 
 type Bookmark struct {
 	BookmarkID   uint64    `json:"bookmark_id"`
@@ -8,3 +20,64 @@ type Bookmark struct {
 	PinID        uint64    `json:"pin_id"`
 	BookmarkTime time.Time `json:"bookmark_time"`
 }
+
+// BookmarksPageParams describes a page of bookmarks of the owner from the newest.
+// Before is the exclusive bound, the last bookmark of the previous page.
+type BookmarksPageParams struct {
+	OwnerID uint64
+	Before  *BookmarksCursor
+	Limit   int
+}
+
+// BookmarksCursor is the position of a bookmark in the list. It holds the values the list is ordered by,
+// so it stays valid after the bookmark itself is deleted.
+type BookmarksCursor struct {
+	BookmarkTime time.Time
+	BookmarkID   uint64
+}
+
+// String encodes the cursor for clients, who pass it back as is.
+// The time is kept in microseconds, the precision of PostgreSQL.
+func (bc BookmarksCursor) String() string {
+	raw := strconv.FormatInt(bc.BookmarkTime.UnixMicro(), 10) + ":" + strconv.FormatUint(bc.BookmarkID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseBookmarksCursor(cursor string) (*BookmarksCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("decode bookmarks cursor: %w", err)
+	}
+	rawTime, rawID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed bookmarks cursor %q", raw)
+	}
+	micros, err := strconv.ParseInt(rawTime, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse bookmarks cursor time: %w", err)
+	}
+	bookmarkID, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse bookmarks cursor id: %w", err)
+	}
+	return &BookmarksCursor{BookmarkTime: time.UnixMicro(micros), BookmarkID: bookmarkID}, nil
+}
+
+func (bpp BookmarksPageParams) Valid() error {
+	if bpp.Limit <= 0 || bpp.Limit > MaxBookmarksPageSize {
+		return errors.ErrBookmarksPageInvalid
+	}
+	return nil
+}
+
+type BookmarkedPin struct {
+	BookmarkID   uint64    `json:"bookmark_id"`
+	BookmarkTime time.Time `json:"bookmark_time"`
+	Pin          *Pin      `json:"pin"`
+}
+
+type BookmarksPage struct {
+	Bookmarks []*BookmarkedPin `json:"bookmarks"`
+	// NextCursor is the bound of the next page, nil on the last page
+	NextCursor *string `json:"next_cursor"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookmarksCursorRoundTrip(t *testing.T) {
+	cursor := BookmarksCursor{
+		BookmarkTime: time.Date(2024, 10, 19, 12, 30, 45, 123456000, time.UTC),
+		BookmarkID:   42,
+	}
+
+	parsed, err := ParseBookmarksCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.True(t, cursor.BookmarkTime.Equal(parsed.BookmarkTime))
+	assert.Equal(t, cursor.BookmarkID, parsed.BookmarkID)
+}
+
+func TestParseBookmarksCursorRejectsGarbage(t *testing.T) {
+	for _, cursor := range []string{"not base64!", "bm9jb2xvbg", "YWJjOjE", "MTIzOmFiYw"} {
+		_, err := ParseBookmarksCursor(cursor)
+		assert.Error(t, err, cursor)
+	}
+}
+
+func TestBookmarksPageParamsValid(t *testing.T) {
+	assert.NoError(t, BookmarksPageParams{Limit: DefaultBookmarksPageSize}.Valid())
+	assert.Error(t, BookmarksPageParams{Limit: 0}.Valid())
+	assert.Error(t, BookmarksPageParams{Limit: MaxBookmarksPageSize + 1}.Valid())
+}
@@ -1,30 +1,94 @@
 package models
 
 import (
-	"pinset/internal/app/models/response"
+	"html"
+	"pinset/internal/errors"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	MessageTypeText   = "text"
+	MessageTypeSystem = "system"
+)
+
+// Content of system messages about chat membership changes
+const (
+	ChatEventCreated       = "chat_created"
+	ChatEventMemberAdded   = "member_added"
+	ChatEventMemberRemoved = "member_removed"
+	ChatEventMemberLeft    = "member_left"
+	ChatEventAdminAdded    = "admin_added"
+	ChatEventAdminRemoved  = "admin_removed"
+	ChatEventInfoUpdated   = "chat_info_updated"
+)
+
+const (
+	AttachmentTypePin   = "pin"
+	AttachmentTypeBoard = "board"
+	AttachmentTypeImage = "image"
+
+	MaxMessageAttachments = 10
+)
+
+const (
+	ChatRoleMember = "member"
+	ChatRoleAdmin  = "admin"
+)
+
+const (
+	maxChatTitleLength  = 255
+	MaxGroupChatMembers = 100
+)
+
+// GroupChatMembersFit tells whether a group chat can have the number of members, its creator included
+func GroupChatMembersFit(members int) bool {
+	return members <= MaxGroupChatMembers
+}
+
 type Message struct {
-	SenderID  uint64    `json:"sender_id"`
-	ChatID    uint64    `json:"chat_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	SenderID    uint64               `json:"sender_id"`
+	ChatID      uint64               `json:"chat_id"`
+	Content     string               `json:"content"`
+	Type        string               `json:"type"`
+	TargetID    *uint64              `json:"target_id,omitempty"`
+	Attachments []*MessageAttachment `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
 }
 
+const (
+	WebSocketMessageType      = "message"
+	WebSocketPresenceType     = "presence"
+	WebSocketErrorType        = "error"
+	WebSocketNotificationType = "notification"
+)
+
 type WebSocketResponse struct {
 	Type string `json:"type"`
 	Data any    `json:"data"`
 }
 
 type MessageInfo struct {
-	ID        uint64    `json:"message_id"`
-	SenderID  uint64    `json:"sender_id"`
-	ChatID    uint64    `json:"chat_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint64               `json:"message_id"`
+	SenderID    uint64               `json:"sender_id"`
+	ChatID      uint64               `json:"chat_id"`
+	Content     string               `json:"content"`
+	Type        string               `json:"type"`
+	TargetID    *uint64              `json:"target_id,omitempty"`
+	Attachments []*MessageAttachment `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+type MessageAttachment struct {
+	AttachmentID uint64  `json:"attachment_id"`
+	Type         string  `json:"type"`
+	PinID        *uint64 `json:"pin_id,omitempty"`
+	BoardID      *uint64 `json:"board_id,omitempty"`
+	MediaUrl     *string `json:"media_url,omitempty"`
+	Pin          *Pin    `json:"pin,omitempty"`
+	Board        *Board  `json:"board,omitempty"`
 }
 
 type ErrorInfo struct {
@@ -38,11 +102,23 @@ type MessageUpdate struct {
 }
 
 type MessageCreateInfo struct {
-	ID        uint64    `json:"message_id"`
-	SenderID  uint64    `json:"sender_id"`
-	ChatID    uint64    `json:"chat_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint64               `json:"message_id"`
+	SenderID    uint64               `json:"sender_id"`
+	ChatID      uint64               `json:"chat_id"`
+	Content     string               `json:"content"`
+	Type        string               `json:"type"`
+	TargetID    *uint64              `json:"target_id,omitempty"`
+	Attachments []*MessageAttachment `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+type Chat struct {
+	ChatID       uint64    `json:"chat_id"`
+	Title        *string   `json:"title"`
+	AvatarUrl    *string   `json:"avatar_url"`
+	IsGroup      bool      `json:"is_group"`
+	CreatorID    *uint64   `json:"creator_id"`
+	CreationTime time.Time `json:"creation_time"`
 }
 
 type ChatCreateInfo struct {
@@ -56,14 +132,104 @@ type ChatJoiner struct {
 type ChatUser struct {
 	ID         uint64
 	Connection *websocket.Conn
+	writeMu    sync.Mutex
+}
+
+// WriteJSON serializes writes to the connection: messages and presence
+// updates for the same user are sent from different goroutines.
+func (cu *ChatUser) WriteJSON(v any) error {
+	cu.writeMu.Lock()
+	defer cu.writeMu.Unlock()
+	return cu.Connection.WriteJSON(v)
+}
+
+type ChatMember struct {
+	UserID    uint64    `json:"user_id"`
+	UserName  *string   `json:"user_name"`
+	NickName  string    `json:"nick_name"`
+	AvatarUrl *string   `json:"avatar_url"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
 }
 
 type ChatInfo struct {
-	ChatID    uint64                       `json:"chat_id"`
-	Companion response.UserProfileResponse `json:"companion"`
+	ChatID       uint64        `json:"chat_id"`
+	Title        *string       `json:"title"`
+	AvatarUrl    *string       `json:"avatar_url"`
+	IsGroup      bool          `json:"is_group"`
+	Participants []*ChatMember `json:"participants"`
 }
 
 type ChatCreateRequest struct {
 	UserID      uint64
 	CompanionID uint64
 }
+
+type GroupChatCreateRequest struct {
+	CreatorID uint64   `json:"-"`
+	Title     string   `json:"title"`
+	AvatarUrl string   `json:"avatar_url"`
+	MemberIDs []uint64 `json:"member_ids"`
+}
+
+type ChatUpdateRequest struct {
+	ChatID    uint64 `json:"-"`
+	UserID    uint64 `json:"-"`
+	Title     string `json:"title"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+func (m Message) Valid() error {
+	if len(m.Content) == 0 && len(m.Attachments) == 0 {
+		return errors.ErrMessageDataInvalid
+	}
+
+	if len(m.Attachments) > MaxMessageAttachments {
+		return errors.ErrMessageDataInvalid
+	}
+
+	for _, attachment := range m.Attachments {
+		if err := attachment.Valid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ma MessageAttachment) Valid() error {
+	switch {
+	case ma.Type == AttachmentTypePin && ma.PinID != nil && ma.BoardID == nil && ma.MediaUrl == nil:
+		return nil
+	case ma.Type == AttachmentTypeBoard && ma.BoardID != nil && ma.PinID == nil && ma.MediaUrl == nil:
+		return nil
+	case ma.Type == AttachmentTypeImage && ma.MediaUrl != nil && ma.PinID == nil && ma.BoardID == nil:
+		return nil
+	default:
+		return errors.ErrMessageDataInvalid
+	}
+}
+
+func (gcr *GroupChatCreateRequest) Sanitize() {
+	gcr.Title = html.EscapeString(gcr.Title)
+	gcr.AvatarUrl = html.EscapeString(gcr.AvatarUrl)
+}
+
+func (gcr GroupChatCreateRequest) Valid() error {
+	if len(gcr.Title) > 0 && len(gcr.Title) <= maxChatTitleLength &&
+		len(gcr.MemberIDs) > 0 && GroupChatMembersFit(len(gcr.MemberIDs)+1) {
+		return nil
+	}
+	return errors.ErrChatDataInvalid
+}
+
+func (cur *ChatUpdateRequest) Sanitize() {
+	cur.Title = html.EscapeString(cur.Title)
+	cur.AvatarUrl = html.EscapeString(cur.AvatarUrl)
+}
+
+func (cur ChatUpdateRequest) Valid() error {
+	if len(cur.Title) > 0 && len(cur.Title) <= maxChatTitleLength {
+		return nil
+	}
+	return errors.ErrChatDataInvalid
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageAttachmentValid(t *testing.T) {
+	id := uint64(1)
+	mediaUrl := "https://media.pinset.ru/images/chat.png"
+
+	tests := []struct {
+		name       string
+		attachment MessageAttachment
+		valid      bool
+	}{
+		{name: "pin", attachment: MessageAttachment{Type: AttachmentTypePin, PinID: &id}, valid: true},
+		{name: "board", attachment: MessageAttachment{Type: AttachmentTypeBoard, BoardID: &id}, valid: true},
+		{name: "image", attachment: MessageAttachment{Type: AttachmentTypeImage, MediaUrl: &mediaUrl}, valid: true},
+		{name: "pin without id", attachment: MessageAttachment{Type: AttachmentTypePin}},
+		{name: "pin with board", attachment: MessageAttachment{Type: AttachmentTypePin, PinID: &id, BoardID: &id}},
+		{name: "image with pin", attachment: MessageAttachment{Type: AttachmentTypeImage, MediaUrl: &mediaUrl, PinID: &id}},
+		{name: "unknown type", attachment: MessageAttachment{Type: "video", MediaUrl: &mediaUrl}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, tt.attachment.Valid() == nil)
+		})
+	}
+}
+
+func TestMessageValid(t *testing.T) {
+	id := uint64(1)
+
+	assert.NoError(t, Message{Content: "hello"}.Valid())
+	assert.NoError(t, Message{Attachments: []*MessageAttachment{{Type: AttachmentTypePin, PinID: &id}}}.Valid())
+	assert.Error(t, Message{}.Valid(), "a message has text or attachments")
+	assert.Error(t, Message{Content: "hello", Attachments: []*MessageAttachment{{Type: AttachmentTypePin}}}.Valid())
+
+	attachments := make([]*MessageAttachment, MaxMessageAttachments+1)
+	for i := range attachments {
+		attachments[i] = &MessageAttachment{Type: AttachmentTypePin, PinID: &id}
+	}
+	assert.Error(t, Message{Attachments: attachments}.Valid())
+}
+
+func TestGroupChatCreateRequestValid(t *testing.T) {
+	memberIDs := func(n int) []uint64 {
+		ids := make([]uint64, n)
+		for i := range ids {
+			ids[i] = uint64(i + 2)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name    string
+		request GroupChatCreateRequest
+		valid   bool
+	}{
+		{name: "members with the creator fit", request: GroupChatCreateRequest{Title: "Trip", MemberIDs: memberIDs(MaxGroupChatMembers - 1)}, valid: true},
+		{name: "too many members with the creator", request: GroupChatCreateRequest{Title: "Trip", MemberIDs: memberIDs(MaxGroupChatMembers)}},
+		{name: "no members", request: GroupChatCreateRequest{Title: "Trip"}},
+		{name: "no title", request: GroupChatCreateRequest{MemberIDs: memberIDs(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, tt.request.Valid() == nil)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import "pinset/internal/errors"
+
+// LinkPreview is what a pin can be made of from a web page
+type LinkPreview struct {
+	Url         string   `json:"url"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	SiteName    string   `json:"site_name"`
+	Images      []string `json:"images"`
+}
+
+type LinkPreviewRequest struct {
+	Url string `json:"url"`
+}
+
+func (r LinkPreviewRequest) Valid() error {
+	if r.Url == "" {
+		return errors.ErrBadRequest
+	}
+	return nil
+}
+
+// PinFromUrlRequest saves the image of a page, the first image of the page is taken unless ImageUrl is chosen
+type PinFromUrlRequest struct {
+	Url      string `json:"url"`
+	ImageUrl string `json:"image_url"`
+}
+
+func (r PinFromUrlRequest) Valid() error {
+	if r.Url == "" {
+		return errors.ErrBadRequest
+	}
+	return nil
+}
+
+// PinDraft is a pin filled in from a page, the image is already in the storage.
+// It is created by the client after editing through the usual pin creation.
+type PinDraft struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	RelatedLink string `json:"related_link"`
+	MediaUrl    string `json:"media_url"`
+}
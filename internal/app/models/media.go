@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Media is an object of the storage tracked by the media registry.
+// VariantKeys are the objects rendered from it, they are removed together with the media.
+type Media struct {
+	MediaID     uint64
+	OwnerID     *uint64
+	BucketName  string
+	ObjectKey   string
+	VariantKeys []string
+	ContentType string
+	Size        int64
+	// Checksum is the hex SHA-256 of the uploaded content, unknown for media that never passed through the API
+	Checksum *string
+	// PerceptualHash is the dHash of images, stored as signed because of the BIGINT column
+	PerceptualHash *int64
+	// Width, Height and DurationMs are probed from videos and audio, images keep their size in image_media
+	Width      *int
+	Height     *int
+	DurationMs *int64
+	// Waveform is the preview of audio, peaks from 0 to 100
+	Waveform []int
+	// PosterKey is the key of the image shown before a video starts
+	PosterKey *string
+	// DominantColor and Blurhash of images are shown while they load
+	DominantColor *string
+	Blurhash      *string
+	MediaKey      string
+	RefCount      uint64
+	CreatedAt     time.Time
+}
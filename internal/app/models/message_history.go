@@ -0,0 +1,54 @@
+package models
+
+import (
+	"pinset/internal/errors"
+	"strings"
+)
+
+const (
+	DefaultMessagesPageSize = 50
+	MaxMessagesPageSize     = 100
+
+	maxMessageSearchQueryLength = 256
+)
+
+// MessagesPageParams describes a page of chat history.
+// BeforeID and AfterID are exclusive bounds, at most one of them is set.
+// Without bounds the latest messages are returned.
+type MessagesPageParams struct {
+	BeforeID *uint64
+	AfterID  *uint64
+	Limit    int
+}
+
+type MessageSearchParams struct {
+	UserID   uint64
+	Query    string
+	ChatID   *uint64
+	BeforeID *uint64
+	Limit    int
+}
+
+func (mpp MessagesPageParams) Valid() error {
+	if mpp.BeforeID != nil && mpp.AfterID != nil {
+		return errors.ErrMessagesPageInvalid
+	}
+	if mpp.Limit <= 0 || mpp.Limit > MaxMessagesPageSize {
+		return errors.ErrMessagesPageInvalid
+	}
+	return nil
+}
+
+func (msp *MessageSearchParams) Sanitize() {
+	msp.Query = strings.TrimSpace(msp.Query)
+}
+
+func (msp MessageSearchParams) Valid() error {
+	if len(msp.Query) == 0 || len(msp.Query) > maxMessageSearchQueryLength {
+		return errors.ErrMessagesPageInvalid
+	}
+	if msp.Limit <= 0 || msp.Limit > MaxMessagesPageSize {
+		return errors.ErrMessagesPageInvalid
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagesPageParamsValid(t *testing.T) {
+	id := uint64(10)
+
+	assert.NoError(t, MessagesPageParams{Limit: DefaultMessagesPageSize}.Valid())
+	assert.NoError(t, MessagesPageParams{BeforeID: &id, Limit: MaxMessagesPageSize}.Valid())
+	assert.Error(t, MessagesPageParams{BeforeID: &id, AfterID: &id, Limit: 1}.Valid(), "a page has one bound")
+	assert.Error(t, MessagesPageParams{Limit: 0}.Valid())
+	assert.Error(t, MessagesPageParams{Limit: MaxMessagesPageSize + 1}.Valid())
+}
+
+func TestMessageSearchParamsValid(t *testing.T) {
+	params := MessageSearchParams{Query: "  trip  ", Limit: DefaultMessagesPageSize}
+	params.Sanitize()
+	assert.Equal(t, "trip", params.Query)
+	assert.NoError(t, params.Valid())
+
+	blank := MessageSearchParams{Query: "   ", Limit: DefaultMessagesPageSize}
+	blank.Sanitize()
+	assert.Error(t, blank.Valid())
+
+	assert.Error(t, MessageSearchParams{Query: strings.Repeat("a", maxMessageSearchQueryLength+1), Limit: 1}.Valid())
+	assert.Error(t, MessageSearchParams{Query: "trip", Limit: MaxMessagesPageSize + 1}.Valid())
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	// NotificationTypePinSaved is sent to the author when someone saves their pin
+	NotificationTypePinSaved = "pin_saved"
+
+	MaxNotifications = 50
+)
+
+type Notification struct {
+	NotificationID uint64  `json:"notification_id"`
+	RecipientID    uint64  `json:"-"`
+	ActorID        uint64  `json:"actor_id"`
+	ActorNickName  string  `json:"actor_nick_name"`
+	ActorAvatarUrl *string `json:"actor_avatar_url"`
+	Type           string  `json:"type"`
+	PinID          uint64  `json:"pin_id"`
+	// BoardID is set only for public boards
+	BoardID   *uint64   `json:"board_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
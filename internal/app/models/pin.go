@@ -2,25 +2,73 @@ package models
 
 import (
 	"html"
+	"pinset/internal/app/models/response"
 	"pinset/internal/errors"
 	"time"
 )
 
+const (
+	// NearDuplicateMaxDistance is the largest number of differing bits of perceptual hashes of near-duplicate images
+	NearDuplicateMaxDistance = 6
+	MaxSimilarPins           = 10
+	// MaxCarouselItems is the largest number of media items of a pin, the cover included
+	MaxCarouselItems = 10
+)
+
+// Statuses of pins, only published pins are shown to users other than the author
+const (
+	PinStatusDraft     = "draft"
+	PinStatusScheduled = "scheduled"
+	PinStatusPublished = "published"
+)
+
+// Kinds of pin media, the type of the content without the subtype
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+	MediaTypeAudio = "audio"
+)
+
+// PinMedia is a media item of a pin. The media of the pin itself is the cover of its carousel.
+type PinMedia struct {
+	MediaUrl      *string                  `json:"media_url"`
+	UploadID      *string                  `json:"upload_id,omitempty"`
+	MediaType     string                   `json:"media_type"`
+	MediaWidth    *int                     `json:"media_width"`
+	MediaHeight   *int                     `json:"media_height"`
+	MediaDuration *int64                   `json:"media_duration_ms"`
+	MediaWaveform []int                    `json:"media_waveform,omitempty"`
+	MediaVariants []*response.ImageVariant `json:"media_variants"`
+	MediaColor    *string                  `json:"media_color"`
+	MediaBlurhash *string                  `json:"media_blurhash"`
+	PosterUrl     *string                  `json:"poster_url"` // the cover embedded in the video is shown unless set
+}
+
 type Pin struct {
-	PinID        uint64     `json:"pin_id"`
-	AuthorID     uint64     `json:"author_id"`
-	AuthorInfo   *UserPin   `json:"author_info"`
-	Title        *string    `json:"title"`
-	Description  *string    `json:"description"`
-	MediaUrl     *string    `json:"media_url"`
+	PinID       uint64   `json:"pin_id"`
+	AuthorID    uint64   `json:"author_id"`
+	AuthorInfo  *UserPin `json:"author_info"`
+	Title       *string  `json:"title"`
+	Description *string  `json:"description"`
+	PinMedia
+	// Carousel is the ordered media of a pin with several items, the first one is the cover.
+	// It is empty for pins with a single media.
+	Carousel []*PinMedia `json:"carousel,omitempty"`
+	// MediaCount is the number of items of the carousel, 1 for a single media
+	MediaCount   int        `json:"media_count"`
 	RelatedLink  *string    `json:"related_link"`
 	BoardID      uint64     `json:"board_id"`
 	Boards       []*Board   `json:"available_boards"`
 	Commentaries []*Comment `json:"commentaries"`
 	IsBookmarked bool       `json:"is_bookmarked"`
 	Bookmarks    uint64     `json:"bookmarks"`
-	Views        uint64     `json:"views"`
-	Geolocation  *string    `json:"geolocation"`
+	Saves        uint64     `json:"saves"`
+	PinReactions
+	Views       uint64  `json:"views"`
+	Geolocation *string `json:"geolocation"`
+	Status      string  `json:"status"`
+	// PublishAt is when a scheduled pin is published
+	PublishAt    *time.Time `json:"publish_at"`
 	CreationTime time.Time  `json:"creation_time"`
 	UpdateTime   time.Time  `json:"update_time"`
 }
@@ -28,10 +76,30 @@ type Pin struct {
 func (p *Pin) Sanitize() {
 	*p.Title = html.EscapeString(*p.Title)
 	*p.Description = html.EscapeString(*p.Description)
-	*p.MediaUrl = html.EscapeString(*p.MediaUrl)
+	p.PinMedia.Sanitize()
+	for _, item := range p.Carousel {
+		if item != nil {
+			item.Sanitize()
+		}
+	}
 	*p.RelatedLink = html.EscapeString(*p.RelatedLink)
 }
 
+func (m *PinMedia) Sanitize() {
+	// Media of a direct upload is resolved from UploadID
+	if m.MediaUrl != nil {
+		*m.MediaUrl = html.EscapeString(*m.MediaUrl)
+	}
+	if m.PosterUrl != nil {
+		*m.PosterUrl = html.EscapeString(*m.PosterUrl)
+	}
+}
+
+// VisibleTo reports whether the user may see the pin: unpublished pins are seen only by their author.
+func (p Pin) VisibleTo(userID uint64) bool {
+	return p.Status == PinStatusPublished || (userID != 0 && p.AuthorID == userID)
+}
+
 func (p Pin) Valid() error {
 	if p.titleValid() && p.descriptionValid() {
 		return nil
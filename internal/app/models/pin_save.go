@@ -0,0 +1,46 @@
+package models
+
+import (
+	"pinset/internal/errors"
+	"time"
+)
+
+// MaxSavedFromDepth bounds the attribution chain shown on the pin page
+const MaxSavedFromDepth = 5
+
+// PinSaveRequest saves the pin to a board of the user.
+// FromBoardID is the board where the user found the pin, zero when found elsewhere.
+type PinSaveRequest struct {
+	PinID       uint64 `json:"-"`
+	SaverID     uint64 `json:"-"`
+	BoardID     uint64 `json:"board_id"`
+	FromBoardID uint64 `json:"from_board_id"`
+}
+
+func (r PinSaveRequest) Valid() error {
+	if r.BoardID == 0 {
+		return errors.ErrBadBoardInputData
+	}
+	return nil
+}
+
+type PinSave struct {
+	PinID       uint64  `json:"pin_id"`
+	BoardID     uint64  `json:"board_id"`
+	SaverID     uint64  `json:"saver_id"`
+	FromBoardID *uint64 `json:"from_board_id"`
+	// FromUserID is the owner of the board the pin was saved from
+	FromUserID *uint64 `json:"from_user_id"`
+	// Saves is the number of saves of the pin including this one
+	Saves     uint64    `json:"saves"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PinSaveLink is a step of the way of the pin from its author to a board:
+// the user the pin was saved from and their board, nil when it's private or deleted.
+type PinSaveLink struct {
+	UserID    uint64  `json:"user_id"`
+	NickName  string  `json:"nick_name"`
+	BoardID   *uint64 `json:"board_id"`
+	BoardName *string `json:"board_name"`
+}
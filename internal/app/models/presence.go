@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const MaxPresenceBatchSize = 100
+
+type Presence struct {
+	UserID   uint64     `json:"user_id"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen"`
+}
+
+type UserPresenceInfo struct {
+	UserID       uint64
+	LastSeen     *time.Time
+	HidePresence bool
+}
+
+type PresenceSettings struct {
+	HidePresence bool `json:"hide_presence"`
+}
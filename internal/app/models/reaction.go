@@ -0,0 +1,42 @@
+package models
+
+import "pinset/internal/errors"
+
+// Types of reactions on pins
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionWow   = "wow"
+	ReactionHaha  = "haha"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+var reactionTypes = map[string]bool{
+	ReactionLike:  true,
+	ReactionLove:  true,
+	ReactionWow:   true,
+	ReactionHaha:  true,
+	ReactionSad:   true,
+	ReactionAngry: true,
+}
+
+// PinReactionRequest toggles the reaction of the user: sets it,
+// removes it when it's already set or replaces the reaction of another type.
+type PinReactionRequest struct {
+	Type string `json:"type"`
+}
+
+func (r PinReactionRequest) Valid() error {
+	if !reactionTypes[r.Type] {
+		return errors.ErrReactionTypeInvalid
+	}
+	return nil
+}
+
+type PinReactions struct {
+	// Counts of reactions by type, types nobody reacted with are missing
+	Counts map[string]uint64 `json:"reactions"`
+	// MyReaction is the reaction of the viewer, nil if none
+	MyReaction *string `json:"my_reaction"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"testing"
+
+	"pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinReactionRequestValid(t *testing.T) {
+	for reactionType := range reactionTypes {
+		assert.NoError(t, PinReactionRequest{Type: reactionType}.Valid(), reactionType)
+	}
+	for _, reactionType := range []string{"", "bookmark", "Like"} {
+		assert.ErrorIs(t, PinReactionRequest{Type: reactionType}.Valid(), errors.ErrReactionTypeInvalid, reactionType)
+	}
+}
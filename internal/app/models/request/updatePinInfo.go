@@ -1,12 +1,26 @@
 package request
 
+import "time"
+
 type UpdatePinRequest struct {
-	PinID       uint64 `json:"pin_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	BoardID     uint64 `json:"board_id"`
-	RelatedLink string `json:"related_link"`
-	Geolocation string `json:"geolocation"`
+	PinID       uint64  `json:"pin_id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	BoardID     uint64  `json:"board_id"`
+	RelatedLink string  `json:"related_link"`
+	Geolocation string  `json:"geolocation"`
+	PosterUrl   *string `json:"poster_url"`
+	// Carousel replaces the media of the pin in the given order when set
+	Carousel []*PinMediaItem `json:"carousel"`
+	// Status and PublishAt are kept unless given, a pin with only PublishAt is scheduled
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PinMediaItem is a media of our storage or of a completed direct upload
+type PinMediaItem struct {
+	MediaUrl *string `json:"media_url"`
+	UploadID *string `json:"upload_id"`
 }
 
 func (upr UpdatePinRequest) Valid() bool {
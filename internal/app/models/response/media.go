@@ -9,29 +9,87 @@ type (
 	}
 
 	PinCreatedResponse struct {
-		PinID   uint64 `json:"pin_id"`
-		Message string `json:"message"`
+		PinID uint64 `json:"pin_id"`
+		// SimilarPinIDs flags existing pins with a near-duplicate image
+		SimilarPinIDs []uint64 `json:"similar_pin_ids,omitempty"`
+		Message       string   `json:"message"`
 	}
 
 	PinPreviewResponse struct {
-		AuthorName            string `json:"author_name"`
-		AuthorAvatarUrl       string `json:"avatar_url"`
-		AuthorFollowersNumber uint64 `json:"followers_count"`
-		MediaUrl              string `json:"media_url"`
-		ViewsNumber           uint64 `json:"views_count"`
-		BookmarksNumber       uint64 `json:"bookmarks_count"`
+		AuthorName            string          `json:"author_name"`
+		AuthorAvatarUrl       string          `json:"avatar_url"`
+		AuthorFollowersNumber uint64          `json:"followers_count"`
+		MediaUrl              string          `json:"media_url"`
+		MediaType             string          `json:"media_type"`
+		MediaWidth            *int            `json:"media_width"`
+		MediaHeight           *int            `json:"media_height"`
+		MediaDuration         *int64          `json:"media_duration_ms"`
+		MediaWaveform         []int           `json:"media_waveform,omitempty"`
+		MediaVariants         []*ImageVariant `json:"media_variants"`
+		MediaColor            *string         `json:"media_color"`
+		MediaBlurhash         *string         `json:"media_blurhash"`
+		PosterUrl             *string         `json:"poster_url"`
+		MediaCount            int             `json:"media_count"`
+		ViewsNumber           uint64          `json:"views_count"`
+		BookmarksNumber       uint64          `json:"bookmarks_count"`
+		IsBookmarked          bool            `json:"is_bookmarked"`
 	}
 
 	PinPageResponse struct {
-		AuthorName            string    `json:"author_name"`
-		AuthorAvatarUrl       string    `json:"avatar_url"`
-		AuthorFollowersNumber uint64    `json:"followers_count"`
-		MediaUrl              string    `json:"media_url"`
-		Title                 string    `json:"title"`
-		Description           string    `json:"description"`
-		RelatedLink           string    `json:"related_link"`
-		Geolocation           string    `json:"geolocation"`
-		CreationTime          time.Time `json:"creation_time"`
+		AuthorName            string            `json:"author_name"`
+		AuthorAvatarUrl       string            `json:"avatar_url"`
+		AuthorFollowersNumber uint64            `json:"followers_count"`
+		MediaUrl              string            `json:"media_url"`
+		MediaType             string            `json:"media_type"`
+		MediaWidth            *int              `json:"media_width"`
+		MediaHeight           *int              `json:"media_height"`
+		MediaDuration         *int64            `json:"media_duration_ms"`
+		MediaWaveform         []int             `json:"media_waveform,omitempty"`
+		MediaVariants         []*ImageVariant   `json:"media_variants"`
+		MediaColor            *string           `json:"media_color"`
+		MediaBlurhash         *string           `json:"media_blurhash"`
+		PosterUrl             *string           `json:"poster_url"`
+		Carousel              []*PinMedia       `json:"carousel,omitempty"`
+		Title                 string            `json:"title"`
+		Description           string            `json:"description"`
+		RelatedLink           string            `json:"related_link"`
+		Geolocation           string            `json:"geolocation"`
+		Saves                 uint64            `json:"saves"`
+		IsBookmarked          bool              `json:"is_bookmarked"`
+		Reactions             map[string]uint64 `json:"reactions"`
+		MyReaction            *string           `json:"my_reaction"`
+		SavedFrom             []*PinSaveLink    `json:"saved_from,omitempty"`
+		CreationTime          time.Time         `json:"creation_time"`
+	}
+
+	// PinSaveLink is a user the pin was saved from, the nearest first
+	PinSaveLink struct {
+		UserID    uint64  `json:"user_id"`
+		NickName  string  `json:"nick_name"`
+		BoardID   *uint64 `json:"board_id"`
+		BoardName *string `json:"board_name"`
+	}
+
+	// PinMedia is an item of the carousel of a pin
+	PinMedia struct {
+		MediaUrl      string          `json:"media_url"`
+		MediaType     string          `json:"media_type"`
+		MediaWidth    *int            `json:"media_width"`
+		MediaHeight   *int            `json:"media_height"`
+		MediaDuration *int64          `json:"media_duration_ms"`
+		MediaWaveform []int           `json:"media_waveform,omitempty"`
+		MediaVariants []*ImageVariant `json:"media_variants"`
+		MediaColor    *string         `json:"media_color"`
+		MediaBlurhash *string         `json:"media_blurhash"`
+		PosterUrl     *string         `json:"poster_url"`
+	}
+
+	// ImageVariant is a downscaled rendition of a pin image
+	ImageVariant struct {
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		Url     string `json:"url"`
+		WebpUrl string `json:"webp_url"`
 	}
 
 	ResponseBookmarkExists struct {
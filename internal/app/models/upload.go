@@ -0,0 +1,54 @@
+package models
+
+import (
+	"pinset/internal/errors"
+	"time"
+)
+
+const (
+	UploadStatusPending   = "pending"
+	UploadStatusCompleted = "completed"
+)
+
+// Upload is a file the client puts straight into the storage by a presigned URL
+type Upload struct {
+	UploadID    string     `json:"upload_id"`
+	UserID      uint64     `json:"user_id"`
+	BucketName  string     `json:"-"`
+	ObjectName  string     `json:"-"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	MediaKey    *string    `json:"-"`
+	MediaUrl    *string    `json:"media_url"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type UploadPresignRequest struct {
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+func (r UploadPresignRequest) Valid() error {
+	if r.ContentType == "" || r.Size <= 0 {
+		return errors.ErrBadRequest
+	}
+	return nil
+}
+
+type UploadCompleteRequest struct {
+	UploadID string `json:"upload_id"`
+}
+
+// PresignedUpload tells the client where and how to send the file.
+// The multipart/form-data request carries the listed fields followed by the file in the "file" field,
+// the upload is then confirmed with UploadID.
+type PresignedUpload struct {
+	UploadID  string            `json:"upload_id"`
+	Url       string            `json:"url"`
+	Method    string            `json:"method"`
+	Fields    map[string]string `json:"fields"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
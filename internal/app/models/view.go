@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PinViews is the number of views of a pin during a day
+type PinViews struct {
+	PinID uint64
+	Day   time.Time
+	Views uint64
+}
+
+// PinLinkClicks is the number of clicks on the related link of a pin during a day
+type PinLinkClicks struct {
+	PinID  uint64
+	Day    time.Time
+	Clicks uint64
+}
+
+// ViewDay truncates the time to the UTC day views are accounted to.
+func ViewDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,141 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"fmt"
+	"pinset/internal/app/models"
+	"time"
+)
+
+// analyticsMetricColumns maps metrics to the columns of pin_stats_daily, the column is put into the query
+var analyticsMetricColumns = map[string]string{
+	models.AnalyticsMetricViews:      "views",
+	models.AnalyticsMetricBookmarks:  "bookmarks",
+	models.AnalyticsMetricSaves:      "saves",
+	models.AnalyticsMetricComments:   "comments",
+	models.AnalyticsMetricLinkClicks: "link_clicks",
+}
+
+// RollupPinStats recounts daily stats of pins since the day and returns the number of rows written.
+func (mrc *MediaRepositoryController) RollupPinStats(since time.Time) (int64, error) {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("psql RollupPinStats begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(DeletePinStatsSince, since); err != nil {
+		return 0, fmt.Errorf("psql RollupPinStats: %w", err)
+	}
+	result, err := tx.Exec(RollupPinStats, since)
+	if err != nil {
+		return 0, fmt.Errorf("psql RollupPinStats: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("psql RollupPinStats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("psql RollupPinStats commit: %w", err)
+	}
+	return rows, nil
+}
+
+// SnapshotFollowers stores the current number of followers of users as the one of the day.
+func (mrc *MediaRepositoryController) SnapshotFollowers(day time.Time) (int64, error) {
+	result, err := mrc.db.Exec(SnapshotFollowers, day)
+	if err != nil {
+		return 0, fmt.Errorf("psql SnapshotFollowers: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("psql SnapshotFollowers: %w", err)
+	}
+	return rows, nil
+}
+
+func (mrc *MediaRepositoryController) GetPinStatsSeries(pinID uint64, statsRange models.AnalyticsRange) ([]*models.DailyPinStats, error) {
+	rows, err := mrc.db.Query(GetPinStatsSeries, pinID, statsRange.From, statsRange.To)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetPinStatsSeries: %w", err)
+	}
+	return scanDailyPinStats(rows)
+}
+
+func (mrc *MediaRepositoryController) GetBoardStatsSeries(boardID, ownerID uint64, statsRange models.AnalyticsRange) ([]*models.DailyPinStats, error) {
+	rows, err := mrc.db.Query(GetBoardStatsSeries, boardID, ownerID, statsRange.From, statsRange.To)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetBoardStatsSeries: %w", err)
+	}
+	return scanDailyPinStats(rows)
+}
+
+func scanDailyPinStats(rows *sql.Rows) ([]*models.DailyPinStats, error) {
+	defer rows.Close()
+
+	var series []*models.DailyPinStats
+	for rows.Next() {
+		var day time.Time
+		stats := &models.DailyPinStats{}
+		err := rows.Scan(&day, &stats.Views, &stats.Bookmarks, &stats.Saves, &stats.Comments, &stats.LinkClicks)
+		if err != nil {
+			return nil, fmt.Errorf("psql scanDailyPinStats rows.Next: %w", err)
+		}
+		stats.Day = day.Format(models.AnalyticsDayLayout)
+		series = append(series, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql scanDailyPinStats rows.Err: %w", err)
+	}
+	return series, nil
+}
+
+func (mrc *MediaRepositoryController) GetTopPins(params *models.TopPinsParams) ([]*models.TopPin, error) {
+	column, ok := analyticsMetricColumns[params.Metric]
+	if !ok {
+		return nil, fmt.Errorf("psql GetTopPins: unknown metric %q", params.Metric)
+	}
+
+	rows, err := mrc.db.Query(fmt.Sprintf(GetTopPins, column), params.AuthorID, params.Range.From, params.Range.To, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetTopPins: %w", err)
+	}
+	defer rows.Close()
+
+	var pins []*models.TopPin
+	for rows.Next() {
+		pin := &models.TopPin{}
+		err := rows.Scan(&pin.PinID, &pin.Title, &pin.Stats.Views, &pin.Stats.Bookmarks, &pin.Stats.Saves, &pin.Stats.Comments, &pin.Stats.LinkClicks)
+		if err != nil {
+			return nil, fmt.Errorf("psql GetTopPins rows.Next: %w", err)
+		}
+		pins = append(pins, pin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetTopPins rows.Err: %w", err)
+	}
+	return pins, nil
+}
+
+// GetFollowerSnapshots returns snapshots of the range from the oldest, preceded by the last one before the range.
+func (mrc *MediaRepositoryController) GetFollowerSnapshots(userID uint64, statsRange models.AnalyticsRange) ([]*models.FollowerSnapshot, error) {
+	rows, err := mrc.db.Query(GetFollowerSnapshots, userID, statsRange.From, statsRange.To)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetFollowerSnapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*models.FollowerSnapshot
+	for rows.Next() {
+		snapshot := &models.FollowerSnapshot{}
+		if err := rows.Scan(&snapshot.Day, &snapshot.Followers); err != nil {
+			return nil, fmt.Errorf("psql GetFollowerSnapshots rows.Next: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetFollowerSnapshots rows.Err: %w", err)
+	}
+	return snapshots, nil
+}
@@ -112,28 +112,37 @@ func (mrc *MediaRepositoryController) GetAllBoardsByOwnerID(ownerID uint64) ([]*
 }
 
 func (mrc *MediaRepositoryController) GetBoardByBoardID(boardID uint64) (*models.Board, error) {
-	row := mrc.db.QueryRow(GetBoardByBoardID, boardID)
-	if row.Err() != nil {
-		if errors.Is(row.Err(), sql.ErrNoRows) {
+	var id, ownerID uint64
+	var cover, description *string
+	var name string
+	var public bool
+	var creationTime, updateTime time.Time
+
+	err := mrc.db.QueryRow(GetBoardByBoardID, boardID).
+		Scan(&id, &ownerID, &cover, &name, &description, &public, &creationTime, &updateTime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, internal_errors.ErrBoardDoesntExists
 		}
-		return nil, fmt.Errorf("psql getBoardByBoardID: %w", row.Err())
+		return nil, fmt.Errorf("psql getBoardByBoardID: %w", err)
 	}
 
-	var id, ownerID uint64
-	var name, description string
-	var public bool
-	var creationTime, updateTime time.Time
+	boardCover := ""
+	if cover != nil {
+		boardCover = *cover
+	}
 
-	if err := row.Scan(&id, &ownerID, &name, &description, &public, &creationTime, &updateTime); err != nil {
-		return nil, fmt.Errorf("getAllBoardsByOwnerID rows.Next: %w", err)
+	boardDescription := ""
+	if description != nil {
+		boardDescription = *description
 	}
 
 	return &models.Board{
-		BoardID:      boardID,
+		BoardID:      id,
 		OwnerID:      ownerID,
+		Cover:        boardCover,
 		Name:         name,
-		Description:  description,
+		Description:  boardDescription,
 		Public:       public,
 		CreationTime: creationTime,
 		UpdateTime:   updateTime,
@@ -0,0 +1,174 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// txRepository runs statements of a unit of work, see RunInTx
+type txRepository struct {
+	tx     *sql.Tx
+	logger *logrus.Logger
+}
+
+// CreatePinBookmark reports false if the user has already bookmarked the pin, the bookmark gets the existing ID then.
+func (tr *txRepository) CreatePinBookmark(bookmark *models.Bookmark) (bool, error) {
+	err := tr.tx.QueryRow(CreatePinBookmark, bookmark.OwnerID, bookmark.PinID, bookmark.BookmarkTime).Scan(&bookmark.BookmarkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tr.tx.QueryRow(GetBookmarkOnUserPin, bookmark.OwnerID, bookmark.PinID).Scan(&bookmark.BookmarkID); err != nil {
+			return false, fmt.Errorf("psql createPinBookmark: %w", err)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("psql createPinBookmark: %w", err)
+	}
+
+	tr.logger.WithField("bookmark was succesfully created with bookmarkID", bookmark.BookmarkID).Info("createPinBookmark func")
+	return true, nil
+}
+
+// DeletePinBookmark reports false if there was no bookmark.
+func (tr *txRepository) DeletePinBookmark(ownerID, pinID uint64) (bool, error) {
+	var bookmarkID uint64
+	err := tr.tx.QueryRow(DeletePinBookmarkByOwnerIDAndPinID, ownerID, pinID).Scan(&bookmarkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("psql deletePinBookmark: %w", err)
+	}
+
+	tr.logger.WithField("bookmark was succesfully deleted with ownerID", ownerID).Info()
+	return true, nil
+}
+
+func (tr *txRepository) UpdateBookmarksCountIncrease(pinID uint64) error {
+	return tr.updateBookmarksCount(UpdateBookmarksCountIncrease, pinID)
+}
+
+func (tr *txRepository) UpdateBookmarksCountDecrease(pinID uint64) error {
+	return tr.updateBookmarksCount(UpdateBookmarksCountDecrease, pinID)
+}
+
+func (tr *txRepository) updateBookmarksCount(query string, pinID uint64) error {
+	result, err := tr.tx.Exec(query, pinID)
+	if err != nil {
+		return fmt.Errorf("psql updateBookmarksCount: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return internal_errors.ErrPinDoesntExists
+	}
+	return nil
+}
+
+// LockPinCounters locks counters of the batch of pins following afterPinID until the end of the unit of work.
+func (tr *txRepository) LockPinCounters(afterPinID uint64, limit int) ([]uint64, error) {
+	rows, err := tr.tx.Query(LockPinCounters, afterPinID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql LockPinCounters: %w", err)
+	}
+	defer rows.Close()
+
+	var pinIDs []uint64
+	for rows.Next() {
+		var pinID uint64
+		if err := rows.Scan(&pinID); err != nil {
+			return nil, fmt.Errorf("psql LockPinCounters rows.Next: %w", err)
+		}
+		pinIDs = append(pinIDs, pinID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql LockPinCounters rows.Err: %w", err)
+	}
+	return pinIDs, nil
+}
+
+// RecountPinBookmarks sets bookmark counters of the pins from the bookmark table
+// and returns the number of counters that were out of sync.
+func (tr *txRepository) RecountPinBookmarks(pinIDs []uint64) (int, error) {
+	if len(pinIDs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(pinIDs))
+	for _, pinID := range pinIDs {
+		ids = append(ids, int64(pinID))
+	}
+
+	result, err := tr.tx.Exec(RecountPinBookmarks, ids)
+	if err != nil {
+		return 0, fmt.Errorf("psql RecountPinBookmarks: %w", err)
+	}
+	fixed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("psql RecountPinBookmarks: %w", err)
+	}
+	return int(fixed), nil
+}
+
+// GetBookmarkedPins returns a page of published pins bookmarked by the owner, the latest bookmark first.
+func (mrc *MediaRepositoryController) GetBookmarkedPins(params *models.BookmarksPageParams) ([]*models.BookmarkedPin, error) {
+	var beforeTime *time.Time
+	var beforeID uint64
+	if params.Before != nil {
+		beforeTime, beforeID = &params.Before.BookmarkTime, params.Before.BookmarkID
+	}
+
+	rows, err := mrc.db.Query(GetBookmarkedPins, params.OwnerID, beforeTime, beforeID, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetBookmarkedPins: %w", err)
+	}
+	defer rows.Close()
+
+	bookmarks := []*models.BookmarkedPin{}
+	for rows.Next() {
+		bookmark := &models.BookmarkedPin{}
+		bookmark.Pin, err = mrc.scanPin(rows, &bookmark.BookmarkID, &bookmark.BookmarkTime)
+		if err != nil {
+			return nil, fmt.Errorf("psql GetBookmarkedPins: %w", err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetBookmarkedPins rows.Err: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// GetBookmarkedPinIDs returns which of the pins the owner has bookmarked.
+func (mrc *MediaRepositoryController) GetBookmarkedPinIDs(ownerID uint64, pinIDs []uint64) (map[uint64]bool, error) {
+	bookmarked := make(map[uint64]bool)
+	if ownerID == 0 || len(pinIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	ids := make([]int64, 0, len(pinIDs))
+	for _, pinID := range pinIDs {
+		ids = append(ids, int64(pinID))
+	}
+
+	rows, err := mrc.db.Query(GetBookmarkedPinIDs, ownerID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetBookmarkedPinIDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pinID uint64
+		if err := rows.Scan(&pinID); err != nil {
+			return nil, fmt.Errorf("psql GetBookmarkedPinIDs rows.Next: %w", err)
+		}
+		bookmarked[pinID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetBookmarkedPinIDs rows.Err: %w", err)
+	}
+	return bookmarked, nil
+}
@@ -1,13 +1,40 @@
 package mediarepository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	internal_errors "pinset/internal/errors"
+	"pinset/pkg/mediaurl"
+
+	"github.com/sirupsen/logrus"
 )
 
-func (mrc *MediaRepositoryController) CreateChat() (*models.ChatCreateInfo, error) {
+// chatQuerier is implemented by both *sql.DB and *sql.Tx
+type chatQuerier interface {
+	queryRower
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// chatMembers runs the statements on chat members either on their own or within a transaction
+type chatMembers struct {
+	q      chatQuerier
+	logger *logrus.Logger
+	urls   *mediaurl.Builder
+}
+
+func (mrc *MediaRepositoryController) chatMembers(q chatQuerier) *chatMembers {
+	return &chatMembers{q: q, logger: mrc.logger, urls: mrc.urls}
+}
+
+func (mrc *MediaRepositoryController) CreateChat(chat *models.Chat) (*models.ChatCreateInfo, error) {
 	var chatID uint64
-	err := mrc.db.QueryRow(`INSERT INTO chat DEFAULT VALUES RETURNING chat_id`).Scan(&chatID)
+	err := mrc.db.QueryRow(`INSERT INTO chat (title, avatar_url, is_group, creator_id, avatar_media_id)
+	 VALUES ($1, $2, $3, $4, (SELECT media_id FROM media WHERE media_key = $2))
+	 RETURNING chat_id`, chat.Title, mrc.urls.OptionalKey(chat.AvatarUrl), chat.IsGroup, chat.CreatorID).Scan(&chatID)
 
 	if err != nil {
 		return nil, fmt.Errorf("psql CreateChat: %w", err)
@@ -17,15 +44,224 @@ func (mrc *MediaRepositoryController) CreateChat() (*models.ChatCreateInfo, erro
 	return &models.ChatCreateInfo{ID: chatID}, nil
 }
 
-func (mrc *MediaRepositoryController) AddUserToChat(chatID uint64, userID uint64) error {
+// CreateDirectChat returns the direct chat of two users, creating it if there is none yet.
+// Uniqueness of the pair is guaranteed by the direct_chat_users_unique index.
+func (mrc *MediaRepositoryController) CreateDirectChat(firstUserID, secondUserID uint64) (*models.ChatCreateInfo, error) {
+	creatorID := firstUserID
+	if firstUserID > secondUserID {
+		firstUserID, secondUserID = secondUserID, firstUserID
+	}
+
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("psql CreateDirectChat begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chatID uint64
+	err = tx.QueryRow(`INSERT INTO chat (is_group, creator_id, first_user_id, second_user_id) VALUES (false, $1, $2, $3)
+	 ON CONFLICT (first_user_id, second_user_id) DO NOTHING RETURNING chat_id`, creatorID, firstUserID, secondUserID).Scan(&chatID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		// Chat already exists, nothing to create
+		chatID, err = mrc.GetDirectChatID(firstUserID, secondUserID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ChatCreateInfo{ID: chatID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("psql CreateDirectChat: %w", err)
+	}
+
+	for _, userID := range []uint64{firstUserID, secondUserID} {
+		_, err = tx.Exec(`INSERT INTO user_chat (user_id, chat_id, role) VALUES ($1, $2, $3)`, userID, chatID, models.ChatRoleMember)
+		if err != nil {
+			return nil, fmt.Errorf("psql CreateDirectChat user_chat: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("psql CreateDirectChat commit: %w", err)
+	}
+
+	mrc.logger.WithField("direct chat was succesfully created with chatID", chatID).Info("createDirectChat func")
+	return &models.ChatCreateInfo{ID: chatID}, nil
+}
+
+func (mrc *MediaRepositoryController) GetDirectChatID(firstUserID, secondUserID uint64) (uint64, error) {
+	if firstUserID > secondUserID {
+		firstUserID, secondUserID = secondUserID, firstUserID
+	}
+
+	var chatID uint64
+	err := mrc.db.QueryRow(`SELECT chat_id FROM chat WHERE first_user_id=$1 AND second_user_id=$2`, firstUserID, secondUserID).Scan(&chatID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, internal_errors.ErrChatDoesntExists
+		}
+		return 0, fmt.Errorf("psql GetDirectChatID: %w", err)
+	}
+	return chatID, nil
+}
+
+// CreateGroupChat creates the group chat with its creator as the admin, the members and the message about the creation,
+// either all of them or nothing. The message gets the ID of the created chat.
+func (mrc *MediaRepositoryController) CreateGroupChat(chat *models.Chat, memberIDs []uint64, msg *models.Message) (*models.ChatCreateInfo, *models.MessageCreateInfo, error) {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("psql CreateGroupChat begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chatID uint64
+	err = tx.QueryRow(`INSERT INTO chat (title, avatar_url, is_group, creator_id, avatar_media_id)
+	 VALUES ($1, $2, true, $3, (SELECT media_id FROM media WHERE media_key = $2))
+	 RETURNING chat_id`, chat.Title, mrc.urls.OptionalKey(chat.AvatarUrl), chat.CreatorID).Scan(&chatID)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("psql CreateGroupChat: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_chat (user_id, chat_id, role) VALUES ($1, $2, $3)`, chat.CreatorID, chatID, models.ChatRoleAdmin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("psql CreateGroupChat user_chat: %w", err)
+	}
+	for _, memberID := range memberIDs {
+		_, err = tx.Exec(`INSERT INTO user_chat (user_id, chat_id, role) VALUES ($1, $2, $3)`, memberID, chatID, models.ChatRoleMember)
+		if err != nil {
+			return nil, nil, fmt.Errorf("psql CreateGroupChat user_chat: %w", err)
+		}
+	}
+
+	msg.ChatID = chatID
+	crMsg, err := mrc.chatMembers(tx).CreateMessage(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("psql CreateGroupChat: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("psql CreateGroupChat commit: %w", err)
+	}
+
+	mrc.logger.WithField("group chat was succesfully created with chatID", chatID).Info("createGroupChat func")
+	return &models.ChatCreateInfo{ID: chatID}, crMsg, nil
+}
+
+// UpdateChatMembers runs fn in a transaction holding the locks of the chat members,
+// so the checks of fn stay true until its changes are committed.
+func (mrc *MediaRepositoryController) UpdateChatMembers(chatID uint64, fn func(repo usecase.ChatMembersRepository) error) error {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql UpdateChatMembers begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT user_id FROM user_chat WHERE chat_id=$1 ORDER BY user_id FOR UPDATE`, chatID)
+	if err != nil {
+		return fmt.Errorf("psql UpdateChatMembers lock: %w", err)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("psql UpdateChatMembers lock: %w", err)
+	}
+
+	if err := fn(mrc.chatMembers(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql UpdateChatMembers commit: %w", err)
+	}
+	return nil
+}
+
+func (mrc *MediaRepositoryController) GetChatByChatID(chatID uint64) (*models.Chat, error) {
+	chat := &models.Chat{}
+	err := mrc.db.QueryRow(`SELECT chat_id, title, avatar_url, is_group, creator_id, creation_time FROM chat WHERE chat_id=$1`, chatID).
+		Scan(&chat.ChatID, &chat.Title, &chat.AvatarUrl, &chat.IsGroup, &chat.CreatorID, &chat.CreationTime)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internal_errors.ErrChatDoesntExists
+		}
+		return nil, fmt.Errorf("psql GetChatByChatID: %w", err)
+	}
+
+	chat.AvatarUrl = mrc.urls.OptionalUrl(chat.AvatarUrl)
+	return chat, nil
+}
+
+func (mrc *MediaRepositoryController) UpdateChatInfo(chat *models.Chat) error {
+	_, err := mrc.db.Exec(`UPDATE chat SET title=$1, avatar_url=$2, avatar_media_id=(SELECT media_id FROM media WHERE media_key = $2) WHERE chat_id=$3`, chat.Title, mrc.urls.OptionalKey(chat.AvatarUrl), chat.ChatID)
+
+	if err != nil {
+		return fmt.Errorf("psql UpdateChatInfo: %w", err)
+	}
+
+	mrc.logger.WithField("chat info was successfully updated with chatID", chat.ChatID).Info("updateChatInfo func")
+	return nil
+}
+
+func (mrc *MediaRepositoryController) AddUserToChat(chatID uint64, userID uint64, role string) error {
+	return mrc.chatMembers(mrc.db).AddUserToChat(chatID, userID, role)
+}
+
+func (cm *chatMembers) AddUserToChat(chatID uint64, userID uint64, role string) error {
 	var createdChatID, createdUserID uint64
-	err := mrc.db.QueryRow(`INSERT INTO user_chat (user_id, chat_id) VALUES ($1, $2)
-	 RETURNING user_id, chat_id`, userID, chatID).Scan(&createdUserID, &createdChatID)
+	err := cm.q.QueryRow(`INSERT INTO user_chat (user_id, chat_id, role) VALUES ($1, $2, $3)
+	 RETURNING user_id, chat_id`, userID, chatID, role).Scan(&createdUserID, &createdChatID)
 
 	if err != nil {
 		return fmt.Errorf("psql AddUserToChat: %w", err)
 	}
-	mrc.logger.WithField("user successfully added to chat", createdUserID).Info("addUserToChat func")
+	cm.logger.WithField("user successfully added to chat", createdUserID).Info("addUserToChat func")
+	return nil
+}
+
+func (mrc *MediaRepositoryController) RemoveUserFromChat(chatID uint64, userID uint64) error {
+	return mrc.chatMembers(mrc.db).RemoveUserFromChat(chatID, userID)
+}
+
+func (cm *chatMembers) RemoveUserFromChat(chatID uint64, userID uint64) error {
+	_, err := cm.q.Exec(`DELETE FROM user_chat WHERE chat_id=$1 AND user_id=$2`, chatID, userID)
+
+	if err != nil {
+		return fmt.Errorf("psql RemoveUserFromChat: %w", err)
+	}
+	cm.logger.WithField("user successfully removed from chat", userID).Info("removeUserFromChat func")
+	return nil
+}
+
+func (mrc *MediaRepositoryController) GetChatMemberRole(chatID uint64, userID uint64) (string, error) {
+	return mrc.chatMembers(mrc.db).GetChatMemberRole(chatID, userID)
+}
+
+func (cm *chatMembers) GetChatMemberRole(chatID uint64, userID uint64) (string, error) {
+	var role string
+	err := cm.q.QueryRow(`SELECT role FROM user_chat WHERE chat_id=$1 AND user_id=$2`, chatID, userID).Scan(&role)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", internal_errors.ErrUserIsNotChatMember
+		}
+		return "", fmt.Errorf("psql GetChatMemberRole: %w", err)
+	}
+	return role, nil
+}
+
+func (mrc *MediaRepositoryController) UpdateChatMemberRole(chatID uint64, userID uint64, role string) error {
+	return mrc.chatMembers(mrc.db).UpdateChatMemberRole(chatID, userID, role)
+}
+
+func (cm *chatMembers) UpdateChatMemberRole(chatID uint64, userID uint64, role string) error {
+	_, err := cm.q.Exec(`UPDATE user_chat SET role=$1 WHERE chat_id=$2 AND user_id=$3`, role, chatID, userID)
+
+	if err != nil {
+		return fmt.Errorf("psql UpdateChatMemberRole: %w", err)
+	}
+	cm.logger.WithField("chat member role successfully updated", userID).Info("updateChatMemberRole func")
 	return nil
 }
 
@@ -51,6 +287,40 @@ func (mrc *MediaRepositoryController) GetChatUsers(chatID uint64) ([]uint64, err
 	return userIDs, nil
 }
 
+func (mrc *MediaRepositoryController) GetChatMembers(chatID uint64) ([]*models.ChatMember, error) {
+	return mrc.chatMembers(mrc.db).GetChatMembers(chatID)
+}
+
+func (cm *chatMembers) GetChatMembers(chatID uint64) ([]*models.ChatMember, error) {
+	rows, err := cm.q.Query(`SELECT u.user_id, u.user_name, u.nick_name, u.avatar_url, uc.role, uc.joined_at
+	 FROM user_chat uc JOIN "user" u ON u.user_id = uc.user_id
+	 WHERE uc.chat_id=$1 ORDER BY uc.joined_at, u.user_id`, chatID)
+
+	if err != nil {
+		return nil, fmt.Errorf("psql GetChatMembers %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]*models.ChatMember, 0)
+	for rows.Next() {
+		member := &models.ChatMember{}
+		if err := rows.Scan(&member.UserID,
+			&member.UserName,
+			&member.NickName,
+			&member.AvatarUrl,
+			&member.Role,
+			&member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("psql GetChatMembers rows.Next: %w", err)
+		}
+		member.AvatarUrl = cm.urls.OptionalUrl(member.AvatarUrl)
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetChatMembers rows.Err: %w", err)
+	}
+	return members, nil
+}
+
 func (mrc *MediaRepositoryController) GetUserChats(userID uint64) ([]uint64, error) {
 	rows, err := mrc.db.Query(`SELECT chat_id FROM user_chat WHERE user_id=$1`, userID)
 
@@ -74,13 +344,42 @@ func (mrc *MediaRepositoryController) GetUserChats(userID uint64) ([]uint64, err
 	return chatIDs, nil
 }
 
+// GetUserCompanions returns users sharing at least one chat with the given user.
+func (mrc *MediaRepositoryController) GetUserCompanions(userID uint64) ([]uint64, error) {
+	rows, err := mrc.db.Query(`SELECT DISTINCT companion.user_id FROM user_chat uc
+	 JOIN user_chat companion ON companion.chat_id = uc.chat_id
+	 WHERE uc.user_id=$1 AND companion.user_id<>$1`, userID)
+
+	if err != nil {
+		return nil, fmt.Errorf("psql GetUserCompanions %w", err)
+	}
+	defer rows.Close()
+
+	companionIDs := make([]uint64, 0)
+	for rows.Next() {
+		var companionID uint64
+		if err := rows.Scan(&companionID); err != nil {
+			return nil, fmt.Errorf("psql GetUserCompanions rows.Next: %w", err)
+		}
+		companionIDs = append(companionIDs, companionID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUserCompanions rows.Err: %w", err)
+	}
+	return companionIDs, nil
+}
+
 func (mrc *MediaRepositoryController) DeleteChat(chatID uint64) error {
-	_, err := mrc.db.Exec(`DELETE FROM chat WHERE chat_id=$1`, chatID)
+	return mrc.chatMembers(mrc.db).DeleteChat(chatID)
+}
+
+func (cm *chatMembers) DeleteChat(chatID uint64) error {
+	_, err := cm.q.Exec(`DELETE FROM chat WHERE chat_id=$1`, chatID)
 
 	if err != nil {
 		return fmt.Errorf("psql DeleteChat: %w", err)
 	}
 
-	mrc.logger.WithField("chat with was successfully deleted with chatID", chatID).Info("deleteChat func")
+	cm.logger.WithField("chat with was successfully deleted with chatID", chatID).Info("deleteChat func")
 	return nil
 }
@@ -1,15 +1,23 @@
 package mediarepository
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
-	"path/filepath"
 	"pinset/configs/s3"
+	"pinset/internal/app/models"
+	"pinset/internal/app/models/response"
 	"pinset/internal/app/usecase"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/mediaurl"
+	"pinset/pkg/objectstore"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/minio/minio-go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,37 +32,40 @@ const (
 	mimeAudMp3Type = "audio/mpeg"
 	mimeAudAacType = "audio/aac"
 	mimeAudWavType = "audio/wav"
+	// http.DetectContentType names WAV this way
+	mimeAudWaveType = "audio/wave"
 )
 
 const (
-	minioUploadFileType = "application/octet-stream"
+	defaultMediaContentType = "application/octet-stream"
 )
 
 type MediaRepositoryController struct {
 	db              *sql.DB
 	logger          *logrus.Logger
-	client          *minio.Client
+	store           objectstore.Store
+	urls            *mediaurl.Builder
+	signedUrlTTL    time.Duration
 	ImageBucketName string
 	VideoBucketName string
 	AudioBucketName string
 }
 
-func NewMediaRepository(db *sql.DB, logger *logrus.Logger) (usecase.MediaRepository, error) {
+// NewMediaRepository keeps the media objects in the store, see NewObjectStore.
+func NewMediaRepository(db *sql.DB, logger *logrus.Logger, store objectstore.Store, urls *mediaurl.Builder) usecase.MediaRepository {
 	config := s3.NewMinioParams()
-	client, err := NewMinioClient(config)
-	if err != nil {
-		return nil, fmt.Errorf("minio client: %w", err)
-	}
 
-	logger.Info("MinioRepo created succesful!")
+	logger.Info("MediaRepo created succesful!")
 	return &MediaRepositoryController{
 		db:              db,
 		logger:          logger,
-		client:          client,
+		store:           store,
+		urls:            urls,
+		signedUrlTTL:    config.SignedUrlExpiration,
 		ImageBucketName: config.ImageBucketName,
 		VideoBucketName: config.VideoBucketName,
 		AudioBucketName: config.AudioBucketName,
-	}, nil
+	}
 }
 
 func (mrc *MediaRepositoryController) GetBucketNameForContentType(fileType string) string {
@@ -63,7 +74,7 @@ func (mrc *MediaRepositoryController) GetBucketNameForContentType(fileType strin
 		return mrc.ImageBucketName
 	case mimeVidMp4Type:
 		return mrc.VideoBucketName
-	case mimeAudMp3Type, mimeAudAacType, mimeAudWavType:
+	case mimeAudMp3Type, mimeAudAacType, mimeAudWavType, mimeAudWaveType:
 		return mrc.AudioBucketName
 	default:
 		return ""
@@ -78,22 +89,239 @@ func (mrc *MediaRepositoryController) HasCorrectContentType(fileType string) boo
 		fileType == mimeVidMp4Type ||
 		fileType == mimeAudMp3Type ||
 		fileType == mimeAudAacType ||
-		fileType == mimeAudWavType
+		fileType == mimeAudWavType ||
+		fileType == mimeAudWaveType
+}
+
+func (mrc *MediaRepositoryController) HasImageContentType(fileType string) bool {
+	return mrc.GetBucketNameForContentType(fileType) == mrc.ImageBucketName
+}
+
+func (mrc *MediaRepositoryController) HasVideoContentType(fileType string) bool {
+	return mrc.GetBucketNameForContentType(fileType) == mrc.VideoBucketName
+}
+
+func (mrc *MediaRepositoryController) HasAudioContentType(fileType string) bool {
+	return mrc.GetBucketNameForContentType(fileType) == mrc.AudioBucketName
+}
+
+// IsImageMediaUrl reports whether the url points to an object of our image bucket.
+func (mrc *MediaRepositoryController) IsImageMediaUrl(mediaUrl string) bool {
+	mediaKey := mrc.urls.Key(mediaUrl)
+	if mediaurl.IsAbsolute(mediaKey) {
+		return false
+	}
+
+	bucketName, _, ok := mediaurl.SplitKey(mediaKey)
+	return ok && bucketName == mrc.ImageBucketName
+}
+
+// MediaUrlType is the kind of the media the url points to, told by its bucket.
+func (mrc *MediaRepositoryController) MediaUrlType(mediaUrl string) string {
+	return mrc.mediaType(mrc.urls.Key(mediaUrl), nil)
+}
+
+// mediaType is the kind of the registered media, media stored before the registry is told by its bucket.
+// External media of pins is always an image.
+func (mrc *MediaRepositoryController) mediaType(mediaKey string, contentType *string) string {
+	if contentType != nil {
+		mediaType, _, _ := strings.Cut(*contentType, "/")
+		return mediaType
+	}
+
+	bucketName, _, ok := mediaurl.SplitKey(mediaKey)
+	switch {
+	case !ok || mediaurl.IsAbsolute(mediaKey):
+		return models.MediaTypeImage
+	case bucketName == mrc.VideoBucketName:
+		return models.MediaTypeVideo
+	case bucketName == mrc.AudioBucketName:
+		return models.MediaTypeAudio
+	default:
+		return models.MediaTypeImage
+	}
+}
+
+// UploadMedia streams the content to the bucket and registers it in the media registry without references.
+// The object is removed when it can't be registered, since the sweeper finds only registered objects.
+// The MinIO store sends objects larger than 64MiB as a multipart upload,
+// in parallel parts when content implements io.ReaderAt (e.g. a spooled multipart file).
+func (mrc *MediaRepositoryController) UploadMedia(media *models.Media, content io.Reader) error {
+	if media.ContentType == "" {
+		media.ContentType = defaultMediaContentType
+	}
+
+	media.ObjectKey = uuid.New().String() + extensionsByContentType[media.ContentType]
+	if err := mrc.store.Put(media.BucketName, media.ObjectKey, content, media.Size, media.ContentType); err != nil {
+		return err
+	}
+
+	if err := createMedia(mrc.db, media); err != nil {
+		mrc.removeObjects(media.BucketName, []string{media.ObjectKey})
+		return err
+	}
+	return nil
+}
+
+// UploadImage stores the processed original next to its variants and registers them as a single media.
+// Variants are named after the original: <id>_<width>.<ext> and <id>_<width>.webp.
+// Stored objects are removed when any of the renditions or the registration fails.
+func (mrc *MediaRepositoryController) UploadImage(media *models.Media, img *imageproc.Result) error {
+	var storedKeys []string
+	if err := mrc.uploadImage(media, img, &storedKeys); err != nil {
+		mrc.removeObjects(media.BucketName, storedKeys)
+		return err
+	}
+	return nil
+}
+
+func (mrc *MediaRepositoryController) uploadImage(media *models.Media, img *imageproc.Result, storedKeys *[]string) error {
+	objectID := uuid.New().String()
+
+	objectKey, err := mrc.putRendition(media.BucketName, objectID, img.Original, storedKeys)
+	if err != nil {
+		return err
+	}
+
+	media.ObjectKey = objectKey
+	media.VariantKeys = make([]string, 0, 2*len(img.Variants))
+	media.ContentType = img.Original.ContentType
+	media.Size = int64(len(img.Original.Data))
+	perceptualHash := int64(img.PerceptualHash)
+	media.PerceptualHash = &perceptualHash
+	media.DominantColor, media.Blurhash = placeholderColumns(img.Placeholder)
+
+	variants := make([]imageVariantKeys, 0, len(img.Variants))
+	for _, variant := range img.Variants {
+		variantID := objectID + "_" + strconv.Itoa(variant.Image.Width)
+
+		variantKey, err := mrc.putRendition(media.BucketName, variantID, variant.Image, storedKeys)
+		if err != nil {
+			return err
+		}
+		webpKey, err := mrc.putRendition(media.BucketName, variantID, variant.WebP, storedKeys)
+		if err != nil {
+			return err
+		}
+		media.VariantKeys = append(media.VariantKeys, variantKey, webpKey)
+
+		variants = append(variants, imageVariantKeys{
+			Width:   variant.Image.Width,
+			Height:  variant.Image.Height,
+			Key:     mediaurl.JoinKey(media.BucketName, variantKey),
+			WebpKey: mediaurl.JoinKey(media.BucketName, webpKey),
+		})
+	}
+
+	variantsJSON, err := json.Marshal(variants)
+	if err != nil {
+		return fmt.Errorf("marshal image variants: %w", err)
+	}
+
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql UploadImage begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := createMedia(tx, media); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(CreateImageMedia, media.MediaKey, img.Width, img.Height, variantsJSON, media.DominantColor, media.Blurhash)
+	if err != nil {
+		return fmt.Errorf("psql UploadImage: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql UploadImage commit: %w", err)
+	}
+	return nil
 }
 
-func (mrc *MediaRepositoryController) UploadMedia(bucketName, fileName string, media io.Reader, mediaSize int64) (string, error) {
-	objectName := uuid.New().String() + filepath.Ext(fileName)
-	_, err := mrc.client.PutObject(bucketName, objectName, media, mediaSize, minio.PutObjectOptions{
-		ContentType: minioUploadFileType,
-	})
+// putRendition returns the key of the stored object and adds it to storedKeys.
+func (mrc *MediaRepositoryController) putRendition(bucketName, objectID string, rendition imageproc.Rendition, storedKeys *[]string) (string, error) {
+	objectName := objectID + imageproc.Extension(rendition.Format)
+	err := mrc.store.Put(bucketName, objectName, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.ContentType)
 	if err != nil {
 		return "", err
 	}
 
-	return mrc.GeneratePublicMediaUrl(bucketName, objectName), nil
+	*storedKeys = append(*storedKeys, objectName)
+	return objectName, nil
+}
+
+// removeObjects cleans up objects of a failed upload. Failures are only logged,
+// the error of the upload is the one worth returning.
+func (mrc *MediaRepositoryController) removeObjects(bucketName string, objectKeys []string) {
+	for _, objectKey := range objectKeys {
+		if err := mrc.store.Remove(bucketName, objectKey); err != nil {
+			mrc.logger.WithError(err).WithField("object", mediaurl.JoinKey(bucketName, objectKey)).Error("removing object of a failed upload")
+		}
+	}
+}
+
+// imageVariantKeys is a variant as stored in image_media
+type imageVariantKeys struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Key     string `json:"key"`
+	WebpKey string `json:"webp_key"`
 }
 
-func (mrc *MediaRepositoryController) GeneratePublicMediaUrl(bucketName, objectName string) string {
-	publicUrl := "http://" + s3.MinioEndPoint + "/" + bucketName + "/" + objectName
-	return publicUrl
+// imageVariants decodes variants joined from image_media, which is absent for non-image media.
+func (mrc *MediaRepositoryController) imageVariants(data []byte, private bool) ([]*response.ImageVariant, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var variantKeys []imageVariantKeys
+	if err := json.Unmarshal(data, &variantKeys); err != nil {
+		return nil, fmt.Errorf("unmarshal image variants: %w", err)
+	}
+
+	variants := make([]*response.ImageVariant, 0, len(variantKeys))
+	for _, variant := range variantKeys {
+		variants = append(variants, &response.ImageVariant{
+			Width:   variant.Width,
+			Height:  variant.Height,
+			Url:     mrc.mediaUrl(variant.Key, private),
+			WebpUrl: mrc.mediaUrl(variant.WebpKey, private),
+		})
+	}
+	return variants, nil
+}
+
+// PublicMediaUrl builds the URL of the stored media reference from the configured public base.
+func (mrc *MediaRepositoryController) PublicMediaUrl(mediaKey string) string {
+	return mrc.urls.Url(mediaKey)
+}
+
+// mediaUrl returns a presigned URL for private media of our storage and the public URL otherwise.
+func (mrc *MediaRepositoryController) mediaUrl(mediaKey string, private bool) string {
+	if !private || mediaurl.IsAbsolute(mediaKey) {
+		return mrc.urls.Url(mediaKey)
+	}
+
+	bucketName, objectName, ok := mediaurl.SplitKey(mediaKey)
+	if !ok {
+		return mrc.urls.Url(mediaKey)
+	}
+
+	// Signing is local, it fails only for malformed keys
+	signedUrl, err := mrc.store.PresignGet(bucketName, objectName, mrc.signedUrlTTL)
+	if err != nil {
+		mrc.logger.WithError(err).WithField("media_key", mediaKey).Error("failed to presign media url")
+		return ""
+	}
+	return signedUrl
+}
+
+// placeholderColumns returns NULL for the color of fully transparent images,
+// the blurhash is always set and marks the image as done for the backfill.
+func placeholderColumns(placeholder imageproc.Placeholder) (dominantColor, blurhash *string) {
+	if placeholder.DominantColor != "" {
+		dominantColor = &placeholder.DominantColor
+	}
+	return dominantColor, &placeholder.Blurhash
 }
@@ -0,0 +1,221 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"pinset/internal/app/models"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/mediaurl"
+	"time"
+)
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func createMedia(q queryRower, media *models.Media) error {
+	media.MediaKey = mediaurl.JoinKey(media.BucketName, media.ObjectKey)
+
+	var waveform *string
+	if media.Waveform != nil {
+		waveformJSON, err := json.Marshal(media.Waveform)
+		if err != nil {
+			return fmt.Errorf("marshal waveform: %w", err)
+		}
+		waveform = new(string)
+		*waveform = string(waveformJSON)
+	}
+
+	err := q.QueryRow(CreateMedia,
+		media.OwnerID,
+		media.BucketName,
+		media.ObjectKey,
+		media.VariantKeys,
+		media.ContentType,
+		media.Size,
+		media.Checksum,
+		media.PerceptualHash,
+		media.Width,
+		media.Height,
+		media.DurationMs,
+		media.PosterKey,
+		waveform,
+		media.DominantColor,
+		media.Blurhash).Scan(&media.MediaID, &media.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("psql CreateMedia: %w", err)
+	}
+	return nil
+}
+
+// CreateMedia registers an object that is already in the storage.
+func (mrc *MediaRepositoryController) CreateMedia(media *models.Media) error {
+	if media.VariantKeys == nil {
+		media.VariantKeys = []string{}
+	}
+	return createMedia(mrc.db, media)
+}
+
+// GetUnreferencedMedia returns media that has had no references since before the given time, oldest first.
+func (mrc *MediaRepositoryController) GetUnreferencedMedia(unreferencedBefore time.Time, limit int) ([]*models.Media, error) {
+	rows, err := mrc.db.Query(GetUnreferencedMedia, unreferencedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetUnreferencedMedia: %w", err)
+	}
+	defer rows.Close()
+
+	var mediaList []*models.Media
+	for rows.Next() {
+		media, err := scanMedia(rows)
+		if err != nil {
+			return nil, fmt.Errorf("psql GetUnreferencedMedia scan: %w", err)
+		}
+		mediaList = append(mediaList, media)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetUnreferencedMedia rows: %w", err)
+	}
+
+	return mediaList, nil
+}
+
+// DeleteUnreferencedMedia removes the registry entry unless the media got referenced or reused meanwhile.
+// deleted is false in that case and the objects must be kept.
+func (mrc *MediaRepositoryController) DeleteUnreferencedMedia(mediaID uint64, unreferencedBefore time.Time) (deleted bool, err error) {
+	var deletedCount int
+	if err := mrc.db.QueryRow(DeleteUnreferencedMedia, mediaID, unreferencedBefore).Scan(&deletedCount); err != nil {
+		return false, fmt.Errorf("psql DeleteUnreferencedMedia: %w", err)
+	}
+	return deletedCount > 0, nil
+}
+
+// ReuseMediaByChecksum returns media of the bucket with the same content, nil if there is none.
+func (mrc *MediaRepositoryController) ReuseMediaByChecksum(bucketName, checksum string) (*models.Media, error) {
+	media, err := scanMedia(mrc.db.QueryRow(ReuseMediaByChecksum, bucketName, checksum))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("psql ReuseMediaByChecksum: %w", err)
+	}
+	return media, nil
+}
+
+// GetSimilarPins returns other pins with images at most maxDistance bits away from the image of the pin, newest first.
+func (mrc *MediaRepositoryController) GetSimilarPins(pinID uint64, maxDistance, limit int) ([]uint64, error) {
+	rows, err := mrc.db.Query(GetSimilarPins, pinID, maxDistance, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetSimilarPins: %w", err)
+	}
+	defer rows.Close()
+
+	var pinIDs []uint64
+	for rows.Next() {
+		var similarPinID uint64
+		if err := rows.Scan(&similarPinID); err != nil {
+			return nil, fmt.Errorf("psql GetSimilarPins scan: %w", err)
+		}
+		pinIDs = append(pinIDs, similarPinID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetSimilarPins rows: %w", err)
+	}
+
+	return pinIDs, nil
+}
+
+// GetMediaWithoutPlaceholder returns a batch of images without a placeholder registered after the given media.
+func (mrc *MediaRepositoryController) GetMediaWithoutPlaceholder(afterMediaID uint64, limit int) ([]*models.Media, error) {
+	rows, err := mrc.db.Query(GetMediaWithoutPlaceholder, afterMediaID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetMediaWithoutPlaceholder: %w", err)
+	}
+	defer rows.Close()
+
+	var mediaList []*models.Media
+	for rows.Next() {
+		media, err := scanMedia(rows)
+		if err != nil {
+			return nil, fmt.Errorf("psql GetMediaWithoutPlaceholder scan: %w", err)
+		}
+		mediaList = append(mediaList, media)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetMediaWithoutPlaceholder rows: %w", err)
+	}
+
+	return mediaList, nil
+}
+
+func (mrc *MediaRepositoryController) UpdateMediaPlaceholder(mediaID uint64, placeholder imageproc.Placeholder) error {
+	dominantColor, blurhash := placeholderColumns(placeholder)
+	if _, err := mrc.db.Exec(UpdateMediaPlaceholder, mediaID, dominantColor, blurhash); err != nil {
+		return fmt.Errorf("psql UpdateMediaPlaceholder: %w", err)
+	}
+	return nil
+}
+
+// GetImageKeysWithoutPlaceholder returns a batch of image keys in use without a placeholder after the given key.
+func (mrc *MediaRepositoryController) GetImageKeysWithoutPlaceholder(afterMediaKey string, limit int) ([]string, error) {
+	rows, err := mrc.db.Query(GetImageKeysWithoutPlaceholder, mrc.ImageBucketName, afterMediaKey, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetImageKeysWithoutPlaceholder: %w", err)
+	}
+	defer rows.Close()
+
+	var mediaKeys []string
+	for rows.Next() {
+		var mediaKey string
+		if err := rows.Scan(&mediaKey); err != nil {
+			return nil, fmt.Errorf("psql GetImageKeysWithoutPlaceholder scan: %w", err)
+		}
+		mediaKeys = append(mediaKeys, mediaKey)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetImageKeysWithoutPlaceholder rows: %w", err)
+	}
+
+	return mediaKeys, nil
+}
+
+// UpdateImageKeyPlaceholder stores the placeholder of the image key, the size is used for keys never processed into image_media.
+func (mrc *MediaRepositoryController) UpdateImageKeyPlaceholder(mediaKey string, width, height int, placeholder imageproc.Placeholder) error {
+	dominantColor, blurhash := placeholderColumns(placeholder)
+	if _, err := mrc.db.Exec(UpdateImageKeyPlaceholder, mediaKey, width, height, dominantColor, blurhash); err != nil {
+		return fmt.Errorf("psql UpdateImageKeyPlaceholder: %w", err)
+	}
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMedia(row scanner) (*models.Media, error) {
+	media := &models.Media{}
+	var variantKeys []byte
+	err := row.Scan(
+		&media.MediaID,
+		&media.OwnerID,
+		&media.BucketName,
+		&media.ObjectKey,
+		&variantKeys,
+		&media.ContentType,
+		&media.Size,
+		&media.Checksum,
+		&media.PerceptualHash,
+		&media.MediaKey,
+		&media.RefCount,
+		&media.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variantKeys, &media.VariantKeys); err != nil {
+		return nil, fmt.Errorf("unmarshal variant keys: %w", err)
+	}
+	return media, nil
+}
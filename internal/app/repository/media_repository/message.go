@@ -1,21 +1,65 @@
 package mediarepository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+	"slices"
 )
 
 func (mrc *MediaRepositoryController) CreateMessage(msg *models.Message) (*models.MessageCreateInfo, error) {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("psql CreateMessage begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	crMsg, err := mrc.chatMembers(tx).CreateMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("psql CreateMessage commit: %w", err)
+	}
+	return crMsg, nil
+}
+
+// CreateMessage creates the message with its attachments, the caller runs it in a transaction
+func (cm *chatMembers) CreateMessage(msg *models.Message) (*models.MessageCreateInfo, error) {
 	crMsg := &models.MessageCreateInfo{}
-	err := mrc.db.QueryRow(`INSERT INTO msg (author_id, chat_id, content, created_at) VALUES ($1, $2, $3, $4) 
-	RETURNING message_id, author_id, chat_id, content, created_at`,
-		msg.SenderID, msg.ChatID, msg.Content, msg.CreatedAt).Scan(&crMsg.ID, &crMsg.SenderID, &crMsg.ChatID, &crMsg.Content, &crMsg.CreatedAt)
+	if msg.Type == "" {
+		msg.Type = models.MessageTypeText
+	}
+
+	err := cm.q.QueryRow(`INSERT INTO msg (author_id, chat_id, content, message_type, target_id, created_at) VALUES ($1, $2, $3, $4, $5, $6) 
+	RETURNING message_id, author_id, chat_id, content, message_type, target_id, created_at`,
+		msg.SenderID, msg.ChatID, msg.Content, msg.Type, msg.TargetID, msg.CreatedAt).
+		Scan(&crMsg.ID, &crMsg.SenderID, &crMsg.ChatID, &crMsg.Content, &crMsg.Type, &crMsg.TargetID, &crMsg.CreatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("psql CreateMessage: %w", err)
 	}
 
-	mrc.logger.WithField("message was succesfully created with messageID", crMsg.ID).Info("createMessage func")
+	crMsg.Attachments = make([]*models.MessageAttachment, 0, len(msg.Attachments))
+	for _, attachment := range msg.Attachments {
+		crAttachment := &models.MessageAttachment{}
+		err = cm.q.QueryRow(`INSERT INTO msg_attachment (message_id, attachment_type, pin_id, board_id, media_url, media_id)
+		VALUES ($1, $2, $3, $4, $5, (SELECT media_id FROM media WHERE media_key = $5))
+		RETURNING attachment_id, attachment_type, pin_id, board_id, media_url`,
+			crMsg.ID, attachment.Type, attachment.PinID, attachment.BoardID, cm.urls.OptionalKey(attachment.MediaUrl)).
+			Scan(&crAttachment.AttachmentID, &crAttachment.Type, &crAttachment.PinID, &crAttachment.BoardID, &crAttachment.MediaUrl)
+
+		if err != nil {
+			return nil, fmt.Errorf("psql CreateMessage attachment: %w", err)
+		}
+		crAttachment.MediaUrl = cm.urls.OptionalUrl(crAttachment.MediaUrl)
+		crMsg.Attachments = append(crMsg.Attachments, crAttachment)
+	}
+
+	cm.logger.WithField("message was succesfully created with messageID", crMsg.ID).Info("createMessage func")
 	return crMsg, nil
 }
 
@@ -42,27 +86,166 @@ func (mrc *MediaRepositoryController) UpdateMessage(msg *models.MessageUpdate) e
 
 }
 
-func (mrc *MediaRepositoryController) GetChatMessages(chatID uint64) ([]*models.MessageInfo, error) {
-	rows, err := mrc.db.Query(`SELECT message_id, chat_id, author_id, content, created_at FROM msg WHERE chat_id=$1`, chatID)
+const messageColumns = `m.message_id, m.chat_id, m.author_id, m.content, m.message_type, m.target_id, m.created_at`
+
+// GetChatMessages returns a page of chat history in chronological order.
+func (mrc *MediaRepositoryController) GetChatMessages(chatID uint64, params *models.MessagesPageParams) ([]*models.MessageInfo, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	switch {
+	case params.AfterID != nil:
+		rows, err = mrc.db.Query(`SELECT `+messageColumns+` FROM msg m
+		WHERE m.chat_id=$1 AND m.message_id>$2 ORDER BY m.message_id LIMIT $3`, chatID, *params.AfterID, params.Limit)
+	case params.BeforeID != nil:
+		rows, err = mrc.db.Query(`SELECT `+messageColumns+` FROM msg m
+		WHERE m.chat_id=$1 AND m.message_id<$2 ORDER BY m.message_id DESC LIMIT $3`, chatID, *params.BeforeID, params.Limit)
+	default:
+		rows, err = mrc.db.Query(`SELECT `+messageColumns+` FROM msg m
+		WHERE m.chat_id=$1 ORDER BY m.message_id DESC LIMIT $2`, chatID, params.Limit)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("getChatMessages: %w", err)
 	}
 	defer rows.Close()
 
-	var messageList []*models.MessageInfo
+	messageList, err := scanMessages(rows)
+	if err != nil {
+		return nil, fmt.Errorf("getChatMessages %w", err)
+	}
+
+	// Pages going back in history are selected newest first
+	if params.AfterID == nil {
+		slices.Reverse(messageList)
+	}
+	return messageList, nil
+}
+
+func (mrc *MediaRepositoryController) GetChatMessage(chatID, messageID uint64) (*models.MessageInfo, error) {
+	message := &models.MessageInfo{}
+	err := mrc.db.QueryRow(`SELECT `+messageColumns+` FROM msg m WHERE m.chat_id=$1 AND m.message_id=$2`, chatID, messageID).
+		Scan(&message.ID,
+			&message.ChatID,
+			&message.SenderID,
+			&message.Content,
+			&message.Type,
+			&message.TargetID,
+			&message.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internal_errors.ErrMessageDoesntExists
+		}
+		return nil, fmt.Errorf("psql GetChatMessage: %w", err)
+	}
+	return message, nil
+}
+
+// SearchMessages runs a full-text search over text messages of the chats the user is a member of.
+// Results are ordered from the newest message.
+func (mrc *MediaRepositoryController) SearchMessages(params *models.MessageSearchParams) ([]*models.MessageInfo, error) {
+	var chatID, beforeID *int64
+	if params.ChatID != nil {
+		id := int64(*params.ChatID)
+		chatID = &id
+	}
+	if params.BeforeID != nil {
+		id := int64(*params.BeforeID)
+		beforeID = &id
+	}
+
+	rows, err := mrc.db.Query(`SELECT `+messageColumns+` FROM msg m
+	JOIN user_chat uc ON uc.chat_id = m.chat_id AND uc.user_id=$1
+	WHERE m.message_type=$2 AND m.content_tsv @@ plainto_tsquery('simple', $3)
+	AND ($4::INT IS NULL OR m.chat_id=$4)
+	AND ($5::INT IS NULL OR m.message_id<$5)
+	ORDER BY m.message_id DESC LIMIT $6`,
+		params.UserID, models.MessageTypeText, params.Query, chatID, beforeID, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("searchMessages: %w", err)
+	}
+	defer rows.Close()
+
+	messageList, err := scanMessages(rows)
+	if err != nil {
+		return nil, fmt.Errorf("searchMessages %w", err)
+	}
+	return messageList, nil
+}
+
+// GetMessagesAttachments returns attachments of the given messages grouped by message ID.
+func (mrc *MediaRepositoryController) GetMessagesAttachments(messageIDs []uint64) (map[uint64][]*models.MessageAttachment, error) {
+	attachments := make(map[uint64][]*models.MessageAttachment)
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	ids := make([]int64, 0, len(messageIDs))
+	for _, messageID := range messageIDs {
+		ids = append(ids, int64(messageID))
+	}
+
+	rows, err := mrc.db.Query(`SELECT message_id, attachment_id, attachment_type, pin_id, board_id, media_url
+	FROM msg_attachment WHERE message_id = ANY($1) ORDER BY attachment_id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("getMessagesAttachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID uint64
+		attachment := &models.MessageAttachment{}
+		if err := rows.Scan(&messageID,
+			&attachment.AttachmentID,
+			&attachment.Type,
+			&attachment.PinID,
+			&attachment.BoardID,
+			&attachment.MediaUrl); err != nil {
+			return nil, fmt.Errorf("getMessagesAttachments rows.Next: %w", err)
+		}
+		attachment.MediaUrl = mrc.urls.OptionalUrl(attachment.MediaUrl)
+		attachments[messageID] = append(attachments[messageID], attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getMessagesAttachments rows.Err: %w", err)
+	}
+	return attachments, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]*models.MessageInfo, error) {
+	messageList := make([]*models.MessageInfo, 0)
 	for rows.Next() {
 		message := &models.MessageInfo{}
 		if err := rows.Scan(&message.ID,
 			&message.ChatID,
 			&message.SenderID,
 			&message.Content,
+			&message.Type,
+			&message.TargetID,
 			&message.CreatedAt); err != nil {
-			return nil, fmt.Errorf("getChatMessages rows.Next: %w", err)
+			return nil, fmt.Errorf("rows.Next: %w", err)
 		}
 		messageList = append(messageList, message)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("getChatMessages rows.Err: %w", err)
+		return nil, fmt.Errorf("rows.Err: %w", err)
 	}
 	return messageList, nil
 }
+
+// IsBoardSharedWithUser reports whether the board was sent to any chat the user is a member of.
+func (mrc *MediaRepositoryController) IsBoardSharedWithUser(boardID, userID uint64) (bool, error) {
+	var shared bool
+	err := mrc.db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM msg_attachment a
+		JOIN msg m ON m.message_id = a.message_id
+		JOIN user_chat uc ON uc.chat_id = m.chat_id
+		WHERE a.board_id=$1 AND uc.user_id=$2)`, boardID, userID).Scan(&shared)
+
+	if err != nil {
+		return false, fmt.Errorf("psql IsBoardSharedWithUser: %w", err)
+	}
+	return shared, nil
+}
@@ -0,0 +1,41 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"fmt"
+	"pinset/internal/app/models"
+)
+
+func createNotification(tx *sql.Tx, notification *models.Notification) error {
+	err := tx.QueryRow(CreateNotification, notification.RecipientID, notification.ActorID, notification.Type,
+		notification.PinID, notification.BoardID).Scan(&notification.NotificationID, &notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create notification: %w", err)
+	}
+	return nil
+}
+
+// GetNotifications returns the latest notifications of the user, the newest first.
+func (mrc *MediaRepositoryController) GetNotifications(recipientID uint64, limit int) ([]*models.Notification, error) {
+	rows, err := mrc.db.Query(GetNotifications, recipientID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetNotifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		notification := &models.Notification{}
+		var pinID sql.NullInt64
+		if err := rows.Scan(&notification.NotificationID, &notification.RecipientID, &notification.ActorID, &notification.ActorNickName,
+			&notification.ActorAvatarUrl, &notification.Type, &pinID, &notification.BoardID, &notification.CreatedAt); err != nil {
+			return nil, fmt.Errorf("psql GetNotifications rows.Next: %w", err)
+		}
+		notification.PinID = uint64(pinID.Int64)
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetNotifications rows.Err: %w", err)
+	}
+	return notifications, nil
+}
@@ -0,0 +1,83 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+)
+
+func (mrc *MediaRepositoryController) IsPinInBoard(boardID, pinID uint64) (bool, error) {
+	var saved bool
+	if err := mrc.db.QueryRow(IsPinInBoard, boardID, pinID).Scan(&saved); err != nil {
+		return false, fmt.Errorf("psql IsPinInBoard: %w", err)
+	}
+	return saved, nil
+}
+
+// SavePin adds the pin to the board with attribution and counts the save.
+// The notification, if any, is stored in the same transaction.
+func (mrc *MediaRepositoryController) SavePin(save *models.PinSave, notification *models.Notification) error {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql SavePin begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pinID uint64
+	err = tx.QueryRow(AddPinToBoard, save.BoardID, save.PinID).Scan(&pinID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return internal_errors.ErrPinAlreadySaved
+	}
+	if err != nil {
+		return fmt.Errorf("psql SavePin: %w", err)
+	}
+
+	err = tx.QueryRow(CreatePinSave, save.BoardID, save.PinID, save.SaverID, save.FromBoardID, save.FromUserID).Scan(&save.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("psql SavePin: %w", err)
+	}
+	if err := tx.QueryRow(IncreasePinSaves, save.PinID).Scan(&save.Saves); err != nil {
+		return fmt.Errorf("psql SavePin: %w", err)
+	}
+
+	if notification != nil {
+		if err := createNotification(tx, notification); err != nil {
+			return fmt.Errorf("psql SavePin: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql SavePin commit: %w", err)
+	}
+
+	mrc.logger.WithField("pin was successfully saved to board", save.BoardID).Info("SavePin func")
+	return nil
+}
+
+// GetPinSavedFrom returns the chain of saves that brought the pin to the board, the nearest first.
+func (mrc *MediaRepositoryController) GetPinSavedFrom(pinID, boardID uint64, maxDepth int) ([]*models.PinSaveLink, error) {
+	rows, err := mrc.db.Query(GetPinSavedFrom, pinID, boardID, maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetPinSavedFrom: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.PinSaveLink
+	for rows.Next() {
+		link := &models.PinSaveLink{}
+		var public bool
+		if err := rows.Scan(&link.UserID, &link.NickName, &link.BoardID, &link.BoardName, &public); err != nil {
+			return nil, fmt.Errorf("psql GetPinSavedFrom rows.Next: %w", err)
+		}
+		if !public {
+			link.BoardID, link.BoardName = nil, nil
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetPinSavedFrom rows.Err: %w", err)
+	}
+	return links, nil
+}
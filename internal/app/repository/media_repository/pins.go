@@ -2,6 +2,7 @@ package mediarepository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"pinset/internal/app/models"
@@ -12,7 +13,14 @@ import (
 )
 
 func (mrc *MediaRepositoryController) CreatePin(pin *models.Pin) error {
-	err := mrc.db.QueryRow(CreatePin, pin.AuthorID, pin.Title, pin.Description, pin.MediaUrl, pin.RelatedLink).Scan(&pin.PinID)
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql CreatePin begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(CreatePin, pin.AuthorID, pin.Title, pin.Description, mrc.urls.OptionalKey(pin.MediaUrl), pin.RelatedLink,
+		mrc.urls.OptionalKey(pin.PosterUrl), pin.Status, pin.PublishAt).Scan(&pin.PinID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			pin.PinID = 0
@@ -24,6 +32,13 @@ func (mrc *MediaRepositoryController) CreatePin(pin *models.Pin) error {
 		return internal_errors.ErrBadPinInputData
 	}
 
+	if err := mrc.createCarouselItems(tx, pin); err != nil {
+		return fmt.Errorf("psql CreatePin: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql CreatePin commit: %w", err)
+	}
+
 	mrc.logger.WithField("pin was succesfully created with pinID", pin.PinID).Info("createPin func")
 	return nil
 }
@@ -35,32 +50,100 @@ func (mrc *MediaRepositoryController) GetAllPins(userID uint64) ([]*models.Pin,
 	}
 	defer rows.Close()
 
+	pins, err := mrc.scanPins(rows)
+	if err != nil {
+		return nil, fmt.Errorf("getAllPins: %w", err)
+	}
+	return pins, nil
+}
+
+// GetUnpublishedPinsByAuthorID returns drafts and scheduled pins of the author.
+func (mrc *MediaRepositoryController) GetUnpublishedPinsByAuthorID(authorID uint64) ([]*models.Pin, error) {
+	rows, err := mrc.db.Query(GetUnpublishedPinsByAuthorID, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("getUnpublishedPinsByAuthorID: %w", err)
+	}
+	defer rows.Close()
+
+	pins, err := mrc.scanPins(rows)
+	if err != nil {
+		return nil, fmt.Errorf("getUnpublishedPinsByAuthorID: %w", err)
+	}
+	return pins, nil
+}
+
+// PublishDuePins publishes up to limit scheduled pins whose time has come and returns their IDs.
+func (mrc *MediaRepositoryController) PublishDuePins(limit int) ([]uint64, error) {
+	rows, err := mrc.db.Query(PublishDuePins, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql PublishDuePins: %w", err)
+	}
+	defer rows.Close()
+
+	var pinIDs []uint64
+	for rows.Next() {
+		var pinID uint64
+		if err := rows.Scan(&pinID); err != nil {
+			return nil, fmt.Errorf("psql PublishDuePins scan: %w", err)
+		}
+		pinIDs = append(pinIDs, pinID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql PublishDuePins rows: %w", err)
+	}
+
+	return pinIDs, nil
+}
+
+// scanPins reads rows of pinListColumns.
+func (mrc *MediaRepositoryController) scanPins(rows *sql.Rows) ([]*models.Pin, error) {
 	var pins []*models.Pin
 	for rows.Next() {
-		pin := &models.Pin{}
-
-		err := rows.Scan(
-			&pin.PinID,
-			&pin.AuthorID,
-			&pin.MediaUrl,
-			&pin.Title,
-			&pin.Description,
-			&pin.Bookmarks,
-			&pin.Views)
+		pin, err := mrc.scanPin(rows)
 		if err != nil {
-			return nil, fmt.Errorf("getAllPins rows.Next: %w", err)
+			return nil, err
 		}
-
 		pins = append(pins, pin)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
 
 	return pins, nil
 }
 
+// scanPin reads the current row of pinListColumns preceded by the leading columns.
+func (mrc *MediaRepositoryController) scanPin(rows *sql.Rows, leading ...any) (*models.Pin, error) {
+	pin := &models.Pin{}
+	var media pinMediaRow
+
+	err := rows.Scan(append(append(leading,
+		&pin.PinID,
+		&pin.AuthorID,
+		&media.key,
+		&pin.Title,
+		&pin.Description,
+		&pin.Bookmarks,
+		&pin.Views,
+		&pin.Status,
+		&pin.PublishAt,
+		&pin.MediaCount), media.dest(&pin.PinMedia)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("rows.Next: %w", err)
+	}
+
+	if err := mrc.fillPinMedia(&pin.PinMedia, &media); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
 func (mrc *MediaRepositoryController) GetPinPreviewInfoByPinID(pinID uint64) (*models.Pin, error) {
 	var pinPreviewInfo models.Pin
+	var media pinMediaRow
 
-	err := mrc.db.QueryRow(GetPinPreviewInfoByPinID, pinID).Scan(&pinPreviewInfo.PinID, &pinPreviewInfo.AuthorID, &pinPreviewInfo.MediaUrl, &pinPreviewInfo.Views)
+	err := mrc.db.QueryRow(GetPinPreviewInfoByPinID, pinID).Scan(append([]any{&pinPreviewInfo.PinID, &pinPreviewInfo.AuthorID, &media.key, &pinPreviewInfo.Views,
+		&pinPreviewInfo.Status, &pinPreviewInfo.PublishAt, &pinPreviewInfo.MediaCount}, media.dest(&pinPreviewInfo.PinMedia)...)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, internal_errors.ErrPinDoesntExists
@@ -68,21 +151,29 @@ func (mrc *MediaRepositoryController) GetPinPreviewInfoByPinID(pinID uint64) (*m
 		return nil, fmt.Errorf("psql getPinPreviewInfoByPinID: %w", err)
 	}
 
+	if err := mrc.fillPinMedia(&pinPreviewInfo.PinMedia, &media); err != nil {
+		return nil, fmt.Errorf("psql getPinPreviewInfoByPinID: %w", err)
+	}
+
 	return &pinPreviewInfo, nil
 }
 
 func (mrc *MediaRepositoryController) GetPinPageInfoByPinID(pinID uint64) (*models.Pin, error) {
 	var pinPreviewInfo models.Pin
+	var media pinMediaRow
 
-	err := mrc.db.QueryRow(GetPinPageInfoByPinID, pinID).Scan(
+	err := mrc.db.QueryRow(GetPinPageInfoByPinID, pinID).Scan(append([]any{
 		&pinPreviewInfo.PinID,
 		&pinPreviewInfo.AuthorID,
 		&pinPreviewInfo.Title,
 		&pinPreviewInfo.Description,
 		&pinPreviewInfo.RelatedLink,
-		&pinPreviewInfo.MediaUrl,
+		&media.key,
 		&pinPreviewInfo.Geolocation,
-		&pinPreviewInfo.CreationTime)
+		&pinPreviewInfo.CreationTime,
+		&pinPreviewInfo.Status,
+		&pinPreviewInfo.PublishAt,
+		&pinPreviewInfo.Saves}, media.dest(&pinPreviewInfo.PinMedia)...)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, internal_errors.ErrPinDoesntExists
@@ -90,6 +181,13 @@ func (mrc *MediaRepositoryController) GetPinPageInfoByPinID(pinID uint64) (*mode
 		return nil, fmt.Errorf("psql getPinPageInfoByPinID: %w", err)
 	}
 
+	if err := mrc.fillPinMedia(&pinPreviewInfo.PinMedia, &media); err != nil {
+		return nil, fmt.Errorf("psql getPinPageInfoByPinID: %w", err)
+	}
+	if err := mrc.fillPinCarousel(&pinPreviewInfo); err != nil {
+		return nil, fmt.Errorf("psql getPinPageInfoByPinID: %w", err)
+	}
+
 	authorInfo := &models.UserPin{}
 	authorInfo.UserID = pinPreviewInfo.AuthorID
 
@@ -100,6 +198,7 @@ func (mrc *MediaRepositoryController) GetPinPageInfoByPinID(pinID uint64) (*mode
 			return nil, fmt.Errorf("getAllPins GetUserInfoForPin: %w", err)
 		}
 	}
+	authorInfo.AvatarUrl = mrc.urls.OptionalUrl(authorInfo.AvatarUrl)
 
 	err = mrc.db.QueryRow(userRepository.GetFollowingsCount, &pinPreviewInfo.AuthorID).
 		Scan(&authorInfo.FollowingsCount)
@@ -121,6 +220,7 @@ func (mrc *MediaRepositoryController) GetPinAuthorNickNameByUserID(userID uint64
 		}
 		return nil, fmt.Errorf("psql getPinAuthorNickNameByUserID: %w", err)
 	}
+	author.AvatarUrl = mrc.urls.OptionalUrl(author.AvatarUrl)
 
 	return &author, nil
 }
@@ -141,7 +241,14 @@ func (mrc *MediaRepositoryController) GetPinBookmarksNumberByPinID(pinID uint64)
 func (mrc *MediaRepositoryController) UpdatePinInfoByPinID(pin *models.Pin) error {
 	var pinID uint64
 
-	_, err := mrc.db.Exec(UpdatePinInfoByPinID, pin.Title, pin.Description, pin.BoardID, pin.MediaUrl, pin.RelatedLink, pin.Geolocation, pin.PinID)
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql updatePinInfoByPinID begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(UpdatePinInfoByPinID, pin.Title, pin.Description, pin.BoardID, mrc.urls.OptionalKey(pin.MediaUrl), pin.RelatedLink, pin.Geolocation, pin.PinID,
+		mrc.urls.OptionalKey(pin.PosterUrl), pin.Status, pin.PublishAt)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return internal_errors.ErrPinDoesntExists
@@ -149,20 +256,20 @@ func (mrc *MediaRepositoryController) UpdatePinInfoByPinID(pin *models.Pin) erro
 		return fmt.Errorf("psql updatePinInfoByPinID: %w", err)
 	}
 
-	mrc.logger.WithField("updatePinInfoByPinID with pinID:", pinID).Info()
-	return nil
-}
-
-func (mrc *MediaRepositoryController) UpdatePinViewsByPinID(pinID uint64) error {
-	_, err := mrc.db.Exec(UpdatePinViewsByPinID, pinID)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return internal_errors.ErrPinDoesntExists
+	// The carousel is kept unless a new order of items is given
+	if pin.Carousel != nil {
+		if _, err := tx.Exec(DeletePinCarouselItems, pin.PinID); err != nil {
+			return fmt.Errorf("psql updatePinInfoByPinID: %w", err)
+		}
+		if err := mrc.createCarouselItems(tx, pin); err != nil {
+			return fmt.Errorf("psql updatePinInfoByPinID: %w", err)
 		}
-		return fmt.Errorf("psql updatePinViewsByPinID: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql updatePinInfoByPinID commit: %w", err)
 	}
 
-	mrc.logger.WithField("updatePinViewsByPinID successfull with pinID:", pinID).Info()
+	mrc.logger.WithField("updatePinInfoByPinID with pinID:", pinID).Info()
 	return nil
 }
 
@@ -218,7 +325,7 @@ func (mrc *MediaRepositoryController) GetBookmarkOnUserPin(ownerID, pinID uint64
 
 	err := mrc.db.QueryRow(GetBookmarkOnUserPin, ownerID, pinID).Scan(&bookmarkID)
 	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, sql.ErrNoRows) {
 			return 0, internal_errors.ErrBookmarkDoesntExists
 		}
 		return 0, fmt.Errorf("psql getBookmarkOnUserPin: %w", err)
@@ -228,45 +335,93 @@ func (mrc *MediaRepositoryController) GetBookmarkOnUserPin(ownerID, pinID uint64
 	return bookmarkID, nil
 }
 
-func (mrc *MediaRepositoryController) CreatePinBookmark(bookmark *models.Bookmark) error {
-	err := mrc.db.QueryRow(CreatePinBookmark, bookmark.OwnerID, bookmark.PinID, bookmark.BookmarkTime).Scan(&bookmark.BookmarkID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			bookmark.BookmarkID = 0
-		}
-		return fmt.Errorf("psql createPinBookmark: %w", err)
-	}
+// pinMediaRow holds the media columns of pin queries that aren't scanned into the pin directly
+type pinMediaRow struct {
+	key         string
+	variants    []byte
+	contentType *string
+	waveform    []byte
+	posterKey   *string
+	private     bool
+}
 
-	if bookmark.BookmarkID == 0 {
-		return internal_errors.ErrBadBookmarkInputData
+// dest returns the scan destinations of pinMediaColumns.
+func (row *pinMediaRow) dest(media *models.PinMedia) []any {
+	return []any{
+		&media.MediaWidth,
+		&media.MediaHeight,
+		&row.variants,
+		&row.contentType,
+		&media.MediaDuration,
+		&row.waveform,
+		&media.MediaColor,
+		&media.MediaBlurhash,
+		&row.posterKey,
+		&row.private,
 	}
-
-	mrc.logger.WithField("bookmark was succesfully created with bookmarkID", bookmark.BookmarkID).Info("createPin func")
-	return nil
 }
 
-func (mrc *MediaRepositoryController) DeletePinBookmarkByOwnerIDAndPinID(bookmark models.Bookmark) error {
-	_, err := mrc.db.Exec(DeletePinBookmarkByOwnerIDAndPinID, bookmark.OwnerID, bookmark.PinID)
-	if err != nil {
-		return internal_errors.ErrBookmarkDoesntExists
+// fillPinMedia turns the stored media key, image variants and poster of the pin into URLs.
+func (mrc *MediaRepositoryController) fillPinMedia(pin *models.PinMedia, media *pinMediaRow) error {
+	mediaUrl := mrc.mediaUrl(media.key, media.private)
+	pin.MediaUrl = &mediaUrl
+	pin.MediaType = mrc.mediaType(media.key, media.contentType)
+
+	if media.posterKey != nil {
+		posterUrl := mrc.mediaUrl(*media.posterKey, media.private)
+		pin.PosterUrl = &posterUrl
+	}
+	if media.waveform != nil {
+		if err := json.Unmarshal(media.waveform, &pin.MediaWaveform); err != nil {
+			return fmt.Errorf("unmarshal waveform: %w", err)
+		}
 	}
 
-	mrc.logger.WithField("bookmark was succesfully deleted with ownerID", bookmark.OwnerID).Info()
-	return nil
+	var err error
+	pin.MediaVariants, err = mrc.imageVariants(media.variants, media.private)
+	return err
 }
 
-func (mrc *MediaRepositoryController) UpdateBookmarksCountIncrease(pinID uint64) error {
-	_, err := mrc.db.Exec(UpdateBookmarksCountIncrease, pinID)
-	if err != nil {
-		return internal_errors.ErrPinDoesntExists
+// createCarouselItems stores the items of the carousel after the cover, which is the media of the pin itself.
+func (mrc *MediaRepositoryController) createCarouselItems(tx *sql.Tx, pin *models.Pin) error {
+	for i := 1; i < len(pin.Carousel); i++ {
+		_, err := tx.Exec(CreatePinCarouselItem, pin.PinID, i, mrc.urls.OptionalKey(pin.Carousel[i].MediaUrl))
+		if err != nil {
+			return fmt.Errorf("create carousel item: %w", err)
+		}
 	}
 	return nil
 }
 
-func (mrc *MediaRepositoryController) UpdateBookmarksCountDecrease(pinID uint64) error {
-	_, err := mrc.db.Exec(UpdateBookmarksCountDecrease, pinID)
+// fillPinCarousel loads the carousel of a pin with several media, the cover goes first.
+func (mrc *MediaRepositoryController) fillPinCarousel(pin *models.Pin) error {
+	rows, err := mrc.db.Query(GetPinCarouselItems, pin.PinID)
 	if err != nil {
-		return internal_errors.ErrPinDoesntExists
+		return fmt.Errorf("getPinCarouselItems: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.PinMedia
+	for rows.Next() {
+		item := &models.PinMedia{}
+		var media pinMediaRow
+
+		if err := rows.Scan(append([]any{&media.key}, media.dest(item)...)...); err != nil {
+			return fmt.Errorf("getPinCarouselItems rows.Next: %w", err)
+		}
+		if err := mrc.fillPinMedia(item, &media); err != nil {
+			return fmt.Errorf("getPinCarouselItems: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("getPinCarouselItems rows.Err: %w", err)
+	}
+
+	pin.MediaCount = len(items) + 1
+	if len(items) > 0 {
+		cover := pin.PinMedia
+		pin.Carousel = append([]*models.PinMedia{&cover}, items...)
 	}
 	return nil
 }
@@ -2,42 +2,256 @@ package mediarepository
 
 // Pins CRUD
 const (
+	// pinMediaPrivate is true for pins saved to private boards only, their media is served by presigned URLs
+	pinMediaPrivate = `(EXISTS (SELECT 1 FROM saved_pin_to_board s WHERE s.pin_id = p.pin_id)
+		AND NOT EXISTS (SELECT 1 FROM saved_pin_to_board s JOIN board b ON b.board_id = s.board_id WHERE s.pin_id = p.pin_id AND b.public))`
+
+	// mediaInfoColumns describe the media joined as im and m with its poster pm.
+	// Sizes of images come from image_media, of videos from the registry.
+	// Images of keys without a registry entry keep the placeholder in image_media, videos and audio show the one of their poster.
+	mediaInfoColumns = `COALESCE(im.width, m.width), COALESCE(im.height, m.height), im.variants, m.content_type, m.duration_ms, m.waveform,
+		COALESCE(m.dominant_color, im.dominant_color, pm.dominant_color), COALESCE(m.blurhash, im.blurhash, pm.blurhash)`
+
+	// pinMediaColumns describe the media of pin p joined by pinMediaJoins, see pinMediaRow.
+	// The poster of the pin overrides the one of the file.
+	pinMediaColumns = mediaInfoColumns + `, COALESCE(p.poster_key, pm.media_key), ` + pinMediaPrivate
+	pinMediaJoins   = `LEFT JOIN image_media im ON im.media_key = p.media_key LEFT JOIN media m ON m.media_id = p.media_id
+		LEFT JOIN media pm ON pm.media_id = CASE WHEN p.poster_key IS NULL THEN m.poster_media_id ELSE p.poster_media_id END`
+
+	// Carousel items after the cover, the media of the pin itself
+	GetPinCarouselItems = `SELECT pi.media_key, ` + mediaInfoColumns + `, pm.media_key, ` + pinMediaPrivate + `
+	FROM pin_media pi JOIN pin p ON p.pin_id = pi.pin_id
+		LEFT JOIN image_media im ON im.media_key = pi.media_key LEFT JOIN media m ON m.media_id = pi.media_id
+		LEFT JOIN media pm ON pm.media_id = m.poster_media_id
+	WHERE pi.pin_id = $1 ORDER BY pi.position;`
+	pinMediaCount = `(SELECT COUNT(*) + 1 FROM pin_media pi WHERE pi.pin_id = p.pin_id)`
+
+	// pinListColumns describe pins of lists, see scanPins
+	pinListColumns = `p.pin_id, p.author_id, p.media_key, p.title, p.description, p.bookmarks, p.views, p.status, p.publish_at, ` +
+		pinMediaCount + `, ` + pinMediaColumns
+	CreatePinCarouselItem  = `INSERT INTO pin_media (pin_id, position, media_key, media_id) VALUES ($1, $2, $3, (SELECT media_id FROM media WHERE media_key = $3));`
+	DeletePinCarouselItems = `DELETE FROM pin_media WHERE pin_id = $1;`
+
 	GetUserInfoForPin = `SELECT nick_name, avatar_url FROM "user" WHERE user_id = $1`
 
-	CreatePin = `INSERT INTO pin (author_id, title, description, media_url, related_link) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING RETURNING pin_id;`
+	CreatePin = `INSERT INTO pin (author_id, title, description, media_key, related_link, media_id, poster_key, poster_media_id, status, publish_at)
+	VALUES ($1, $2, $3, $4, $5, (SELECT media_id FROM media WHERE media_key = $4), $6, (SELECT media_id FROM media WHERE media_key = $6), $7, $8)
+	ON CONFLICT DO NOTHING RETURNING pin_id;`
 
 	AddPinToBoard            = `INSERT INTO saved_pin_to_board (board_id, pin_id) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING pin_id;`
 	DeletePinFromBoard       = `DELETE FROM saved_pin_to_board WHERE board_id = $1 AND pin_id = $2;`
-	GetAllPins               = `SELECT pin_id, author_id, media_url, title, description, bookmarks, views FROM pin;`
-	GetPinPreviewInfoByPinID = `SELECT pin_id, author_id, media_url, views FROM pin WHERE pin_id = $1;`
-	GetPinPageInfoByPinID    = `SELECT pin_id, author_id, title, description, related_link, media_url, geolocation, creation_time FROM pin WHERE pin_id = $1;`
-	GetPinAuthorByUserID     = `SELECT nick_name, avatar_url FROM "user" WHERE user_id = $1`
-	GetPinsIDByBoardID       = `SELECT pin_id FROM saved_pin_to_board WHERE board_id = $1;`
-
-	UpdatePinInfoByPinID       = `UPDATE pin SET title = $1, description = $2, board_id = $3, media_url = $4, related_link = $5, geolocation = $6 WHERE pin_id = $7`
-	UpdatePinViewsByPinID      = `UPDATE pin SET views = views + 1 WHERE pin_id = $1;`
+	GetAllPins               = `SELECT ` + pinListColumns + ` FROM pin p ` + pinMediaJoins + ` WHERE p.status = 'published';`
+	GetPinPreviewInfoByPinID = `SELECT p.pin_id, p.author_id, p.media_key, p.views, p.status, p.publish_at, ` + pinMediaCount + `, ` + pinMediaColumns + ` FROM pin p ` + pinMediaJoins + ` WHERE p.pin_id = $1;`
+	GetPinPageInfoByPinID    = `SELECT p.pin_id, p.author_id, p.title, p.description, p.related_link, p.media_key, p.geolocation, p.creation_time, p.status, p.publish_at, p.saves, ` + pinMediaColumns + ` FROM pin p ` + pinMediaJoins + ` WHERE p.pin_id = $1;`
+
+	// Scheduled pins go first in the order of publishing, then drafts from the newest
+	GetUnpublishedPinsByAuthorID = `SELECT ` + pinListColumns + ` FROM pin p ` + pinMediaJoins + `
+	WHERE p.author_id = $1 AND p.status <> 'published' ORDER BY p.publish_at NULLS LAST, p.pin_id DESC;`
+
+	// Pins are taken with SKIP LOCKED, so schedulers of several instances publish different pins
+	PublishDuePins = `UPDATE pin SET status = 'published' WHERE pin_id IN (
+		SELECT pin_id FROM pin WHERE status = 'scheduled' AND publish_at <= NOW() ORDER BY publish_at LIMIT $1 FOR UPDATE SKIP LOCKED
+	) RETURNING pin_id;`
+	GetPinAuthorByUserID = `SELECT nick_name, avatar_url FROM "user" WHERE user_id = $1`
+	GetPinsIDByBoardID   = `SELECT pin_id FROM saved_pin_to_board WHERE board_id = $1;`
+
+	UpdatePinInfoByPinID = `UPDATE pin SET title = $1, description = $2, board_id = $3, media_key = $4, media_id = (SELECT media_id FROM media WHERE media_key = $4), related_link = $5, geolocation = $6,
+		poster_key = $8, poster_media_id = (SELECT media_id FROM media WHERE media_key = $8),
+		status = COALESCE(NULLIF($9, ''), status), publish_at = CASE WHEN $9 = '' THEN publish_at ELSE $10 END WHERE pin_id = $7`
 	UpdatePinUpdateTimeByPinID = `UPDATE pin SET update_time = $1 WHERE pin_id = $2;`
 
+	// Views of deleted pins are skipped, so a flush is not failed by a pin deleted after it was viewed
+	AddPinViews      = `UPDATE pin SET views = views + $2 WHERE pin_id = $1;`
+	AddPinDailyViews = `INSERT INTO pin_view_daily (pin_id, day, views) SELECT pin_id, $2, $3 FROM pin WHERE pin_id = $1
+	ON CONFLICT (pin_id, day) DO UPDATE SET views = pin_view_daily.views + EXCLUDED.views;`
+
 	DeletePinByPinID = `DELETE FROM pin WHERE pin_id = $1;`
 
 	// Related things
 	GetAllCommentariesByPinID = `SELECT * FROM comment WHERE pin_id = $1;`
 
-	UpdateBookmarksCounter             = `UPDATE pin SET bookmarks = bookmarks + 1 WHERE pin_id = $1;`
 	GetPinBookmarksNumberByPinID       = `SELECT COUNT(bookmark_id) FROM bookmark WHERE pin_id = $1;`
 	GetBookmarkOnUserPin               = `SELECT bookmark_id FROM bookmark WHERE owner_id = $1 AND pin_id = $2`
-	CreatePinBookmark                  = `INSERT INTO bookmark (owner_id, pin_id, bookmark_time) VALUES($1, $2, $3) ON CONFLICT DO NOTHING RETURNING bookmark_id;`
+	CreatePinBookmark                  = `INSERT INTO bookmark (owner_id, pin_id, bookmark_time) VALUES($1, $2, $3) ON CONFLICT (owner_id, pin_id) DO NOTHING RETURNING bookmark_id;`
 	UpdateBookmarksCountIncrease       = `UPDATE pin SET bookmarks = bookmarks + 1 WHERE pin_id = $1`
 	UpdateBookmarksCountDecrease       = `UPDATE pin SET bookmarks = bookmarks - 1 WHERE pin_id = $1`
-	DeletePinBookmarkByOwnerIDAndPinID = `DELETE FROM bookmark WHERE owner_id = $1 AND pin_id = $2;`
+	DeletePinBookmarkByOwnerIDAndPinID = `DELETE FROM bookmark WHERE owner_id = $1 AND pin_id = $2 RETURNING bookmark_id;`
+	GetBookmarkedPinIDs                = `SELECT pin_id FROM bookmark WHERE owner_id = $1 AND pin_id = ANY($2);`
+
+	// Bookmarks of the same time are ordered by ID, so the page bound is unambiguous.
+	// The bound is compared by value, it doesn't have to exist anymore.
+	GetBookmarkedPins = `SELECT bm.bookmark_id, bm.bookmark_time, ` + pinListColumns + `
+	FROM bookmark bm JOIN pin p ON p.pin_id = bm.pin_id ` + pinMediaJoins + `
+	WHERE bm.owner_id = $1 AND p.status = 'published'
+		AND ($2::TIMESTAMPTZ IS NULL OR (bm.bookmark_time, bm.bookmark_id) < ($2::TIMESTAMPTZ, $3::INT))
+	ORDER BY bm.bookmark_time DESC, bm.bookmark_id DESC LIMIT $4;`
+
+	// Pins are locked against counter updates only, bookmarks of the pins can be created meanwhile
+	LockPinCounters     = `SELECT pin_id FROM pin WHERE pin_id > $1 ORDER BY pin_id LIMIT $2 FOR NO KEY UPDATE;`
+	RecountPinBookmarks = `UPDATE pin p SET bookmarks = c.count
+	FROM (SELECT l.pin_id, (SELECT COUNT(*) FROM bookmark b WHERE b.pin_id = l.pin_id) AS count FROM pin l WHERE l.pin_id = ANY($1)) c
+	WHERE p.pin_id = c.pin_id AND p.bookmarks IS DISTINCT FROM c.count;`
+)
+
+// Pin saves
+const (
+	IsPinInBoard     = `SELECT EXISTS (SELECT 1 FROM saved_pin_to_board WHERE board_id = $1 AND pin_id = $2);`
+	CreatePinSave    = `INSERT INTO pin_save (board_id, pin_id, saver_id, from_board_id, from_user_id) VALUES ($1, $2, $3, $4, $5) RETURNING created_at;`
+	IncreasePinSaves = `UPDATE pin SET saves = saves + 1 WHERE pin_id = $1 RETURNING saves;`
+
+	// The chain follows the boards the pin was saved from, depth guards against cycles left by deleted saves
+	GetPinSavedFrom = `WITH RECURSIVE chain AS (
+		SELECT from_board_id, from_user_id, 1 AS depth FROM pin_save WHERE pin_id = $1 AND board_id = $2
+		UNION ALL
+		SELECT s.from_board_id, s.from_user_id, c.depth + 1 FROM chain c JOIN pin_save s ON s.pin_id = $1 AND s.board_id = c.from_board_id
+		WHERE c.depth < $3
+	)
+	SELECT u.user_id, u.nick_name, b.board_id, b.name, COALESCE(b.public, false) FROM chain c
+		JOIN "user" u ON u.user_id = c.from_user_id LEFT JOIN board b ON b.board_id = c.from_board_id
+	ORDER BY c.depth;`
+)
+
+// Pin reactions
+const (
+	CreatePinReaction       = `INSERT INTO pin_reaction (pin_id, user_id, type) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING RETURNING type;`
+	GetPinReactionForUpdate = `SELECT type FROM pin_reaction WHERE pin_id = $1 AND user_id = $2 FOR UPDATE;`
+	UpdatePinReaction       = `UPDATE pin_reaction SET type = $3, created_at = NOW() WHERE pin_id = $1 AND user_id = $2;`
+	DeletePinReaction       = `DELETE FROM pin_reaction WHERE pin_id = $1 AND user_id = $2;`
+	GetPinsReactionCounts   = `SELECT pin_id, type, count FROM pin_reaction_count WHERE pin_id = ANY($1) AND count > 0;`
+	GetUserReactionsOnPins  = `SELECT pin_id, type FROM pin_reaction WHERE user_id = $1 AND pin_id = ANY($2);`
+)
+
+// Notifications
+const (
+	CreateNotification = `INSERT INTO notification (recipient_id, actor_id, type, pin_id, board_id) VALUES ($1, $2, $3, $4, $5) RETURNING notification_id, created_at;`
+	GetNotifications   = `SELECT n.notification_id, n.recipient_id, n.actor_id, u.nick_name, u.avatar_url, n.type, n.pin_id, n.board_id, n.created_at
+	FROM notification n JOIN "user" u ON u.user_id = n.actor_id
+	WHERE n.recipient_id = $1 ORDER BY n.notification_id DESC LIMIT $2;`
 )
 
 // Boards
 const (
 	GetAllBoardsByOwnerID = `SELECT * FROM BOARD WHERE owner_id = $1`
-	GetBoardByBoardID     = `SELECT * FROM board WHERE board_id = $1`
+	GetBoardByBoardID     = `SELECT board_id, owner_id, cover, name, description, public, creation_time, update_time FROM board WHERE board_id = $1`
 
 	CreateBoard          = `INSERT INTO board (owner_id, name, description, public) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING RETURNING board_id;`
 	UpdateBoardByBoardID = `UPDATE board SET name = $1, description = $2, public = $3 RETURNING board_id;`
 	DeleteBoardByBoardID = `DELETE FROM board WHERE board_id = $1`
 )
+
+// Image media
+const (
+	CreateImageMedia = `INSERT INTO image_media (media_key, width, height, variants, dominant_color, blurhash) VALUES ($1, $2, $3, $4, $5, $6);`
+
+	// Image keys shown by pins and carousels or processed into image_media, registered or not,
+	// that have no placeholder in image_media, in the order of keys
+	GetImageKeysWithoutPlaceholder = `SELECT k.media_key FROM (
+		SELECT media_key FROM pin UNION SELECT media_key FROM pin_media UNION SELECT media_key FROM image_media
+	) k
+	WHERE k.media_key LIKE $1 || '/%' AND k.media_key > $2
+		AND NOT EXISTS (SELECT 1 FROM image_media im WHERE im.media_key = k.media_key AND im.blurhash IS NOT NULL)
+	ORDER BY k.media_key LIMIT $3;`
+	// The registry entry of the key gets the placeholder too unless it has one
+	UpdateImageKeyPlaceholder = `WITH registered AS (
+		UPDATE media SET dominant_color = $4, blurhash = $5 WHERE media_key = $1 AND blurhash IS NULL
+	)
+	INSERT INTO image_media (media_key, width, height, dominant_color, blurhash) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (media_key) DO UPDATE SET dominant_color = EXCLUDED.dominant_color, blurhash = EXCLUDED.blurhash;`
+)
+
+// Media uploads
+const (
+	CreateUpload   = `INSERT INTO media_upload (upload_id, user_id, bucket_name, object_name, content_type, size, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING status, created_at;`
+	GetUpload      = `SELECT ` + uploadColumns + ` FROM media_upload WHERE upload_id = $1;`
+	CompleteUpload = `UPDATE media_upload SET status = 'completed', content_type = $2, media_key = $3, completed_at = NOW() WHERE upload_id = $1 AND status = 'pending' RETURNING status, completed_at;`
+
+	GetExpiredUploads   = `SELECT ` + uploadColumns + ` FROM media_upload WHERE status = 'pending' AND expires_at < $1 ORDER BY expires_at LIMIT $2;`
+	DeletePendingUpload = `DELETE FROM media_upload WHERE upload_id = $1 AND status = 'pending';`
+
+	uploadColumns = `upload_id, user_id, bucket_name, object_name, content_type, size, media_key, status, created_at, expires_at, completed_at`
+)
+
+// Media registry
+const (
+	mediaColumns = `media_id, owner_id, bucket_name, object_key, to_jsonb(variant_keys), content_type, size, checksum, phash, media_key, ref_count, created_at`
+
+	CreateMedia = `INSERT INTO media (owner_id, bucket_name, object_key, variant_keys, content_type, size, checksum, phash, width, height, duration_ms, poster_media_id, waveform,
+		dominant_color, blurhash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, (SELECT media_id FROM media WHERE media_key = $12), $13::jsonb, $14, $15) RETURNING media_id, created_at;`
+	GetUnreferencedMedia = `SELECT ` + mediaColumns + ` FROM media WHERE ref_count = 0 AND unreferenced_since < $1 ORDER BY unreferenced_since LIMIT $2;`
+
+	// Images are removed from image_media together with the registry entry
+	DeleteUnreferencedMedia = `WITH deleted AS (
+		DELETE FROM media WHERE media_id = $1 AND ref_count = 0 AND unreferenced_since < $2 RETURNING media_key
+	), deleted_image AS (
+		DELETE FROM image_media im USING deleted d WHERE im.media_key = d.media_key
+	)
+	SELECT COUNT(*) FROM deleted;`
+
+	// The found media is protected from the sweeper for another grace period, since it is about to be referenced
+	ReuseMediaByChecksum = `UPDATE media SET unreferenced_since = CASE WHEN ref_count = 0 THEN NOW() ELSE unreferenced_since END
+	WHERE media_id = (SELECT media_id FROM media WHERE bucket_name = $1 AND checksum = $2 ORDER BY media_id LIMIT 1)
+	RETURNING ` + mediaColumns + `;`
+
+	// Images registered before placeholders were computed, in the order of registration
+	GetMediaWithoutPlaceholder = `SELECT ` + mediaColumns + ` FROM media
+	WHERE blurhash IS NULL AND content_type LIKE 'image/%' AND media_id > $1 ORDER BY media_id LIMIT $2;`
+	UpdateMediaPlaceholder = `UPDATE media SET dominant_color = $2, blurhash = $3 WHERE media_id = $1;`
+
+	// Pins are compared by the Hamming distance of the perceptual hashes of their media
+	GetSimilarPins = `SELECT p.pin_id FROM pin p JOIN media m ON m.media_id = p.media_id
+	WHERE p.pin_id <> $1 AND p.status = 'published' AND m.phash IS NOT NULL
+		AND bit_count((m.phash # (SELECT sm.phash FROM pin sp JOIN media sm ON sm.media_id = sp.media_id WHERE sp.pin_id = $1))::BIT(64)) <= $2
+	ORDER BY p.pin_id DESC LIMIT $3;`
+)
+
+// Analytics
+const (
+	AddPinLinkClicks = `INSERT INTO pin_link_click_daily (pin_id, day, clicks) SELECT pin_id, $2, $3 FROM pin WHERE pin_id = $1
+	ON CONFLICT (pin_id, day) DO UPDATE SET clicks = pin_link_click_daily.clicks + EXCLUDED.clicks;`
+
+	// Stats of the days since $1 are recounted from scratch, so days that lost all their activity are cleared.
+	// Bookmarks and comments are counted while they exist, removed ones drop out of the past days on the next recount.
+	DeletePinStatsSince = `DELETE FROM pin_stats_daily WHERE day >= $1::date;`
+	RollupPinStats      = `INSERT INTO pin_stats_daily (pin_id, day, views, bookmarks, saves, comments, link_clicks)
+	SELECT pin_id, day, SUM(views), SUM(bookmarks), SUM(saves), SUM(comments), SUM(link_clicks) FROM (
+		SELECT pin_id, day, views, 0 AS bookmarks, 0 AS saves, 0 AS comments, 0 AS link_clicks FROM pin_view_daily WHERE day >= $1::date
+		UNION ALL
+		SELECT pin_id, (bookmark_time AT TIME ZONE 'UTC')::date, 0, 1, 0, 0, 0 FROM bookmark WHERE bookmark_time >= $1::date::timestamp AT TIME ZONE 'UTC'
+		UNION ALL
+		SELECT pin_id, (created_at AT TIME ZONE 'UTC')::date, 0, 0, 1, 0, 0 FROM pin_save WHERE created_at >= $1::date::timestamp AT TIME ZONE 'UTC'
+		UNION ALL
+		SELECT pin_id, (creation_time AT TIME ZONE 'UTC')::date, 0, 0, 0, 1, 0 FROM comment WHERE creation_time >= $1::date::timestamp AT TIME ZONE 'UTC'
+		UNION ALL
+		SELECT pin_id, day, 0, 0, 0, 0, clicks FROM pin_link_click_daily WHERE day >= $1::date
+	) events GROUP BY pin_id, day;`
+
+	// Users are snapshotted while they have followers and once more after losing the last one
+	SnapshotFollowers = `INSERT INTO user_follower_daily (user_id, day, followers)
+	SELECT u.user_id, $1::date, COUNT(f.follower_id) FROM "user" u LEFT JOIN follower f ON f.owner_id = u.user_id
+	WHERE u.user_id IN (
+		SELECT owner_id FROM follower
+		UNION
+		SELECT user_id FROM user_follower_daily WHERE day >= $1::date - 1 AND followers > 0
+	)
+	GROUP BY u.user_id
+	ON CONFLICT (user_id, day) DO UPDATE SET followers = EXCLUDED.followers;`
+
+	GetPinStatsSeries = `SELECT day, views, bookmarks, saves, comments, link_clicks FROM pin_stats_daily
+	WHERE pin_id = $1 AND day BETWEEN $2::date AND $3::date ORDER BY day;`
+	// Only pins of the owner of the board are summed, stats of pins saved from other authors are not theirs
+	GetBoardStatsSeries = `SELECT s.day, SUM(s.views), SUM(s.bookmarks), SUM(s.saves), SUM(s.comments), SUM(s.link_clicks)
+	FROM pin_stats_daily s JOIN saved_pin_to_board b ON b.pin_id = s.pin_id JOIN pin p ON p.pin_id = s.pin_id
+	WHERE b.board_id = $1 AND p.author_id = $2 AND s.day BETWEEN $3::date AND $4::date
+	GROUP BY s.day ORDER BY s.day;`
+	// %s is the column of the metric pins are ranked by
+	GetTopPins = `SELECT p.pin_id, p.title, SUM(s.views), SUM(s.bookmarks), SUM(s.saves), SUM(s.comments), SUM(s.link_clicks)
+	FROM pin_stats_daily s JOIN pin p ON p.pin_id = s.pin_id
+	WHERE p.author_id = $1 AND s.day BETWEEN $2::date AND $3::date
+	GROUP BY p.pin_id, p.title ORDER BY SUM(s.%s) DESC, p.pin_id DESC LIMIT $4;`
+	// The last snapshot before the range is the starting point of the series
+	GetFollowerSnapshots = `SELECT day, followers FROM user_follower_daily
+	WHERE user_id = $1 AND day <= $3::date
+		AND day >= COALESCE((SELECT MAX(day) FROM user_follower_daily WHERE user_id = $1 AND day < $2::date), $2::date)
+	ORDER BY day;`
+)
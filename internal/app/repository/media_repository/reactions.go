@@ -0,0 +1,119 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"pinset/internal/app/models"
+)
+
+// maxReactionToggleAttempts bounds retries when the reaction is removed by a concurrent request
+// between the conflicting insert and the lock of the existing row
+const maxReactionToggleAttempts = 3
+
+// TogglePinReaction sets the reaction of the user, removes it if it's of the same type or replaces the other one.
+// Returns the reaction of the user after the toggle, nil if removed. Counters follow the reactions by the pin_reaction_count trigger.
+func (mrc *MediaRepositoryController) TogglePinReaction(pinID, userID uint64, reactionType string) (*string, error) {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("psql TogglePinReaction begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	reaction, err := togglePinReaction(tx, pinID, userID, reactionType)
+	if err != nil {
+		return nil, fmt.Errorf("psql TogglePinReaction: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("psql TogglePinReaction commit: %w", err)
+	}
+	return reaction, nil
+}
+
+func togglePinReaction(tx *sql.Tx, pinID, userID uint64, reactionType string) (*string, error) {
+	for attempt := 0; attempt < maxReactionToggleAttempts; attempt++ {
+		var created string
+		err := tx.QueryRow(CreatePinReaction, pinID, userID, reactionType).Scan(&created)
+		if err == nil {
+			return &reactionType, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		var current string
+		err = tx.QueryRow(GetPinReactionForUpdate, pinID, userID).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if current == reactionType {
+			_, err := tx.Exec(DeletePinReaction, pinID, userID)
+			return nil, err
+		}
+		if _, err := tx.Exec(UpdatePinReaction, pinID, userID, reactionType); err != nil {
+			return nil, err
+		}
+		return &reactionType, nil
+	}
+	return nil, fmt.Errorf("reaction changed concurrently %d times", maxReactionToggleAttempts)
+}
+
+// GetPinsReactions returns reactions of the pins with the ones of the viewer, anonymous viewers have none.
+// Every pin gets an entry, even without reactions.
+func (mrc *MediaRepositoryController) GetPinsReactions(pinIDs []uint64, viewerID uint64) (map[uint64]*models.PinReactions, error) {
+	reactions := make(map[uint64]*models.PinReactions, len(pinIDs))
+	if len(pinIDs) == 0 {
+		return reactions, nil
+	}
+
+	ids := make([]int64, 0, len(pinIDs))
+	for _, pinID := range pinIDs {
+		ids = append(ids, int64(pinID))
+		reactions[pinID] = &models.PinReactions{Counts: map[string]uint64{}}
+	}
+
+	rows, err := mrc.db.Query(GetPinsReactionCounts, ids)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetPinsReactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pinID, count uint64
+		var reactionType string
+		if err := rows.Scan(&pinID, &reactionType, &count); err != nil {
+			return nil, fmt.Errorf("psql GetPinsReactions rows.Next: %w", err)
+		}
+		reactions[pinID].Counts[reactionType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetPinsReactions rows.Err: %w", err)
+	}
+
+	if viewerID == 0 {
+		return reactions, nil
+	}
+
+	viewerRows, err := mrc.db.Query(GetUserReactionsOnPins, viewerID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetPinsReactions: %w", err)
+	}
+	defer viewerRows.Close()
+
+	for viewerRows.Next() {
+		var pinID uint64
+		var reactionType string
+		if err := viewerRows.Scan(&pinID, &reactionType); err != nil {
+			return nil, fmt.Errorf("psql GetPinsReactions rows.Next: %w", err)
+		}
+		reactions[pinID].MyReaction = &reactionType
+	}
+	if err := viewerRows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetPinsReactions rows.Err: %w", err)
+	}
+	return reactions, nil
+}
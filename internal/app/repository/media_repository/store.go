@@ -0,0 +1,91 @@
+package mediarepository
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"pinset/configs/s3"
+	"pinset/pkg/objectstore"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+)
+
+const (
+	minioBucketLocation = "eu-central-1"
+
+	signingKeySize = 32
+)
+
+// NewObjectStore creates the backend selected by the storage config. The local backends create the buckets of the media,
+// the ones of MinIO must already exist. Objects of the local backends are served by the returned handler, it is nil for MinIO.
+func NewObjectStore(config s3.MinioParams, storage s3.StorageParams) (objectstore.Store, http.Handler, error) {
+	var (
+		store   objectstore.Store
+		handler http.Handler
+	)
+
+	switch storage.Backend {
+	case s3.StorageBackendMinio:
+		client, err := NewMinioClient(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("minio client: %w", err)
+		}
+		presignClient, err := NewMinioPresignClient(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("minio presign client: %w", err)
+		}
+		minioStore := objectstore.NewMinioStore(client, presignClient)
+		for _, bucketName := range config.Buckets() {
+			if err := minioStore.CheckBucket(bucketName); err != nil {
+				return nil, nil, err
+			}
+		}
+		store = minioStore
+	case s3.StorageBackendFS, s3.StorageBackendMemory:
+		signingKey := storage.SigningKey
+		if len(signingKey) == 0 {
+			// URLs signed before a restart become invalid, which is fine for development
+			signingKey = make([]byte, signingKeySize)
+			if _, err := rand.Read(signingKey); err != nil {
+				return nil, nil, fmt.Errorf("signing key: %w", err)
+			}
+		}
+		signer := objectstore.NewURLSigner(storage.LocalBaseUrl, signingKey)
+
+		var localStore objectstore.LocalStore
+		if storage.Backend == s3.StorageBackendFS {
+			localStore = objectstore.NewFileStore(storage.LocalRoot, signer)
+		} else {
+			localStore = objectstore.NewMemoryStore(signer)
+		}
+		for _, bucketName := range config.Buckets() {
+			if err := localStore.EnsureBucket(bucketName); err != nil {
+				return nil, nil, fmt.Errorf("bucket %s: %w", bucketName, err)
+			}
+		}
+		store = localStore
+		handler = objectstore.NewHandler(store, signer)
+	default:
+		return nil, nil, fmt.Errorf("unknown object store backend %q", storage.Backend)
+	}
+
+	return store, handler, nil
+}
+
+func NewMinioClient(config s3.MinioParams) (*minio.Client, error) {
+	return minio.NewWithOptions(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure: config.UseSSL,
+	})
+}
+
+// NewMinioPresignClient creates a client that only signs URLs for the public endpoint.
+// The region is fixed, so the client never calls the storage to look up bucket locations.
+func NewMinioPresignClient(config s3.MinioParams) (*minio.Client, error) {
+	return minio.NewWithOptions(config.PublicEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure: config.UseSSL,
+		Region: minioBucketLocation,
+	})
+}
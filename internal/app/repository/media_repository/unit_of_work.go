@@ -0,0 +1,24 @@
+package mediarepository
+
+import (
+	"fmt"
+	"pinset/internal/app/usecase"
+)
+
+// RunInTx runs fn as a unit of work: its statements are committed together if fn succeeds
+// and rolled back otherwise. The repository passed to fn must not be used after fn returns.
+func (mrc *MediaRepositoryController) RunInTx(fn func(repo usecase.TxRepository) error) error {
+	tx, err := mrc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("psql RunInTx begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&txRepository{tx: tx, logger: mrc.logger}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("psql RunInTx commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package mediarepository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"pinset/internal/app/models"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+	"pinset/pkg/objectstore"
+
+	"github.com/google/uuid"
+)
+
+var extensionsByContentType = map[string]string{
+	mimeImgJpegType: ".jpg",
+	mimeImgJpgType:  ".jpg",
+	mimeImgPngType:  ".png",
+	mimeImgGifType:  ".gif",
+	mimeVidMp4Type:  ".mp4",
+	mimeAudMp3Type:  ".mp3",
+	mimeAudAacType:  ".aac",
+	mimeAudWavType:  ".wav",
+	mimeAudWaveType: ".wav",
+}
+
+// CreateUpload registers a pending upload and generates the object key the client is allowed to put.
+func (mrc *MediaRepositoryController) CreateUpload(upload *models.Upload) error {
+	upload.UploadID = uuid.New().String()
+	upload.ObjectName = uuid.New().String() + extensionsByContentType[upload.ContentType]
+
+	err := mrc.db.QueryRow(CreateUpload,
+		upload.UploadID,
+		upload.UserID,
+		upload.BucketName,
+		upload.ObjectName,
+		upload.ContentType,
+		upload.Size,
+		upload.ExpiresAt).Scan(&upload.Status, &upload.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("psql CreateUpload: %w", err)
+	}
+	return nil
+}
+
+func (mrc *MediaRepositoryController) GetUpload(uploadID string) (*models.Upload, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil, internal_errors.ErrUploadDoesntExists
+	}
+
+	upload := &models.Upload{}
+	err := mrc.db.QueryRow(GetUpload, uploadID).Scan(
+		&upload.UploadID,
+		&upload.UserID,
+		&upload.BucketName,
+		&upload.ObjectName,
+		&upload.ContentType,
+		&upload.Size,
+		&upload.MediaKey,
+		&upload.Status,
+		&upload.CreatedAt,
+		&upload.ExpiresAt,
+		&upload.CompletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internal_errors.ErrUploadDoesntExists
+		}
+		return nil, fmt.Errorf("psql GetUpload: %w", err)
+	}
+	upload.MediaUrl = mrc.urls.OptionalUrl(upload.MediaKey)
+	return upload, nil
+}
+
+// CompleteUpload marks the pending upload completed with the sniffed content type and the key of the media.
+func (mrc *MediaRepositoryController) CompleteUpload(upload *models.Upload) error {
+	err := mrc.db.QueryRow(CompleteUpload, upload.UploadID, upload.ContentType, upload.MediaKey).
+		Scan(&upload.Status, &upload.CompletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal_errors.ErrUploadDoesntExists
+		}
+		return fmt.Errorf("psql CompleteUpload: %w", err)
+	}
+	upload.MediaUrl = mrc.urls.OptionalUrl(upload.MediaKey)
+	return nil
+}
+
+// PresignUploadForm returns the url and the fields of a POST form uploading the object of the upload.
+// The declared type and size are signed, so the storage rejects any other object.
+func (mrc *MediaRepositoryController) PresignUploadForm(upload *models.Upload, expires time.Duration) (string, map[string]string, error) {
+	presigned, err := mrc.store.PresignPost(upload.BucketName, upload.ObjectName, objectstore.UploadPolicy{
+		ContentType: upload.ContentType,
+		MinSize:     upload.Size,
+		MaxSize:     upload.Size,
+		Expires:     expires,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("PresignUploadForm: %w", err)
+	}
+	return presigned.Url, presigned.Fields, nil
+}
+
+// GetObjectSize returns ErrUploadNotFinished when the object is not in the bucket yet.
+func (mrc *MediaRepositoryController) GetObjectSize(bucketName, objectName string) (int64, error) {
+	info, err := mrc.store.Stat(bucketName, objectName)
+	if err != nil {
+		if errors.Is(err, objectstore.ErrNotFound) {
+			return 0, internal_errors.ErrUploadNotFinished
+		}
+		return 0, fmt.Errorf("GetObjectSize: %w", err)
+	}
+	return info.Size, nil
+}
+
+func (mrc *MediaRepositoryController) OpenObject(bucketName, objectName string) (io.ReadCloser, error) {
+	object, err := mrc.store.Get(bucketName, objectName)
+	if err != nil {
+		return nil, fmt.Errorf("OpenObject: %w", err)
+	}
+	return object, nil
+}
+
+func (mrc *MediaRepositoryController) RemoveObject(bucketName, objectName string) error {
+	if err := mrc.store.Remove(bucketName, objectName); err != nil {
+		return fmt.Errorf("RemoveObject: %w", err)
+	}
+	return nil
+}
+
+// GetExpiredUploads returns pending uploads whose presigned URL expired before the given time.
+func (mrc *MediaRepositoryController) GetExpiredUploads(expiredBefore time.Time, limit int) ([]*models.Upload, error) {
+	rows, err := mrc.db.Query(GetExpiredUploads, expiredBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetExpiredUploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []*models.Upload
+	for rows.Next() {
+		upload := &models.Upload{}
+		err := rows.Scan(
+			&upload.UploadID,
+			&upload.UserID,
+			&upload.BucketName,
+			&upload.ObjectName,
+			&upload.ContentType,
+			&upload.Size,
+			&upload.MediaKey,
+			&upload.Status,
+			&upload.CreatedAt,
+			&upload.ExpiresAt,
+			&upload.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("psql GetExpiredUploads scan: %w", err)
+		}
+		uploads = append(uploads, upload)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetExpiredUploads rows: %w", err)
+	}
+
+	return uploads, nil
+}
+
+// DeletePendingUpload forgets the upload unless it has been completed meanwhile.
+func (mrc *MediaRepositoryController) DeletePendingUpload(uploadID string) (deleted bool, err error) {
+	result, err := mrc.db.Exec(DeletePendingUpload, uploadID)
+	if err != nil {
+		return false, fmt.Errorf("psql DeletePendingUpload: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("psql DeletePendingUpload: %w", err)
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,25 @@
+package mediarepository
+
+import (
+	"fmt"
+	"pinset/internal/app/models"
+)
+
+// AddPinViews adds the views to the total and the daily counters of the pin, views of a deleted pin are dropped.
+func (tr *txRepository) AddPinViews(views *models.PinViews) error {
+	if _, err := tr.tx.Exec(AddPinViews, views.PinID, views.Views); err != nil {
+		return fmt.Errorf("psql AddPinViews: %w", err)
+	}
+	if _, err := tr.tx.Exec(AddPinDailyViews, views.PinID, views.Day, views.Views); err != nil {
+		return fmt.Errorf("psql AddPinViews daily: %w", err)
+	}
+	return nil
+}
+
+// AddPinLinkClicks adds the clicks to the daily clicks of the pin, clicks on a deleted pin are dropped.
+func (tr *txRepository) AddPinLinkClicks(clicks *models.PinLinkClicks) error {
+	if _, err := tr.tx.Exec(AddPinLinkClicks, clicks.PinID, clicks.Day, clicks.Clicks); err != nil {
+		return fmt.Errorf("psql AddPinLinkClicks: %w", err)
+	}
+	return nil
+}
@@ -48,3 +48,15 @@ func (uoc *UserOnlineRepositoryController) DeleteOnlineUser(userID uint64) {
 	defer uoc.mu.Unlock()
 	delete(uoc.data, userID)
 }
+
+// DeleteOnlineUserConnection removes the user only if the given connection is still the active one,
+// so closing a stale connection doesn't put offline the user who has reconnected.
+func (uoc *UserOnlineRepositoryController) DeleteOnlineUserConnection(user *models.ChatUser) bool {
+	uoc.mu.Lock()
+	defer uoc.mu.Unlock()
+	if uoc.data[user.ID] != user {
+		return false
+	}
+	delete(uoc.data, user.ID)
+	return true
+}
@@ -0,0 +1,113 @@
+package userRepository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// foreignKeyViolationCode is returned by postgres when the blocked user doesn't exist
+const foreignKeyViolationCode = "23503"
+
+func (urc *UserRepositoryController) BlockUser(blockerID, blockedID uint64) error {
+	_, err := urc.db.Exec(BlockUser, blockerID, blockedID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return internal_errors.ErrUserDoesntExists
+		}
+		return fmt.Errorf("psql BlockUser: %w", err)
+	}
+
+	urc.logger.WithField("user was blocked with userID", blockedID).Info("blockUser func")
+	return nil
+}
+
+func (urc *UserRepositoryController) UnblockUser(blockerID, blockedID uint64) error {
+	_, err := urc.db.Exec(UnblockUser, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("psql UnblockUser: %w", err)
+	}
+
+	urc.logger.WithField("user was unblocked with userID", blockedID).Info("unblockUser func")
+	return nil
+}
+
+func (urc *UserRepositoryController) GetBlockedUsers(blockerID uint64) ([]*models.BlockedUser, error) {
+	rows, err := urc.db.Query(GetBlockedUsers, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetBlockedUsers: %w", err)
+	}
+	defer rows.Close()
+
+	blockedUsers := make([]*models.BlockedUser, 0)
+	for rows.Next() {
+		blockedUser := &models.BlockedUser{}
+		if err := rows.Scan(&blockedUser.UserID,
+			&blockedUser.UserName,
+			&blockedUser.NickName,
+			&blockedUser.AvatarUrl,
+			&blockedUser.BlockedAt); err != nil {
+			return nil, fmt.Errorf("psql GetBlockedUsers rows.Next: %w", err)
+		}
+		blockedUser.AvatarUrl = urc.urls.OptionalUrl(blockedUser.AvatarUrl)
+		blockedUsers = append(blockedUsers, blockedUser)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetBlockedUsers rows.Err: %w", err)
+	}
+	return blockedUsers, nil
+}
+
+// GetBlockedUserIDs returns users blocked by the given user.
+func (urc *UserRepositoryController) GetBlockedUserIDs(blockerID uint64) ([]uint64, error) {
+	return urc.queryUserIDs(GetBlockedUserIDs, blockerID)
+}
+
+// GetBlockerUserIDs returns users who blocked the given user.
+func (urc *UserRepositoryController) GetBlockerUserIDs(blockedID uint64) ([]uint64, error) {
+	return urc.queryUserIDs(GetBlockerUserIDs, blockedID)
+}
+
+// GetBlockRelatedUserIDs returns users blocked by the given user and users who blocked them.
+func (urc *UserRepositoryController) GetBlockRelatedUserIDs(userID uint64) ([]uint64, error) {
+	return urc.queryUserIDs(GetBlockRelatedIDs, userID)
+}
+
+// IsBlockedBetween reports whether either of the users has blocked the other.
+func (urc *UserRepositoryController) IsBlockedBetween(firstUserID, secondUserID uint64) (bool, error) {
+	var blocked bool
+	err := urc.db.QueryRow(IsBlockedBetween, firstUserID, secondUserID).Scan(&blocked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("psql IsBlockedBetween: %w", err)
+	}
+	return blocked, nil
+}
+
+func (urc *UserRepositoryController) queryUserIDs(query string, userID uint64) ([]uint64, error) {
+	rows, err := urc.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("psql queryUserIDs: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := make([]uint64, 0)
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("psql queryUserIDs rows.Next: %w", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql queryUserIDs rows.Err: %w", err)
+	}
+	return userIDs, nil
+}
@@ -8,7 +8,7 @@ const (
 	CheckUserByEmail       = `SELECT user_id FROM "user" WHERE email = $1 LIMIT 1;`
 	GetUserAvatar          = `SELECT avatar_url FROM "user" WHERE user_id = $1 LIMIT 1;`
 	GetUserInfoByID        = `SELECT user_name, nick_name, description, birth_time, gender, avatar_url FROM "user" WHERE user_id = $1 LIMIT 1;`
-	UpdateUserInfoByID     = `UPDATE "user" SET user_name = $1, nick_name = $2, description = $3, birth_time = $4, gender = $5, update_time = NOW(), avatar_url = $6 WHERE user_id = $7 RETURNING user_id;`
+	UpdateUserInfoByID     = `UPDATE "user" SET user_name = $1, nick_name = $2, description = $3, birth_time = $4, gender = $5, update_time = NOW(), avatar_url = $6, avatar_media_id = (SELECT media_id FROM media WHERE media_key = $6) WHERE user_id = $7 RETURNING user_id;`
 	UpdateUserPasswordByID = `UPDATE "user" SET password = $1, update_time = NOW() WHERE user_id = $2 RETURNING user_id;`
 	DeleteUserByID         = `DELETE FROM "user" WHERE user_id = $1;`
 
@@ -24,3 +24,21 @@ const (
 	GetBoardsByUserID = `SELECT board_id FROM "saved_boards" WHERE user_id = $1;`
 	GetPinsByUserID   = `SELECT pin_id FROM "saved_pins" WHERE user_id = $1`
 )
+
+const (
+	// Presence
+	UpdateUserLastSeen        = `UPDATE "user" SET last_seen = NOW() WHERE user_id = $1;`
+	UpdateUserPresenceSetting = `UPDATE "user" SET hide_presence = $1, update_time = NOW() WHERE user_id = $2 RETURNING user_id;`
+	GetUsersPresenceInfo      = `SELECT user_id, last_seen, hide_presence FROM "user" WHERE user_id = ANY($1);`
+)
+
+const (
+	// Block list
+	BlockUser          = `INSERT INTO user_block (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+	UnblockUser        = `DELETE FROM user_block WHERE blocker_id = $1 AND blocked_id = $2;`
+	GetBlockedUsers    = `SELECT u.user_id, u.user_name, u.nick_name, u.avatar_url, ub.created_at FROM user_block ub JOIN "user" u ON u.user_id = ub.blocked_id WHERE ub.blocker_id = $1 ORDER BY ub.created_at DESC;`
+	GetBlockedUserIDs  = `SELECT blocked_id FROM user_block WHERE blocker_id = $1;`
+	GetBlockerUserIDs  = `SELECT blocker_id FROM user_block WHERE blocked_id = $1;`
+	GetBlockRelatedIDs = `SELECT blocked_id FROM user_block WHERE blocker_id = $1 UNION SELECT blocker_id FROM user_block WHERE blocked_id = $1;`
+	IsBlockedBetween   = `SELECT EXISTS (SELECT 1 FROM user_block WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1));`
+)
@@ -9,6 +9,7 @@ import (
 	"pinset/internal/app/session"
 	"pinset/internal/app/usecase"
 	internal_errors "pinset/internal/errors"
+	"pinset/pkg/mediaurl"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -18,13 +19,15 @@ type UserRepositoryController struct {
 	db     *sql.DB
 	sm     *session.SessionsManager
 	logger *logrus.Logger
+	urls   *mediaurl.Builder
 }
 
-func NewUserRepository(db *sql.DB, logger *logrus.Logger) usecase.UserRepository {
+func NewUserRepository(db *sql.DB, logger *logrus.Logger, urls *mediaurl.Builder) usecase.UserRepository {
 	return &UserRepositoryController{
 		db:     db,
 		logger: logger,
 		sm:     session.NewSessionManager(),
+		urls:   urls,
 	}
 }
 
@@ -84,30 +87,30 @@ func (urc *UserRepositoryController) CheckUserCredentials(user *models.User) err
 	return nil
 }
 
-func (urc *UserRepositoryController) GetUsersByParams(userParams *models.UserSearchParams) ([]*models.UserInfo, error) {
-	fmt.Println("YESYESYES")
+// GetUsersByParams searches users other than the caller, users blocked by the caller or blocking the caller are not found.
+func (urc *UserRepositoryController) GetUsersByParams(callerID uint64, userParams *models.UserSearchParams) ([]*models.UserInfo, error) {
 	queryString := `SELECT user_id, user_name, nick_name, avatar_url FROM "user" WHERE `
-	conditions := make([]string, 0)
-	params := make([]interface{}, 0)
+	conditions := []string{`user_id <> $1`, `NOT EXISTS (SELECT 1 FROM user_block ub
+		WHERE (ub.blocker_id = $1 AND ub.blocked_id = user_id) OR (ub.blocker_id = user_id AND ub.blocked_id = $1))`}
+	params := []interface{}{callerID}
 
 	if userParams.NickName != nil {
-		conditions = append(conditions, `(LOWER(nick_name) LIKE '%' || $1 || '%')`)
 		params = append(params, strings.ToLower(*userParams.NickName))
+		conditions = append(conditions, fmt.Sprintf(`(LOWER(nick_name) LIKE '%%' || $%d || '%%')`, len(params)))
 	}
 	if userParams.Email != nil {
-		conditions = append(conditions, `(LOWER(email) LIKE '%' || $2 || '%')`)
 		params = append(params, strings.ToLower(*userParams.Email))
+		conditions = append(conditions, fmt.Sprintf(`(LOWER(email) LIKE '%%' || $%d || '%%')`, len(params)))
 	}
 	if userParams.UserName != nil {
-		conditions = append(conditions, `(LOWER(user_name) LIKE '%' || $3 || '%')`)
 		params = append(params, strings.ToLower(*userParams.UserName))
+		conditions = append(conditions, fmt.Sprintf(`(LOWER(user_name) LIKE '%%' || $%d || '%%')`, len(params)))
 	}
 	if userParams.Gender != nil {
-		conditions = append(conditions, `(LOWER(gender) LIKE $4)`)
 		params = append(params, strings.ToLower(*userParams.Gender))
+		conditions = append(conditions, fmt.Sprintf(`(LOWER(gender) LIKE $%d)`, len(params)))
 	}
 	queryString += strings.Join(conditions, ` AND `)
-	fmt.Println(queryString)
 
 	rows, err := urc.db.Query(queryString, params...)
 	if err != nil {
@@ -123,6 +126,7 @@ func (urc *UserRepositoryController) GetUsersByParams(userParams *models.UserSea
 		if err != nil {
 			return nil, fmt.Errorf("getUsersByParams: rows.Next %w", err)
 		}
+		foundUser.AvatarUrl = urc.urls.OptionalUrl(foundUser.AvatarUrl)
 		res = append(res, foundUser)
 	}
 
@@ -149,6 +153,7 @@ func (urc *UserRepositoryController) GetUserInfo(user *models.User, currUserID u
 		}
 		return &models.UserProfile{}, fmt.Errorf("psql GetUserByID: %w", err)
 	}
+	userInfo.AvatarUrl = urc.urls.OptionalUrl(userInfo.AvatarUrl)
 	return userInfo, nil
 }
 
@@ -168,6 +173,7 @@ func (urc *UserRepositoryController) GetUserInfoPublic(userID uint64) (*response
 		}
 		return &response.UserProfileResponse{}, fmt.Errorf("psql GetUserByID: %w", err)
 	}
+	userInfo.AvatarUrl = urc.urls.OptionalUrl(userInfo.AvatarUrl)
 	return userInfo, nil
 }
 
@@ -179,7 +185,7 @@ func (urc *UserRepositoryController) UpdateUserInfo(user *models.User) error {
 		user.Description,
 		user.BirthTime,
 		user.Gender,
-		user.AvatarUrl,
+		urc.urls.OptionalKey(user.AvatarUrl),
 		user.UserID).Scan(&userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -311,7 +317,7 @@ func (urc *UserRepositoryController) GetUserAvatar(userID uint64) (string, error
 
 	avatar_url := ""
 	if userAvatar != nil {
-		avatar_url = *userAvatar
+		avatar_url = urc.urls.Url(*userAvatar)
 	}
 
 	return avatar_url, nil
@@ -324,3 +330,51 @@ func (urc *UserRepositoryController) UserHasActiveSession(token string) bool {
 func (urc *UserRepositoryController) Session() *session.SessionsManager {
 	return urc.sm
 }
+
+func (urc *UserRepositoryController) UpdateUserLastSeen(userID uint64) error {
+	_, err := urc.db.Exec(UpdateUserLastSeen, userID)
+	if err != nil {
+		return fmt.Errorf("psql UpdateUserLastSeen: %w", err)
+	}
+	return nil
+}
+
+func (urc *UserRepositoryController) UpdateUserPresenceSettings(userID uint64, settings *models.PresenceSettings) error {
+	var updatedUserID uint64
+	err := urc.db.QueryRow(UpdateUserPresenceSetting, settings.HidePresence, userID).Scan(&updatedUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal_errors.ErrUserDoesntExists
+		}
+		return fmt.Errorf("psql UpdateUserPresenceSettings: %w", err)
+	}
+
+	urc.logger.WithField("presence settings updated with userID:", updatedUserID).Info()
+	return nil
+}
+
+func (urc *UserRepositoryController) GetUsersPresenceInfo(userIDs []uint64) ([]*models.UserPresenceInfo, error) {
+	ids := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		ids = append(ids, int64(userID))
+	}
+
+	rows, err := urc.db.Query(GetUsersPresenceInfo, ids)
+	if err != nil {
+		return nil, fmt.Errorf("psql GetUsersPresenceInfo: %w", err)
+	}
+	defer rows.Close()
+
+	presenceInfo := make([]*models.UserPresenceInfo, 0, len(userIDs))
+	for rows.Next() {
+		info := &models.UserPresenceInfo{}
+		if err := rows.Scan(&info.UserID, &info.LastSeen, &info.HidePresence); err != nil {
+			return nil, fmt.Errorf("psql GetUsersPresenceInfo rows.Next: %w", err)
+		}
+		presenceInfo = append(presenceInfo, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("psql GetUsersPresenceInfo rows.Err: %w", err)
+	}
+	return presenceInfo, nil
+}
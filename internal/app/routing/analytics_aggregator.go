@@ -0,0 +1,27 @@
+package routing
+
+import (
+	"context"
+	"pinset/internal/app/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runAnalyticsAggregator backfills daily analytics on start and then keeps the last days up to date.
+func runAnalyticsAggregator(ctx context.Context, logger *logrus.Logger, aggregator *usecase.AnalyticsAggregator) {
+	rows, err := aggregator.Backfill()
+	if err != nil {
+		logger.WithError(err).Error("analytics backfill failed")
+	}
+	logger.WithField("rows", rows).Info("analytics backfilled")
+
+	runPeriodically(ctx, aggregator.Interval(), func() {
+		rows, err := aggregator.Aggregate()
+		if err != nil {
+			logger.WithError(err).Error("analytics aggregation failed")
+		}
+		if rows > 0 {
+			logger.WithField("rows", rows).Debug("analytics aggregated")
+		}
+	})
+}
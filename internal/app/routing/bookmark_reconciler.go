@@ -0,0 +1,21 @@
+package routing
+
+import (
+	"context"
+	"pinset/internal/app/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runBookmarkReconciler fixes bookmark counters that drifted from the bookmarks.
+func runBookmarkReconciler(ctx context.Context, logger *logrus.Logger, reconciler *usecase.BookmarkReconciler) {
+	runPeriodically(ctx, reconciler.Interval(), func() {
+		fixed, err := reconciler.Reconcile()
+		if err != nil {
+			logger.WithError(err).Error("bookmark reconciliation failed")
+		}
+		if fixed > 0 {
+			logger.WithField("pins", fixed).Info("bookmark counters reconciled")
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package routing
+
+import (
+	"context"
+	"pinset/internal/app/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runMediaSweeper removes unreferenced media and expired uploads from the storage.
+func runMediaSweeper(ctx context.Context, logger *logrus.Logger, sweeper *usecase.MediaSweeper) {
+	runPeriodically(ctx, sweeper.Interval(), func() {
+		result, err := sweeper.Sweep()
+		if err != nil {
+			logger.WithError(err).Error("media sweep failed")
+		}
+		if result.Media > 0 || result.Uploads > 0 {
+			logger.WithFields(logrus.Fields{
+				"media":   result.Media,
+				"uploads": result.Uploads,
+			}).Info("media sweep removed unreferenced objects")
+		}
+	})
+}
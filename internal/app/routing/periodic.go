@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runPeriodically calls fn every interval until ctx is cancelled. The call in progress is finished first.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// startBackgroundJobs runs every job in its own goroutine.
+// The returned stop cancels the jobs and waits until all of them return.
+func startBackgroundJobs(jobs ...func(ctx context.Context)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job(ctx)
+		}()
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
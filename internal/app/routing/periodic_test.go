@@ -0,0 +1,24 @@
+package routing
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartBackgroundJobs(t *testing.T) {
+	var calls atomic.Int32
+	stop := startBackgroundJobs(func(ctx context.Context) {
+		runPeriodically(ctx, time.Millisecond, func() { calls.Add(1) })
+	})
+
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, time.Millisecond)
+
+	stop()
+	stopped := calls.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load(), "no calls after the jobs are stopped")
+}
@@ -0,0 +1,30 @@
+package routing
+
+import (
+	"context"
+	"pinset/internal/app/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runPinCounterFlusher writes the events buffered by the counter in batches.
+func runPinCounterFlusher(ctx context.Context, logger *logrus.Logger, counter *usecase.PinCounter, events string) {
+	runPeriodically(ctx, counter.Interval(), func() {
+		flushed, err := counter.Flush()
+		if err != nil {
+			logger.WithError(err).Errorf("pin %s flush failed", events)
+		}
+		if flushed > 0 {
+			logger.WithField(events, flushed).Debugf("pin %s flushed", events)
+		}
+	})
+}
+
+// flushPinCounter writes the events buffered since the last flush, they would be lost on exit.
+func flushPinCounter(logger *logrus.Logger, counter *usecase.PinCounter, events string) {
+	flushed, err := counter.Flush()
+	if err != nil {
+		logger.WithError(err).Errorf("final pin %s flush failed", events)
+	}
+	logger.WithField(events, flushed).Infof("pin %s flushed on shutdown", events)
+}
@@ -0,0 +1,21 @@
+package routing
+
+import (
+	"context"
+	"pinset/internal/app/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runPinScheduler publishes scheduled pins once they are due.
+func runPinScheduler(ctx context.Context, logger *logrus.Logger, scheduler *usecase.PinScheduler) {
+	runPeriodically(ctx, scheduler.Interval(), func() {
+		pinIDs, err := scheduler.PublishDue()
+		if err != nil {
+			logger.WithError(err).Error("publishing scheduled pins failed")
+		}
+		if len(pinIDs) > 0 {
+			logger.WithField("pin_ids", pinIDs).Info("scheduled pins published")
+		}
+	})
+}
@@ -1,9 +1,15 @@
 package routing
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"pinset/configs"
+	"pinset/configs/s3"
+	"syscall"
 
 	"pinset/internal/app/db"
 	delivery "pinset/internal/app/delivery/http"
@@ -14,6 +20,7 @@ import (
 	"pinset/internal/app/usecase"
 
 	"pinset/pkg/logger"
+	"pinset/pkg/mediaurl"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -36,10 +43,15 @@ type (
 		GetUserInfo(w http.ResponseWriter, r *http.Request)
 		UpdateUserInfo(w http.ResponseWriter, r *http.Request)
 		GetUsersByParams(w http.ResponseWriter, r *http.Request)
+
+		BlockUser(w http.ResponseWriter, r *http.Request)
+		UnblockUser(w http.ResponseWriter, r *http.Request)
+		GetBlockedUsers(w http.ResponseWriter, r *http.Request)
 	}
 
 	MediaDelivery interface {
 		Feed(w http.ResponseWriter, r *http.Request)
+		GetDrafts(w http.ResponseWriter, r *http.Request)
 
 		GetPinPreview(w http.ResponseWriter, r *http.Request)
 		GetPinPage(w http.ResponseWriter, r *http.Request)
@@ -54,13 +66,28 @@ type (
 		UpdateBoard(w http.ResponseWriter, r *http.Request)
 		DeleteBoard(w http.ResponseWriter, r *http.Request)
 		AddPinToBoard(w http.ResponseWriter, r *http.Request)
+		SavePin(w http.ResponseWriter, r *http.Request)
+		TogglePinReaction(w http.ResponseWriter, r *http.Request)
 		DeletePinFromBoard(w http.ResponseWriter, r *http.Request)
 		GetBoardPins(w http.ResponseWriter, r *http.Request)
 
 		GetBookmark(w http.ResponseWriter, r *http.Request)
 		CreateBookmark(w http.ResponseWriter, r *http.Request)
 		DeleteBookmark(w http.ResponseWriter, r *http.Request)
+		GetMyBookmarks(w http.ResponseWriter, r *http.Request)
+
+		ClickPinLink(w http.ResponseWriter, r *http.Request)
+		GetPinAnalytics(w http.ResponseWriter, r *http.Request)
+		GetBoardAnalytics(w http.ResponseWriter, r *http.Request)
+		GetTopPins(w http.ResponseWriter, r *http.Request)
+		GetFollowerGrowth(w http.ResponseWriter, r *http.Request)
 		UploadMedia(w http.ResponseWriter, r *http.Request)
+		PresignUpload(w http.ResponseWriter, r *http.Request)
+		CompleteUpload(w http.ResponseWriter, r *http.Request)
+		PreviewLink(w http.ResponseWriter, r *http.Request)
+		CreatePinDraftFromUrl(w http.ResponseWriter, r *http.Request)
+
+		GetNotifications(w http.ResponseWriter, r *http.Request)
 	}
 
 	MessageDelivery interface {
@@ -68,6 +95,22 @@ type (
 		GetAllChatMessages(w http.ResponseWriter, r *http.Request)
 		GetUserChats(w http.ResponseWriter, r *http.Request)
 		CreateChat(w http.ResponseWriter, r *http.Request)
+
+		CreateGroupChat(w http.ResponseWriter, r *http.Request)
+		GetChatInfo(w http.ResponseWriter, r *http.Request)
+		UpdateChat(w http.ResponseWriter, r *http.Request)
+		AddChatMember(w http.ResponseWriter, r *http.Request)
+		RemoveChatMember(w http.ResponseWriter, r *http.Request)
+		LeaveChat(w http.ResponseWriter, r *http.Request)
+		AddChatAdmin(w http.ResponseWriter, r *http.Request)
+		RemoveChatAdmin(w http.ResponseWriter, r *http.Request)
+		UploadChatAttachments(w http.ResponseWriter, r *http.Request)
+
+		GetChatMessagesAround(w http.ResponseWriter, r *http.Request)
+		SearchMessages(w http.ResponseWriter, r *http.Request)
+
+		GetUsersPresence(w http.ResponseWriter, r *http.Request)
+		UpdatePresenceSettings(w http.ResponseWriter, r *http.Request)
 	}
 )
 
@@ -107,22 +150,33 @@ func InitializeUserLayerRoutings(rh *RoutingHandler, userHandlers UserDelivery)
 	rh.mux.HandleFunc("/get_avatar", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.GetAvatar)).Methods("GET")
 	rh.mux.HandleFunc("/user/{user_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.GetUserInfo)).Methods("GET")
 	rh.mux.HandleFunc("/users/by/params", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.GetUsersByParams)).Methods("POST")
+
+	rh.mux.HandleFunc("/block/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.BlockUser)).Methods("POST")
+	rh.mux.HandleFunc("/block/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.UnblockUser)).Methods("DELETE")
+	rh.mux.HandleFunc("/blocked-users", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, userHandlers.GetBlockedUsers)).Methods("GET")
 }
 
 func NewMediaDelivery(logger *logrus.Logger, usecase delivery.MediaUsecase) MediaDelivery {
 	return &delivery.MediaDeliveryController{
-		Usecase: usecase,
-		Logger:  logger,
+		Usecase:      usecase,
+		Logger:       logger,
+		UploadParams: configs.NewUploadParams(),
 	}
 }
 
 // Media layer handlers
 func InitializeMediaLayerRoutings(rh *RoutingHandler, mediaHandlers MediaDelivery) {
 	rh.mux.HandleFunc("/image/upload", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.UploadMedia)).Methods("POST")
+	rh.mux.HandleFunc("/uploads/presign", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.PresignUpload)).Methods("POST")
+	rh.mux.HandleFunc("/uploads/complete", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.CompleteUpload)).Methods("POST")
 
 	rh.mux.HandleFunc("/feed", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.Feed)).Methods("GET")
+	rh.mux.HandleFunc("/me/drafts", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetDrafts)).Methods("GET")
+	rh.mux.HandleFunc("/me/notifications", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetNotifications)).Methods("GET")
 
 	rh.mux.HandleFunc("/create-pin", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.CreatePin)).Methods("POST")
+	rh.mux.HandleFunc("/pins/from-url/preview", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.PreviewLink)).Methods("POST")
+	rh.mux.HandleFunc("/pins/from-url", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.CreatePinDraftFromUrl)).Methods("POST")
 	rh.mux.HandleFunc("/pins/view/{pin_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.ViewPin)).Methods("POST")
 	rh.mux.HandleFunc("/pins/preview/{pin_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetPinPreview)).Methods("GET")
 	rh.mux.HandleFunc("/pins/page/{pin_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetPinPage)).Methods("GET")
@@ -136,12 +190,21 @@ func InitializeMediaLayerRoutings(rh *RoutingHandler, mediaHandlers MediaDeliver
 	rh.mux.HandleFunc("/boards/delete/{board_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.DeleteBoard)).Methods("DELETE")
 
 	rh.mux.HandleFunc("/boards/{board_id}/addpin/{pin_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.AddPinToBoard)).Methods("POST")
+	rh.mux.HandleFunc("/pins/{pin_id}/save", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.SavePin)).Methods("POST")
+	rh.mux.HandleFunc("/pins/{pin_id}/reactions", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.TogglePinReaction)).Methods("POST")
 	rh.mux.HandleFunc("/boards/{board_id}/deletepin/{pin_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.DeletePinFromBoard)).Methods("DELETE")
 	rh.mux.HandleFunc("/boards/{board_id}/pins", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetBoardPins)).Methods("GET")
 
-	rh.mux.HandleFunc("/create-bookmark", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.CreateBookmark)).Methods("POST")
-	rh.mux.HandleFunc("/bookmark/{bookmark_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetBookmark)).Methods("GET")
-	rh.mux.HandleFunc("/bookmark/delete/{bookmark_id}", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.DeleteBookmark)).Methods("DELETE")
+	rh.mux.HandleFunc("/create-bookmark", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.CreateBookmark)).Methods("POST")
+	rh.mux.HandleFunc("/bookmark/{pin_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetBookmark)).Methods("GET")
+	rh.mux.HandleFunc("/bookmark/delete/{pin_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.DeleteBookmark)).Methods("DELETE")
+	rh.mux.HandleFunc("/me/bookmarks", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetMyBookmarks)).Methods("GET")
+
+	rh.mux.HandleFunc("/pins/{pin_id}/link-click", middleware.NotRequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.ClickPinLink)).Methods("POST")
+	rh.mux.HandleFunc("/me/analytics/pins/{pin_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetPinAnalytics)).Methods("GET")
+	rh.mux.HandleFunc("/me/analytics/boards/{board_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetBoardAnalytics)).Methods("GET")
+	rh.mux.HandleFunc("/me/analytics/top-pins", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetTopPins)).Methods("GET")
+	rh.mux.HandleFunc("/me/analytics/followers", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, mediaHandlers.GetFollowerGrowth)).Methods("GET")
 
 	// rh.mux.HandleFunc("/handshake", delivery.HandShake).Methods("GET")
 }
@@ -157,14 +220,30 @@ func NewMessageDelivery(logger *logrus.Logger, usecase delivery.MessageUsecase)
 				return true
 			},
 		},
+		UploadParams: configs.NewUploadParams(),
 	}
 }
 
 func InitializeMessageLayerRoutings(rh *RoutingHandler, messageHandlers MessageDelivery) {
 	rh.mux.HandleFunc("/handshake", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.HandShake)).Methods("GET")
 	rh.mux.HandleFunc("/chat/{chat_id}/messages", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.GetAllChatMessages)).Methods("GET")
+	rh.mux.HandleFunc("/chat/{chat_id}/messages/{message_id}/around", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.GetChatMessagesAround)).Methods("GET")
+	rh.mux.HandleFunc("/messages/search", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.SearchMessages)).Methods("GET")
 	rh.mux.HandleFunc("/mychats", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.GetUserChats)).Methods("GET")
 	rh.mux.HandleFunc("/create/chat/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.CreateChat)).Methods("POST")
+
+	rh.mux.HandleFunc("/create/group-chat", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.CreateGroupChat)).Methods("POST")
+	rh.mux.HandleFunc("/chat/{chat_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.GetChatInfo)).Methods("GET")
+	rh.mux.HandleFunc("/chat/{chat_id}/update", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.UpdateChat)).Methods("PUT")
+	rh.mux.HandleFunc("/chat/{chat_id}/leave", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.LeaveChat)).Methods("POST")
+	rh.mux.HandleFunc("/chat/{chat_id}/members/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.AddChatMember)).Methods("POST")
+	rh.mux.HandleFunc("/chat/{chat_id}/members/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.RemoveChatMember)).Methods("DELETE")
+	rh.mux.HandleFunc("/chat/{chat_id}/admins/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.AddChatAdmin)).Methods("POST")
+	rh.mux.HandleFunc("/chat/{chat_id}/admins/{user_id}", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.RemoveChatAdmin)).Methods("DELETE")
+	rh.mux.HandleFunc("/chat/{chat_id}/attachments/upload", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.UploadChatAttachments)).Methods("POST")
+
+	rh.mux.HandleFunc("/presence", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.GetUsersPresence)).Methods("GET")
+	rh.mux.HandleFunc("/presence/settings", middleware.RequiredAuthorization(rh.logger, rh.userUsecase, messageHandlers.UpdatePresenceSettings)).Methods("PUT")
 }
 
 func Route() {
@@ -177,19 +256,35 @@ func Route() {
 	mux := mux.NewRouter()
 
 	repo := db.InitDB(logger)
-	mediaRepo, mediaErr := mediarepository.NewMediaRepository(repo, logger)
-	if mediaErr != nil {
-		logger.Fatal(mediaErr)
+	minioParams, storageParams := s3.NewMinioParams(), s3.NewStorageParams()
+	objectStore, objectStoreHandler, storeErr := mediarepository.NewObjectStore(minioParams, storageParams)
+	if storeErr != nil {
+		logger.Fatal(storeErr)
 	}
+	// Both repositories turn media keys into the same URLs
+	mediaUrls := mediaurl.NewBuilder(storageParams.MediaBaseUrl(minioParams), minioParams.Buckets()...)
+	mediaRepo := mediarepository.NewMediaRepository(repo, logger, objectStore, mediaUrls)
 
-	userRepo := userRepository.NewUserRepository(repo, logger)
+	userRepo := userRepository.NewUserRepository(repo, logger, mediaUrls)
 	userUsecase := usecase.NewUserUsecase(userRepo, mediaRepo)
 	userDelivery := NewUserDelivery(logger, userUsecase)
 
-	mediaUsecase := usecase.NewMediaUsecase(mediaRepo, userRepo)
+	userOnlineRepo := UserOnlineRepository.NewUserOnlineRepository()
+
+	viewCounter := usecase.NewViewCounter(mediaRepo)
+	linkClickCounter := usecase.NewLinkClickCounter(mediaRepo)
+	mediaUsecase := usecase.NewMediaUsecase(mediaRepo, userRepo, userOnlineRepo, viewCounter, linkClickCounter)
 	mediaDelivery := NewMediaDelivery(logger, mediaUsecase)
 
-	userOnlineRepo := UserOnlineRepository.NewUserOnlineRepository()
+	stopBackgroundJobs := startBackgroundJobs(
+		func(ctx context.Context) { runMediaSweeper(ctx, logger, usecase.NewMediaSweeper(mediaRepo)) },
+		func(ctx context.Context) { runPinScheduler(ctx, logger, usecase.NewPinScheduler(mediaRepo)) },
+		func(ctx context.Context) { runBookmarkReconciler(ctx, logger, usecase.NewBookmarkReconciler(mediaRepo)) },
+		func(ctx context.Context) { runPinCounterFlusher(ctx, logger, viewCounter, "views") },
+		func(ctx context.Context) { runPinCounterFlusher(ctx, logger, linkClickCounter, "link clicks") },
+		func(ctx context.Context) { runAnalyticsAggregator(ctx, logger, usecase.NewAnalyticsAggregator(mediaRepo)) },
+	)
+
 	messageUsecase := usecase.NewMessageUsecase(userOnlineRepo, mediaRepo, userRepo)
 	messageDelivery := NewMessageDelivery(logger, messageUsecase)
 
@@ -199,12 +294,35 @@ func Route() {
 	InitializeUserLayerRoutings(rh, userDelivery)
 	InitializeMediaLayerRoutings(rh, mediaDelivery)
 	InitializeMessageLayerRoutings(rh, messageDelivery)
+	if objectStoreHandler != nil {
+		mux.PathPrefix(s3.LocalStoragePath + "/").Handler(http.StripPrefix(s3.LocalStoragePath, objectStoreHandler))
+	}
 
 	server := http.Server{
 		Addr:    routerParams.MainServerPort,
 		Handler: middleware.AccessLog(logger, middleware.CORS(middleware.RequestID(middleware.Panic(logger, mux)))),
 	}
 
-	logger.WithField("starting server at ", routerParams.MainServerPort).Info()
-	logger.Fatal(server.ListenAndServe())
+	go func() {
+		logger.WithField("starting server at ", routerParams.MainServerPort).Info()
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	logger.Info("shutting down the server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), routerParams.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.WithError(err).Error("server shutdown failed")
+	}
+
+	// The flushers are stopped first, so the final flush doesn't run along with theirs
+	stopBackgroundJobs()
+	flushPinCounter(logger, viewCounter, "views")
+	flushPinCounter(logger, linkClickCounter, "link clicks")
 }
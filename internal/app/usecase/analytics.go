@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+
+	internal_errors "pinset/internal/errors"
+)
+
+// RecordPinLinkClick counts a click on the related link of a visible pin once per viewer
+// within the dedup window and returns the link.
+func (muc *MediaUsecaseController) RecordPinLinkClick(pinID, currUserID uint64, viewer string) (string, error) {
+	pin, err := muc.repo.GetPinPageInfoByPinID(pinID)
+	if err != nil {
+		return "", err
+	}
+	if pin, err = muc.hideInvisiblePin(pin, currUserID); err != nil {
+		return "", err
+	}
+	if pin.RelatedLink == nil || *pin.RelatedLink == "" {
+		return "", internal_errors.ErrBadPinInputData
+	}
+
+	muc.linkClicks.Record(pinID, viewer)
+	return *pin.RelatedLink, nil
+}
+
+func (muc *MediaUsecaseController) GetPinAnalytics(pinID, userID uint64, statsRange models.AnalyticsRange) (*models.PinAnalytics, error) {
+	if err := statsRange.Valid(); err != nil {
+		return nil, err
+	}
+	pin, err := muc.repo.GetPinPreviewInfoByPinID(pinID)
+	if err != nil {
+		return nil, err
+	}
+	if pin.AuthorID != userID {
+		return nil, internal_errors.ErrAnalyticsNotAuthor
+	}
+
+	days, err := muc.repo.GetPinStatsSeries(pinID, statsRange)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PinAnalytics{PinID: pinID, StatsSeries: statsSeries(statsRange, days)}, nil
+}
+
+func (muc *MediaUsecaseController) GetBoardAnalytics(boardID, userID uint64, statsRange models.AnalyticsRange) (*models.BoardAnalytics, error) {
+	if err := statsRange.Valid(); err != nil {
+		return nil, err
+	}
+	board, err := muc.repo.GetBoardByBoardID(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if board.OwnerID != userID {
+		return nil, internal_errors.ErrAnalyticsNotAuthor
+	}
+
+	days, err := muc.repo.GetBoardStatsSeries(boardID, userID, statsRange)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BoardAnalytics{BoardID: boardID, StatsSeries: statsSeries(statsRange, days)}, nil
+}
+
+// statsSeries puts the stats of active days into the series of every day of the range.
+func statsSeries(statsRange models.AnalyticsRange, days []*models.DailyPinStats) models.StatsSeries {
+	byDay := make(map[string]*models.DailyPinStats, len(days))
+	for _, day := range days {
+		byDay[day.Day] = day
+	}
+
+	series := models.StatsSeries{
+		From:   statsRange.From.Format(models.AnalyticsDayLayout),
+		To:     statsRange.To.Format(models.AnalyticsDayLayout),
+		Series: make([]*models.DailyPinStats, 0, statsRange.Days()),
+	}
+	for day := statsRange.From; !day.After(statsRange.To); day = day.AddDate(0, 0, 1) {
+		stats, ok := byDay[day.Format(models.AnalyticsDayLayout)]
+		if !ok {
+			stats = &models.DailyPinStats{Day: day.Format(models.AnalyticsDayLayout)}
+		}
+		series.Totals.Add(stats.PinStats)
+		series.Series = append(series.Series, stats)
+	}
+	return series
+}
+
+func (muc *MediaUsecaseController) GetTopPins(params *models.TopPinsParams) ([]*models.TopPin, error) {
+	if err := params.Valid(); err != nil {
+		return nil, err
+	}
+	pins, err := muc.repo.GetTopPins(params)
+	if err != nil {
+		return nil, err
+	}
+	if pins == nil {
+		pins = []*models.TopPin{}
+	}
+	return pins, nil
+}
+
+// GetFollowerGrowth returns the number of followers of the user for every day of the range.
+// Days without a snapshot keep the number of the previous snapshot.
+func (muc *MediaUsecaseController) GetFollowerGrowth(userID uint64, statsRange models.AnalyticsRange) (*models.FollowerGrowth, error) {
+	if err := statsRange.Valid(); err != nil {
+		return nil, err
+	}
+	snapshots, err := muc.repo.GetFollowerSnapshots(userID, statsRange)
+	if err != nil {
+		return nil, err
+	}
+
+	growth := &models.FollowerGrowth{
+		From:   statsRange.From.Format(models.AnalyticsDayLayout),
+		To:     statsRange.To.Format(models.AnalyticsDayLayout),
+		Series: make([]*models.DailyFollowers, 0, statsRange.Days()),
+	}
+
+	var followers uint64
+	next := 0
+	for ; next < len(snapshots) && snapshots[next].Day.Before(statsRange.From); next++ {
+		followers = snapshots[next].Followers
+	}
+	start := followers
+
+	for day := statsRange.From; !day.After(statsRange.To); day = day.AddDate(0, 0, 1) {
+		previous := followers
+		for ; next < len(snapshots) && !snapshots[next].Day.After(day); next++ {
+			followers = snapshots[next].Followers
+		}
+		growth.Series = append(growth.Series, &models.DailyFollowers{
+			Day:       day.Format(models.AnalyticsDayLayout),
+			Followers: followers,
+			Change:    int64(followers) - int64(previous),
+		})
+	}
+	growth.Growth = int64(followers) - int64(start)
+	return growth, nil
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"fmt"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"time"
+)
+
+// AnalyticsAggregator rolls views, bookmarks, saves, comments and link clicks of pins up into daily stats
+// and snapshots the number of followers of users for the day.
+type AnalyticsAggregator struct {
+	repo   MediaRepository
+	params configs.AnalyticsAggregatorParams
+}
+
+func NewAnalyticsAggregator(repo MediaRepository) *AnalyticsAggregator {
+	return &AnalyticsAggregator{
+		repo:   repo,
+		params: configs.NewAnalyticsAggregatorParams(),
+	}
+}
+
+func (aa *AnalyticsAggregator) Interval() time.Duration {
+	return aa.params.Interval
+}
+
+func (aa *AnalyticsAggregator) Backfill() (int64, error) {
+	return aa.aggregate(aa.params.BackfillDays)
+}
+
+// Aggregate recounts the last days, the older ones don't change anymore.
+func (aa *AnalyticsAggregator) Aggregate() (int64, error) {
+	return aa.aggregate(aa.params.LookbackDays)
+}
+
+// aggregate returns the number of written daily rows.
+func (aa *AnalyticsAggregator) aggregate(days int) (int64, error) {
+	today := models.ViewDay(time.Now())
+
+	pinRows, err := aa.repo.RollupPinStats(today.AddDate(0, 0, 1-days))
+	if err != nil {
+		return 0, fmt.Errorf("rollup pin stats of %d days: %w", days, err)
+	}
+	userRows, err := aa.repo.SnapshotFollowers(today)
+	if err != nil {
+		return pinRows, fmt.Errorf("snapshot followers: %w", err)
+	}
+	return pinRows + userRows, nil
+}
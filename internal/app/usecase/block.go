@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+)
+
+func (uuc *UserUsecaseController) BlockUser(blockerID, blockedID uint64) error {
+	if blockerID == blockedID {
+		return internal_errors.ErrCantBlockYourself
+	}
+	return uuc.repo.BlockUser(blockerID, blockedID)
+}
+
+func (uuc *UserUsecaseController) UnblockUser(blockerID, blockedID uint64) error {
+	return uuc.repo.UnblockUser(blockerID, blockedID)
+}
+
+func (uuc *UserUsecaseController) GetBlockedUsers(blockerID uint64) ([]*models.BlockedUser, error) {
+	return uuc.repo.GetBlockedUsers(blockerID)
+}
+
+// blockedUsersSet returns users blocked by the viewer, whose content is hidden from them.
+// Anonymous viewers have nobody blocked.
+func blockedUsersSet(userRepo UserRepository, viewerID uint64) (map[uint64]bool, error) {
+	blocked := make(map[uint64]bool)
+	if viewerID == 0 {
+		return blocked, nil
+	}
+
+	blockedUserIDs, err := userRepo.GetBlockedUserIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, blockedUserID := range blockedUserIDs {
+		blocked[blockedUserID] = true
+	}
+	return blocked, nil
+}
+
+// blockRelatedUsersSet returns users blocked by the viewer and users who blocked the viewer,
+// content is hidden between them in both directions. Anonymous viewers have nobody blocked.
+func blockRelatedUsersSet(userRepo UserRepository, viewerID uint64) (map[uint64]bool, error) {
+	related := make(map[uint64]bool)
+	if viewerID == 0 {
+		return related, nil
+	}
+
+	relatedUserIDs, err := userRepo.GetBlockRelatedUserIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, relatedUserID := range relatedUserIDs {
+		related[relatedUserID] = true
+	}
+	return related, nil
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+	"slices"
+)
+
+// GetBookmarks returns a page of pins bookmarked by the owner, pins of users blocked by the owner are left out.
+func (muc *MediaUsecaseController) GetBookmarks(params *models.BookmarksPageParams) (*models.BookmarksPage, error) {
+	if err := params.Valid(); err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := muc.repo.GetBookmarkedPins(params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.BookmarksPage{}
+	// The bound comes before filtering, so hidden pins don't end the list early
+	if len(bookmarks) == params.Limit {
+		last := bookmarks[len(bookmarks)-1]
+		cursor := models.BookmarksCursor{BookmarkTime: last.BookmarkTime, BookmarkID: last.BookmarkID}.String()
+		page.NextCursor = &cursor
+	}
+
+	blockedUsers, err := blockedUsersSet(muc.userRepo, params.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	page.Bookmarks = slices.DeleteFunc(bookmarks, func(bookmark *models.BookmarkedPin) bool {
+		return blockedUsers[bookmark.Pin.AuthorID]
+	})
+
+	pins := make([]*models.Pin, 0, len(page.Bookmarks))
+	for _, bookmark := range page.Bookmarks {
+		pins = append(pins, bookmark.Pin)
+	}
+	if err := muc.fillPinViewerState(pins, params.OwnerID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"fmt"
+	"pinset/configs"
+	"time"
+)
+
+// BookmarkReconciler recomputes bookmark counters of pins from the bookmark table,
+// repairing counters left out of sync by bookmarks changed before counters became transactional.
+type BookmarkReconciler struct {
+	repo   MediaRepository
+	params configs.BookmarkReconcilerParams
+}
+
+func NewBookmarkReconciler(repo MediaRepository) *BookmarkReconciler {
+	return &BookmarkReconciler{
+		repo:   repo,
+		params: configs.NewBookmarkReconcilerParams(),
+	}
+}
+
+func (br *BookmarkReconciler) Interval() time.Duration {
+	return br.params.Interval
+}
+
+// Reconcile goes over all pins in batches and returns the number of fixed counters.
+// Counters of a batch are locked while recounted, so concurrent bookmarks are counted once.
+func (br *BookmarkReconciler) Reconcile() (int, error) {
+	var fixed int
+	var afterPinID uint64
+	for {
+		var pinIDs []uint64
+		err := br.repo.RunInTx(func(repo TxRepository) error {
+			var err error
+			if pinIDs, err = repo.LockPinCounters(afterPinID, br.params.BatchSize); err != nil {
+				return err
+			}
+			batchFixed, err := repo.RecountPinBookmarks(pinIDs)
+			fixed += batchFixed
+			return err
+		})
+		if err != nil {
+			return fixed, fmt.Errorf("reconcile bookmarks after pin %d: %w", afterPinID, err)
+		}
+
+		if len(pinIDs) < br.params.BatchSize {
+			return fixed, nil
+		}
+		afterPinID = pinIDs[len(pinIDs)-1]
+	}
+}
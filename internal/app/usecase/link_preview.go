@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"pinset/internal/app/models"
+	"pinset/pkg/linkpreview"
+	"slices"
+
+	internal_errors "pinset/internal/errors"
+)
+
+// PreviewLink reads the title, the description and the images of the page.
+func (muc *MediaUsecaseController) PreviewLink(ctx context.Context, pageUrl string) (*models.LinkPreview, error) {
+	preview, err := muc.scraper.Scrape(ctx, pageUrl)
+	if err != nil {
+		return nil, linkError(err)
+	}
+
+	return &models.LinkPreview{
+		Url:         preview.Url,
+		Title:       preview.Title,
+		Description: preview.Description,
+		SiteName:    preview.SiteName,
+		Images:      preview.Images,
+	}, nil
+}
+
+// CreatePinDraftFromUrl stores the chosen image of the page as media of the author and fills in a pin from the page.
+// Only images the page refers to are downloaded, so the server doesn't fetch arbitrary urls for the client.
+func (muc *MediaUsecaseController) CreatePinDraftFromUrl(ctx context.Context, authorID uint64, req *models.PinFromUrlRequest) (*models.PinDraft, error) {
+	preview, err := muc.scraper.Scrape(ctx, req.Url)
+	if err != nil {
+		return nil, linkError(err)
+	}
+
+	imageUrl := req.ImageUrl
+	switch {
+	case len(preview.Images) == 0:
+		return nil, internal_errors.ErrLinkImageNotFound
+	case imageUrl == "":
+		imageUrl = preview.Images[0]
+	case !slices.Contains(preview.Images, imageUrl):
+		return nil, internal_errors.ErrLinkImageNotFound
+	}
+
+	imageBytes, err := muc.scraper.FetchImage(ctx, imageUrl, muc.uploadParams.MaxImageSize)
+	if err != nil {
+		if errors.Is(err, linkpreview.ErrTooLarge) {
+			return nil, internal_errors.ErrMediaTooLarge
+		}
+		if errors.Is(err, linkpreview.ErrNotImage) {
+			return nil, internal_errors.ErrWrongMediaContentType
+		}
+		return nil, linkError(err)
+	}
+
+	// The content type of the response is not trusted, as with uploaded files
+	fileType := http.DetectContentType(imageBytes)
+	if !muc.repo.HasImageContentType(fileType) {
+		return nil, internal_errors.ErrWrongMediaContentType
+	}
+
+	media := &models.Media{OwnerID: &authorID, BucketName: muc.repo.GetBucketNameForContentType(fileType)}
+	mediaKey, err := uploadImage(muc.repo, media, imageBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PinDraft{
+		Title:       preview.Title,
+		Description: preview.Description,
+		RelatedLink: preview.Url,
+		MediaUrl:    muc.repo.PublicMediaUrl(mediaKey),
+	}, nil
+}
+
+// linkError tells the client why the page couldn't be read, failures of the remote side are not internal errors.
+func linkError(err error) error {
+	switch {
+	case errors.Is(err, linkpreview.ErrInvalidUrl):
+		return internal_errors.ErrLinkInvalid
+	case errors.Is(err, linkpreview.ErrForbiddenAddress):
+		return internal_errors.ErrLinkForbidden
+	case errors.Is(err, context.Canceled):
+		return err
+	default:
+		return internal_errors.ErrLinkUnreachable
+	}
+}
@@ -1,59 +1,249 @@
 package usecase
 
 import (
-	"bytes"
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"pinset/configs"
 	delivery "pinset/internal/app/delivery/http"
 	"pinset/internal/app/models"
+	"pinset/pkg/audioprobe"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/linkpreview"
+	"pinset/pkg/mp4probe"
+	"slices"
+	"time"
 
 	internal_errors "pinset/internal/errors"
 )
 
-func NewMediaUsecase(repo MediaRepository, userRepo UserRepository) delivery.MediaUsecase {
+func NewMediaUsecase(repo MediaRepository, userRepo UserRepository, userOnlineRepo UserOnlineRepo, views, linkClicks *PinCounter) delivery.MediaUsecase {
+	return NewMediaUsecaseWithLinkClient(repo, userRepo, userOnlineRepo, views, linkClicks, linkpreview.NewSafeClient(configs.NewLinkPreviewParams().Timeout))
+}
+
+// NewMediaUsecaseWithLinkClient fetches pages pins are saved from with the given client.
+func NewMediaUsecaseWithLinkClient(repo MediaRepository, userRepo UserRepository, userOnlineRepo UserOnlineRepo, views, linkClicks *PinCounter, linkClient *http.Client) delivery.MediaUsecase {
 	return &MediaUsecaseController{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:           repo,
+		userRepo:       userRepo,
+		userOnlineRepo: userOnlineRepo,
+		uploadParams:   configs.NewUploadParams(),
+		scraper:        linkpreview.NewScraper(linkClient, configs.NewLinkPreviewParams().MaxPageSize),
+		views:          views,
+		linkClicks:     linkClicks,
 	}
 }
 
-func (muc *MediaUsecaseController) UploadMedia(files []*multipart.FileHeader) ([]string, error) {
+func (muc *MediaUsecaseController) UploadMedia(ownerID uint64, files []*multipart.FileHeader) ([]string, error) {
+	return uploadMediaFiles(muc.repo, muc.uploadParams, ownerID, files, muc.repo.HasCorrectContentType)
+}
+
+// sniffLen is the amount of bytes http.DetectContentType looks at
+const sniffLen = 512
+
+// uploadMediaFiles stores files in the bucket matching their content type and registers them as media of the owner.
+// Files with a type rejected by hasAllowedType or exceeding the size limit of the type fail the whole upload.
+func uploadMediaFiles(repo MediaRepository, uploadParams configs.UploadParams, ownerID uint64, files []*multipart.FileHeader, hasAllowedType func(string) bool) ([]string, error) {
 	var uploadedMediaUrls []string
 
 	for _, fileHeader := range files {
-		// Open the file
-		file, err := fileHeader.Open()
+		uploadedMediaId, err := uploadMediaFile(repo, uploadParams, ownerID, fileHeader, hasAllowedType)
 		if err != nil {
 			return []string{}, err
 		}
-		defer file.Close()
 
-		mediaBytes := make([]byte, fileHeader.Size)
-		_, err = file.Read(mediaBytes)
+		uploadedMediaUrls = append(uploadedMediaUrls, uploadedMediaId)
+	}
+
+	return uploadedMediaUrls, nil
+}
+
+// uploadMediaFile streams the file to the storage without loading it into memory.
+// Only images are read whole, since they have to be decoded for processing.
+func uploadMediaFile(repo MediaRepository, uploadParams configs.UploadParams, ownerID uint64, fileHeader *multipart.FileHeader, hasAllowedType func(string) bool) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// Checking the content type
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("sniff media: %w", err)
+	}
+	fileType := http.DetectContentType(head[:n])
+	if !hasAllowedType(fileType) {
+		return "", internal_errors.ErrWrongMediaContentType
+	}
+
+	if fileHeader.Size > uploadParams.MaxSizeForContentType(fileType) {
+		return "", internal_errors.ErrMediaTooLarge
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind media: %w", err)
+	}
+
+	media := newMedia(ownerID, repo.GetBucketNameForContentType(fileType))
+
+	if repo.HasImageContentType(fileType) {
+		mediaBytes, err := io.ReadAll(file)
 		if err != nil {
-			return []string{}, err
+			return "", fmt.Errorf("read image: %w", err)
+		}
+		mediaKey, err := uploadImage(repo, media, mediaBytes)
+		if err != nil {
+			return "", err
 		}
-		r := bytes.NewReader(mediaBytes)
+		return repo.PublicMediaUrl(mediaKey), nil
+	}
+
+	// The spooled file is hashed before the upload, so that a duplicate is never sent to the storage
+	// and minio still gets an io.ReaderAt for the parallel multipart upload
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, file); err != nil {
+		return "", fmt.Errorf("hash media: %w", err)
+	}
+	media.Checksum = checksumHex(checksum.Sum(nil))
 
-		// Checking the content type
-		fileType := http.DetectContentType(mediaBytes)
-		if !muc.repo.HasCorrectContentType(fileType) {
-			return []string{}, internal_errors.ErrWrongMediaContentType
+	duplicate, err := repo.ReuseMediaByChecksum(media.BucketName, *media.Checksum)
+	if err != nil {
+		return "", err
+	}
+	if duplicate != nil {
+		return repo.PublicMediaUrl(duplicate.MediaKey), nil
+	}
+
+	if repo.HasVideoContentType(fileType) || repo.HasAudioContentType(fileType) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("rewind media: %w", err)
 		}
+		if err := probeMedia(repo, media, fileType, file); err != nil {
+			return "", err
+		}
+	}
 
-		bucketName := muc.repo.GetBucketNameForContentType(fileType)
-		uploadedMediaId, err := muc.repo.UploadMedia(bucketName, fileHeader.Filename, r, fileHeader.Size)
-		if err != nil {
-			return []string{}, err
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind media: %w", err)
+	}
+
+	media.ContentType = fileType
+	media.Size = fileHeader.Size
+	if err := repo.UploadMedia(media, file); err != nil {
+		return "", err
+	}
+	return repo.PublicMediaUrl(media.MediaKey), nil
+}
+
+// uploadImage strips metadata from the image and stores it together with the resized variants.
+// An image with the same content as an already stored one is not processed again, the stored one is reused.
+// The media key of the stored image is returned.
+func uploadImage(repo MediaRepository, media *models.Media, mediaBytes []byte) (string, error) {
+	checksum := sha256.Sum256(mediaBytes)
+	media.Checksum = checksumHex(checksum[:])
+
+	duplicate, err := repo.ReuseMediaByChecksum(media.BucketName, *media.Checksum)
+	if err != nil {
+		return "", err
+	}
+	if duplicate != nil {
+		return duplicate.MediaKey, nil
+	}
+
+	img, err := imageproc.Process(mediaBytes)
+	if err != nil {
+		if errors.Is(err, imageproc.ErrUnsupportedFormat) || errors.Is(err, imageproc.ErrImageTooLarge) {
+			return "", internal_errors.ErrWrongMediaContentType
 		}
+		return "", fmt.Errorf("process image: %w", err)
+	}
 
-		uploadedMediaUrls = append(uploadedMediaUrls, uploadedMediaId)
+	if err := repo.UploadImage(media, img); err != nil {
+		return "", err
 	}
+	return media.MediaKey, nil
+}
 
-	return uploadedMediaUrls, nil
+// probeMedia reads the metadata of a video or audio file of the content type into the media.
+// The cover embedded in the file is stored as an image and becomes the poster of the media.
+func probeMedia(repo MediaRepository, media *models.Media, fileType string, content io.Reader) error {
+	if repo.HasVideoContentType(fileType) {
+		return probeVideo(repo, media, content)
+	}
+	return probeAudio(repo, media, fileType, content)
+}
+
+// probeVideo reads the duration and size of an MP4 video.
+func probeVideo(repo MediaRepository, media *models.Media, content io.Reader) error {
+	info, err := mp4probe.Probe(content)
+	if err != nil {
+		if errors.Is(err, mp4probe.ErrInvalidFormat) || errors.Is(err, mp4probe.ErrNoMoov) || errors.Is(err, mp4probe.ErrMoovTooLarge) {
+			return internal_errors.ErrWrongMediaContentType
+		}
+		return fmt.Errorf("probe video: %w", err)
+	}
+
+	durationMs := info.Duration.Milliseconds()
+	media.DurationMs = &durationMs
+	if info.Width > 0 && info.Height > 0 {
+		media.Width = &info.Width
+		media.Height = &info.Height
+	}
+	return uploadPoster(repo, media, info.CoverArt, info.CoverArtType)
+}
+
+// probeAudio reads the duration and the waveform of an audio file.
+func probeAudio(repo MediaRepository, media *models.Media, fileType string, content io.Reader) error {
+	info, err := audioprobe.Probe(content, fileType)
+	if err != nil {
+		if errors.Is(err, audioprobe.ErrInvalidFormat) || errors.Is(err, audioprobe.ErrUnsupportedFormat) {
+			return internal_errors.ErrWrongMediaContentType
+		}
+		return fmt.Errorf("probe audio: %w", err)
+	}
+
+	durationMs := info.Duration.Milliseconds()
+	media.DurationMs = &durationMs
+	media.Waveform = info.Waveform
+	return uploadPoster(repo, media, info.Cover, info.CoverType)
+}
+
+func uploadPoster(repo MediaRepository, media *models.Media, cover []byte, coverType string) error {
+	if cover == nil {
+		return nil
+	}
+
+	poster := &models.Media{OwnerID: media.OwnerID, BucketName: repo.GetBucketNameForContentType(coverType)}
+	posterKey, err := uploadImage(repo, poster, cover)
+	if err != nil {
+		// A broken cover doesn't make the file invalid, it is left without a poster
+		if errors.Is(err, internal_errors.ErrWrongMediaContentType) {
+			return nil
+		}
+		return err
+	}
+	media.PosterKey = &posterKey
+	return nil
+}
+
+// newMedia describes media of the bucket, ownerID is 0 for anonymous uploads.
+func newMedia(ownerID uint64, bucketName string) *models.Media {
+	media := &models.Media{BucketName: bucketName}
+	if ownerID != 0 {
+		media.OwnerID = &ownerID
+	}
+	return media
+}
+
+func checksumHex(sum []byte) *string {
+	checksum := hex.EncodeToString(sum)
+	return &checksum
 }
 
 //////////////////////// PINS ////////////////////////////
@@ -64,6 +254,18 @@ func (muc *MediaUsecaseController) Feed(userID uint64) ([]*models.Pin, error) {
 		return nil, fmt.Errorf("feed usecase: %w", err)
 	}
 
+	blockedUsers, err := blockedUsersSet(muc.userRepo, userID)
+	if err != nil {
+		return nil, fmt.Errorf("feed usecase blockedUsersSet: %w", err)
+	}
+	pinSet = slices.DeleteFunc(pinSet, func(pin *models.Pin) bool {
+		return blockedUsers[pin.AuthorID]
+	})
+
+	if err := muc.fillPinViewerState(pinSet, userID); err != nil {
+		return nil, fmt.Errorf("feed usecase fillPinViewerState: %w", err)
+	}
+
 	for _, pin := range pinSet {
 		pin.AuthorInfo, err = muc.GetPinAuthorNickNameByUserID(pin.AuthorID)
 		if err != nil {
@@ -83,29 +285,57 @@ func (muc *MediaUsecaseController) Feed(userID uint64) ([]*models.Pin, error) {
 			}
 
 			pin.Boards = availableBoards
-
-			var isBookmarked uint64
-			isBookmarked, err = muc.repo.GetBookmarkOnUserPin(userID, pin.PinID)
-			if err != nil {
-				if !errors.Is(err, sql.ErrNoRows) {
-					return nil, fmt.Errorf("feed usecase GetBookmarkUserPin: %w", err)
-				}
-			}
-
-			if isBookmarked != 0 {
-				pin.IsBookmarked = true
-			}
 		}
 	}
 	return pinSet, nil
 }
 
-func (muc *MediaUsecaseController) GetPinPreviewInfo(pinID uint64) (*models.Pin, error) {
-	return muc.repo.GetPinPreviewInfoByPinID(pinID)
+func (muc *MediaUsecaseController) GetPinPreviewInfo(pinID uint64, currUserID uint64) (*models.Pin, error) {
+	pin, err := muc.visiblePin(pinID, currUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillPinViewerState([]*models.Pin{pin}, currUserID); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
+// visiblePin returns the preview of the pin if the user can see it.
+func (muc *MediaUsecaseController) visiblePin(pinID uint64, currUserID uint64) (*models.Pin, error) {
+	pin, err := muc.repo.GetPinPreviewInfoByPinID(pinID)
+	if err != nil {
+		return nil, err
+	}
+	return muc.hideInvisiblePin(pin, currUserID)
+}
+
+func (muc *MediaUsecaseController) GetPinPageInfo(pinID uint64, currUserID uint64) (*models.Pin, error) {
+	pin, err := muc.repo.GetPinPageInfoByPinID(pinID)
+	if err != nil {
+		return nil, err
+	}
+	if pin, err = muc.hideInvisiblePin(pin, currUserID); err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillPinViewerState([]*models.Pin{pin}, currUserID); err != nil {
+		return nil, err
+	}
+	return pin, nil
 }
 
-func (muc *MediaUsecaseController) GetPinPageInfo(pinID uint64) (*models.Pin, error) {
-	return muc.repo.GetPinPageInfoByPinID(pinID)
+// hideInvisiblePin reports pins of users blocked by the viewer and unpublished pins of other users as missing.
+func (muc *MediaUsecaseController) hideInvisiblePin(pin *models.Pin, currUserID uint64) (*models.Pin, error) {
+	blockedUsers, err := blockedUsersSet(muc.userRepo, currUserID)
+	if err != nil {
+		return nil, err
+	}
+	if blockedUsers[pin.AuthorID] || !pin.VisibleTo(currUserID) {
+		return nil, internal_errors.ErrPinDoesntExists
+	}
+	return pin, nil
 }
 
 func (mrc *MediaUsecaseController) GetPinAuthorNickNameByUserID(userID uint64) (*models.UserPin, error) {
@@ -116,20 +346,170 @@ func (muc *MediaUsecaseController) GetPinBookmarksNumber(pinID uint64) (uint64,
 	return muc.repo.GetPinBookmarksNumberByPinID(pinID)
 }
 
-func (muc *MediaUsecaseController) GetAllCommentaries(pinID uint64) ([]*models.Comment, error) {
-	return muc.repo.GetAllCommentariesByPinID(pinID)
+func (muc *MediaUsecaseController) GetAllCommentaries(pinID uint64, currUserID uint64) ([]*models.Comment, error) {
+	comments, err := muc.repo.GetAllCommentariesByPinID(pinID)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedUsers, err := blockedUsersSet(muc.userRepo, currUserID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(comments, func(comment *models.Comment) bool {
+		return blockedUsers[comment.AuthorID]
+	}), nil
 }
 
 func (muc *MediaUsecaseController) CreatePin(pin *models.Pin) error {
+	if err := muc.resolvePinUpload(pin.AuthorID, &pin.PinMedia); err != nil {
+		return err
+	}
+	if err := muc.checkPinCarousel(pin); err != nil {
+		return err
+	}
+	if err := muc.checkPinPoster(pin); err != nil {
+		return err
+	}
+	// A pin is published right away unless it is saved as a draft or scheduled
+	if pin.Status == "" && pin.PublishAt == nil {
+		pin.Status = models.PinStatusPublished
+	}
+	if err := checkPinStatus(pin, time.Now()); err != nil {
+		return err
+	}
+
 	return muc.repo.CreatePin(pin)
 }
 
+// checkPinStatus validates a change of the status, a pin with only a publishing time is scheduled.
+// The status is kept when neither is given.
+func checkPinStatus(pin *models.Pin, now time.Time) error {
+	if pin.Status == "" && pin.PublishAt != nil {
+		pin.Status = models.PinStatusScheduled
+	}
+
+	switch pin.Status {
+	case "":
+	case models.PinStatusScheduled:
+		if pin.PublishAt == nil || !pin.PublishAt.After(now) {
+			return internal_errors.ErrPinPublishAtInvalid
+		}
+	case models.PinStatusDraft, models.PinStatusPublished:
+		pin.PublishAt = nil
+	default:
+		return internal_errors.ErrPinStatusInvalid
+	}
+	return nil
+}
+
+// GetDrafts lists the drafts and scheduled pins of the user.
+func (muc *MediaUsecaseController) GetDrafts(userID uint64) ([]*models.Pin, error) {
+	drafts, err := muc.repo.GetUnpublishedPinsByAuthorID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillPinViewerState(drafts, userID); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// resolvePinUpload takes the media of a direct upload of the author.
+func (muc *MediaUsecaseController) resolvePinUpload(authorID uint64, media *models.PinMedia) error {
+	if media.UploadID == nil {
+		return nil
+	}
+
+	upload, err := muc.repo.GetUpload(*media.UploadID)
+	if err != nil {
+		return err
+	}
+	if upload.UserID != authorID {
+		return internal_errors.ErrUploadDoesntExists
+	}
+	if upload.Status != models.UploadStatusCompleted {
+		return internal_errors.ErrUploadNotCompleted
+	}
+	media.MediaUrl = upload.MediaKey
+	return nil
+}
+
+// checkPinCarousel resolves the items of the carousel, the first of which becomes the media of the pin.
+// Carousels hold images and videos only.
+func (muc *MediaUsecaseController) checkPinCarousel(pin *models.Pin) error {
+	if len(pin.Carousel) > models.MaxCarouselItems {
+		return internal_errors.ErrTooManyCarouselItems
+	}
+
+	for _, item := range pin.Carousel {
+		if item == nil {
+			return internal_errors.ErrPinDataInvalid
+		}
+		if err := muc.resolvePinUpload(pin.AuthorID, item); err != nil {
+			return err
+		}
+		if item.MediaUrl == nil {
+			return internal_errors.ErrPinDataInvalid
+		}
+		if muc.repo.MediaUrlType(*item.MediaUrl) == models.MediaTypeAudio {
+			return internal_errors.ErrWrongMediaContentType
+		}
+	}
+
+	if len(pin.Carousel) > 0 {
+		pin.MediaUrl = pin.Carousel[0].MediaUrl
+	}
+	return nil
+}
+
+// checkPinPoster allows only images of our storage as posters.
+func (muc *MediaUsecaseController) checkPinPoster(pin *models.Pin) error {
+	if pin.PosterUrl != nil && !muc.repo.IsImageMediaUrl(*pin.PosterUrl) {
+		return internal_errors.ErrWrongMediaContentType
+	}
+	return nil
+}
+
+// GetSimilarPins finds pins with images that look like the image of the pin.
+func (muc *MediaUsecaseController) GetSimilarPins(pinID uint64) ([]uint64, error) {
+	return muc.repo.GetSimilarPins(pinID, models.NearDuplicateMaxDistance, models.MaxSimilarPins)
+}
+
+// UpdatePinInfo updates the pin of its author, pin.AuthorID is the user requesting the update.
 func (muc *MediaUsecaseController) UpdatePinInfo(pin *models.Pin) error {
+	current, err := muc.repo.GetPinPreviewInfoByPinID(pin.PinID)
+	if err != nil {
+		return err
+	}
+	if current.AuthorID != pin.AuthorID {
+		return internal_errors.ErrUserIsNotPinAuthor
+	}
+
+	if err := muc.checkPinCarousel(pin); err != nil {
+		return err
+	}
+	if err := muc.checkPinPoster(pin); err != nil {
+		return err
+	}
+	if err := checkPinStatus(pin, time.Now()); err != nil {
+		return err
+	}
 	return muc.repo.UpdatePinInfoByPinID(pin)
 }
 
-func (muc *MediaUsecaseController) UpdatePinViewsNumber(pinID uint64) error {
-	return muc.repo.UpdatePinViewsByPinID(pinID)
+// RecordPinView counts the view of a visible pin once per viewer within the dedup window.
+// Repeated views are checked before the pin is loaded, so they cost no queries.
+func (muc *MediaUsecaseController) RecordPinView(pinID, currUserID uint64, viewer string) error {
+	if muc.views.Seen(pinID, viewer) {
+		return nil
+	}
+	if _, err := muc.visiblePin(pinID, currUserID); err != nil {
+		return err
+	}
+	muc.views.Record(pinID, viewer)
+	return nil
 }
 
 func (muc *MediaUsecaseController) DeletePinByPinID(pinID uint64) error {
@@ -140,30 +520,38 @@ func (muc *MediaUsecaseController) GetBookmarkOnUserPin(ownerID, pinID uint64) (
 	return muc.repo.GetBookmarkOnUserPin(ownerID, pinID)
 }
 
+// CreatePinBookmark is idempotent: bookmarking the pin again changes nothing.
 func (muc *MediaUsecaseController) CreatePinBookmark(bookmark *models.Bookmark) error {
-	err := muc.repo.CreatePinBookmark(bookmark)
-	if err != nil {
-		return fmt.Errorf("createPinBookmark usecase: %w", err)
+	if _, err := muc.visiblePin(bookmark.PinID, bookmark.OwnerID); err != nil {
+		return err
 	}
+	bookmark.BookmarkTime = time.Now()
 
-	err = muc.repo.UpdateBookmarksCountIncrease(bookmark.PinID)
+	err := muc.repo.RunInTx(func(repo TxRepository) error {
+		created, err := repo.CreatePinBookmark(bookmark)
+		if err != nil || !created {
+			return err
+		}
+		return repo.UpdateBookmarksCountIncrease(bookmark.PinID)
+	})
 	if err != nil {
-		return fmt.Errorf("updateBookmarksCountIncrease usecase: %w", err)
+		return fmt.Errorf("createPinBookmark usecase: %w", err)
 	}
-
 	return nil
 }
 
+// DeletePinBookmarkByOwnerIDAndPinID is idempotent: deleting a missing bookmark changes nothing.
 func (muc *MediaUsecaseController) DeletePinBookmarkByOwnerIDAndPinID(bookmark models.Bookmark) error {
-	err := muc.repo.DeletePinBookmarkByOwnerIDAndPinID(bookmark)
+	err := muc.repo.RunInTx(func(repo TxRepository) error {
+		deleted, err := repo.DeletePinBookmark(bookmark.OwnerID, bookmark.PinID)
+		if err != nil || !deleted {
+			return err
+		}
+		return repo.UpdateBookmarksCountDecrease(bookmark.PinID)
+	})
 	if err != nil {
 		return fmt.Errorf("deletePinBookmarkByOwnerIDAndPinID usecase: %w", err)
 	}
-
-	err = muc.repo.UpdateBookmarksCountDecrease(bookmark.PinID)
-	if err != nil {
-		return fmt.Errorf("UpdatePinBookmarksByPinID usecase: %w", err)
-	}
 	return nil
 }
 
@@ -173,8 +561,17 @@ func (muc *MediaUsecaseController) GetAllUserBoards(ownerID uint64, currUserID u
 	return muc.repo.GetAllBoardsByOwnerID(ownerID)
 }
 
-func (muc *MediaUsecaseController) GetBoard(boardID uint64) (*models.Board, error) {
-	return muc.repo.GetBoardByBoardID(boardID)
+func (muc *MediaUsecaseController) GetBoard(boardID uint64, currUserID uint64) (*models.Board, error) {
+	board, err := muc.repo.GetBoardByBoardID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := canViewBoard(muc.repo, board, currUserID); err != nil {
+		return nil, err
+	}
+
+	return board, nil
 }
 
 func (muc *MediaUsecaseController) CreateBoard(board *models.Board) error {
@@ -189,21 +586,35 @@ func (muc *MediaUsecaseController) DeleteBoard(boardID uint64) error {
 	return muc.repo.DeleteBoardByBoardID(boardID)
 }
 
-func (muc *MediaUsecaseController) GetBoardPins(boardID uint64) ([]*models.Pin, error) {
+func (muc *MediaUsecaseController) GetBoardPins(boardID uint64, currUserID uint64) ([]*models.Pin, error) {
+	if _, err := muc.GetBoard(boardID, currUserID); err != nil {
+		return nil, err
+	}
+
 	PinIDs, err := muc.repo.GetBoardPinsByBoardID(boardID)
+	if err != nil {
+		return nil, err
+	}
 
 	var pins []*models.Pin
-
+	blockedUsers, err := blockedUsersSet(muc.userRepo, currUserID)
 	if err != nil {
 		return nil, err
 	}
 	for _, pinID := range PinIDs {
-		pin, err := muc.GetPinPageInfo(pinID)
+		pin, err := muc.repo.GetPinPageInfoByPinID(pinID)
 		if err != nil {
 			return nil, err
 		}
+		if blockedUsers[pin.AuthorID] || !pin.VisibleTo(currUserID) {
+			continue
+		}
 		pins = append(pins, pin)
 	}
+
+	if err := muc.fillPinViewerState(pins, currUserID); err != nil {
+		return nil, err
+	}
 	return pins, nil
 }
 
@@ -211,14 +622,28 @@ func (muc *MediaUsecaseController) AddPinToBoard(boardID uint64, pinID uint64) e
 	return muc.repo.AddPinToBoard(boardID, pinID)
 }
 
-func (muc *MediaUsecaseController) UpdateBookmarksCountIncrease(pinID uint64) error {
-	return muc.repo.UpdateBookmarksCountIncrease(pinID)
+func (muc *MediaUsecaseController) DeletePinFromBoard(boardID uint64, pinID uint64) error {
+	return muc.repo.DeletePinFromBoardByBoardIDAndPinID(boardID, pinID)
 }
 
-func (muc *MediaUsecaseController) UpdateBookmarksCountDecrease(pinID uint64) error {
-	return muc.repo.UpdateBookmarksCountDecrease(pinID)
-}
+// canViewBoard lets everyone see public boards, while private ones are
+// visible only to the owner and to members of chats the board was shared in.
+func canViewBoard(repo MediaRepository, board *models.Board, userID uint64) error {
+	if board.Public || board.OwnerID == userID {
+		return nil
+	}
 
-func (muc *MediaUsecaseController) DeletePinFromBoard(boardID uint64, pinID uint64) error {
-	return muc.repo.DeletePinFromBoardByBoardIDAndPinID(boardID, pinID)
+	if userID == 0 {
+		return internal_errors.ErrBoardDoesntExists
+	}
+
+	shared, err := repo.IsBoardSharedWithUser(board.BoardID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !shared {
+		return internal_errors.ErrBoardDoesntExists
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"pinset/configs"
+	"time"
+)
+
+// MediaSweeper removes objects of the storage that nothing refers to anymore:
+// media without references and uploads never completed after their URL expired.
+type MediaSweeper struct {
+	repo   MediaRepository
+	params configs.MediaGCParams
+}
+
+// SweepResult counts the entries removed by a single sweep
+type SweepResult struct {
+	Media   int
+	Uploads int
+}
+
+func NewMediaSweeper(repo MediaRepository) *MediaSweeper {
+	return &MediaSweeper{
+		repo:   repo,
+		params: configs.NewMediaGCParams(),
+	}
+}
+
+func (ms *MediaSweeper) Interval() time.Duration {
+	return ms.params.Interval
+}
+
+// Sweep removes a batch of unreferenced media and a batch of expired uploads.
+// A registry entry is deleted before its objects, so an object is never removed while referenced.
+// Failing to remove an object only leaks it, the sweep goes on and reports the errors in the end.
+func (ms *MediaSweeper) Sweep() (SweepResult, error) {
+	var result SweepResult
+	var errs []error
+	threshold := time.Now().Add(-ms.params.GracePeriod)
+
+	mediaList, err := ms.repo.GetUnreferencedMedia(threshold, ms.params.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("sweep media: %w", err)
+	}
+	for _, media := range mediaList {
+		deleted, err := ms.repo.DeleteUnreferencedMedia(media.MediaID, threshold)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Referenced or reused by a duplicate upload during the sweep
+		if !deleted {
+			continue
+		}
+
+		for _, objectKey := range append([]string{media.ObjectKey}, media.VariantKeys...) {
+			if err := ms.repo.RemoveObject(media.BucketName, objectKey); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		result.Media++
+	}
+
+	uploads, err := ms.repo.GetExpiredUploads(threshold, ms.params.BatchSize)
+	if err != nil {
+		return result, errors.Join(append(errs, fmt.Errorf("sweep uploads: %w", err))...)
+	}
+	for _, upload := range uploads {
+		deleted, err := ms.repo.DeletePendingUpload(upload.UploadID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+
+		// The client may have never put the object, removing a missing object is not an error for minio
+		if err := ms.repo.RemoveObject(upload.BucketName, upload.ObjectName); err != nil {
+			errs = append(errs, err)
+		}
+		result.Uploads++
+	}
+
+	return result, errors.Join(errs...)
+}
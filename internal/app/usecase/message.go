@@ -1,8 +1,15 @@
 package usecase
 
 import (
+	"errors"
+	"mime/multipart"
+	"pinset/configs"
 	delivery "pinset/internal/app/delivery/http"
 	"pinset/internal/app/models"
+	"slices"
+	"time"
+
+	internal_errors "pinset/internal/errors"
 )
 
 func NewMessageUsecase(userOnlineRepo UserOnlineRepo, mediaRepo MediaRepository, userRepo UserRepository) delivery.MessageUsecase {
@@ -10,6 +17,7 @@ func NewMessageUsecase(userOnlineRepo UserOnlineRepo, mediaRepo MediaRepository,
 		mediaRepo:      mediaRepo,
 		userRepo:       userRepo,
 		userOnlineRepo: userOnlineRepo,
+		uploadParams:   configs.NewUploadParams(),
 	}
 }
 
@@ -33,36 +41,519 @@ func (muc *MessageUsecaseController) DeleteOnlineUser(userID uint64) {
 	muc.userOnlineRepo.DeleteOnlineUser(userID)
 }
 
-func (muc *MessageUsecaseController) GetChatMessages(chatID uint64) ([]*models.MessageInfo, error) {
-	return muc.mediaRepo.GetChatMessages(chatID)
+func (muc *MessageUsecaseController) GetChatMessages(userID, chatID uint64, params *models.MessagesPageParams) ([]*models.MessageInfo, error) {
+	if err := params.Valid(); err != nil {
+		return nil, err
+	}
+
+	if _, err := muc.mediaRepo.GetChatMemberRole(chatID, userID); err != nil {
+		return nil, err
+	}
+
+	messages, err := muc.mediaRepo.GetChatMessages(chatID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err = muc.hideBlockedMessages(userID, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillMessagesAttachments(userID, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetChatMessagesAround returns the message with up to limit/2 messages on each side of it,
+// which lets clients jump to a search hit and keep paginating in both directions.
+func (muc *MessageUsecaseController) GetChatMessagesAround(userID, chatID, messageID uint64, limit int) ([]*models.MessageInfo, error) {
+	if limit <= 0 || limit > models.MaxMessagesPageSize {
+		return nil, internal_errors.ErrMessagesPageInvalid
+	}
+
+	if _, err := muc.mediaRepo.GetChatMemberRole(chatID, userID); err != nil {
+		return nil, err
+	}
+
+	message, err := muc.mediaRepo.GetChatMessage(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	sideLimit := max(limit/2, 1)
+	before, err := muc.mediaRepo.GetChatMessages(chatID, &models.MessagesPageParams{BeforeID: &messageID, Limit: sideLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := muc.mediaRepo.GetChatMessages(chatID, &models.MessagesPageParams{AfterID: &messageID, Limit: sideLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.MessageInfo, 0, len(before)+len(after)+1)
+	messages = append(messages, before...)
+	messages = append(messages, message)
+	messages = append(messages, after...)
+
+	messages, err = muc.hideBlockedMessages(userID, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillMessagesAttachments(userID, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (muc *MessageUsecaseController) SearchMessages(params *models.MessageSearchParams) ([]*models.MessageInfo, error) {
+	params.Sanitize()
+	if err := params.Valid(); err != nil {
+		return nil, err
+	}
+
+	if params.ChatID != nil {
+		if _, err := muc.mediaRepo.GetChatMemberRole(*params.ChatID, params.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := muc.mediaRepo.SearchMessages(params)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err = muc.hideBlockedMessages(params.UserID, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillMessagesAttachments(params.UserID, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
 }
 
 func (muc *MessageUsecaseController) AddChatMessage(message *models.Message) (*models.MessageCreateInfo, error) {
-	return muc.mediaRepo.CreateMessage(message)
+	if _, err := muc.mediaRepo.GetChatMemberRole(message.ChatID, message.SenderID); err != nil {
+		return nil, err
+	}
+
+	// System messages are produced by the server only
+	message.Type = models.MessageTypeText
+	message.TargetID = nil
+
+	if err := message.Valid(); err != nil {
+		return nil, err
+	}
+
+	if err := muc.checkDirectChatNotBlocked(message.ChatID, message.SenderID); err != nil {
+		return nil, err
+	}
+
+	if err := muc.checkAttachmentsAccess(message.SenderID, message.Attachments); err != nil {
+		return nil, err
+	}
+
+	messageInfo, err := muc.mediaRepo.CreateMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.fillAttachmentPreviews(message.SenderID, messageInfo.Attachments); err != nil {
+		return nil, err
+	}
+	return messageInfo, nil
+}
+
+// UploadChatAttachments stores images that are going to be sent to the chat.
+func (muc *MessageUsecaseController) UploadChatAttachments(userID, chatID uint64, files []*multipart.FileHeader) ([]string, error) {
+	if _, err := muc.mediaRepo.GetChatMemberRole(chatID, userID); err != nil {
+		return nil, err
+	}
+
+	return uploadMediaFiles(muc.mediaRepo, muc.uploadParams, userID, files, muc.mediaRepo.HasImageContentType)
+}
+
+// checkAttachmentsAccess makes sure the sender can share every attachment.
+// Pins must be visible to the sender, private boards can be shared by their owner only.
+func (muc *MessageUsecaseController) checkAttachmentsAccess(senderID uint64, attachments []*models.MessageAttachment) error {
+	blockRelated, err := blockRelatedUsersSet(muc.userRepo, senderID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		switch attachment.Type {
+		case models.AttachmentTypePin:
+			pin, err := muc.mediaRepo.GetPinPreviewInfoByPinID(*attachment.PinID)
+			if err != nil {
+				return err
+			}
+			if blockRelated[pin.AuthorID] || !pin.VisibleTo(senderID) {
+				return internal_errors.ErrPinDoesntExists
+			}
+		case models.AttachmentTypeBoard:
+			board, err := muc.mediaRepo.GetBoardByBoardID(*attachment.BoardID)
+			if err != nil {
+				return err
+			}
+			if !board.Public && board.OwnerID != senderID {
+				return internal_errors.ErrBoardDoesntExists
+			}
+		case models.AttachmentTypeImage:
+			if !muc.mediaRepo.IsImageMediaUrl(*attachment.MediaUrl) {
+				return internal_errors.ErrMessageDataInvalid
+			}
+		}
+	}
+	return nil
+}
+
+// fillMessagesAttachments loads attachments with previews of a page of messages for the viewer.
+func (muc *MessageUsecaseController) fillMessagesAttachments(viewerID uint64, messages []*models.MessageInfo) error {
+	messageIDs := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		messageIDs = append(messageIDs, message.ID)
+	}
+
+	attachments, err := muc.mediaRepo.GetMessagesAttachments(messageIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		message.Attachments = attachments[message.ID]
+		if err := muc.fillAttachmentPreviews(viewerID, message.Attachments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillAttachmentPreviews loads pin and board preview data for rendering in the chat.
+// Chat members may see private boards shared with them, so boards aren't checked.
+// Pins the viewer can't see, such as pins unpublished or blocked after sharing, are left without a preview.
+func (muc *MessageUsecaseController) fillAttachmentPreviews(viewerID uint64, attachments []*models.MessageAttachment) error {
+	blockRelated, err := blockRelatedUsersSet(muc.userRepo, viewerID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		var err error
+		switch attachment.Type {
+		case models.AttachmentTypePin:
+			var pin *models.Pin
+			pin, err = muc.mediaRepo.GetPinPreviewInfoByPinID(*attachment.PinID)
+			if err == nil && !blockRelated[pin.AuthorID] && pin.VisibleTo(viewerID) {
+				attachment.Pin = pin
+			}
+		case models.AttachmentTypeBoard:
+			attachment.Board, err = muc.mediaRepo.GetBoardByBoardID(*attachment.BoardID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
+
 func (muc *MessageUsecaseController) GetChatUsers(chatID uint64) ([]uint64, error) {
 	return muc.mediaRepo.GetChatUsers(chatID)
 }
 
+// GetMessageReceivers returns chat members the message should be delivered to.
+// Text messages aren't delivered to members who blocked the sender.
+func (muc *MessageUsecaseController) GetMessageReceivers(message *models.MessageCreateInfo) ([]uint64, error) {
+	chatUserIDs, err := muc.mediaRepo.GetChatUsers(message.ChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Type == models.MessageTypeSystem {
+		return chatUserIDs, nil
+	}
+
+	blockedBy, err := muc.userRepo.GetBlockerUserIDs(message.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	receiverIDs := make([]uint64, 0, len(chatUserIDs))
+	for _, userID := range chatUserIDs {
+		if userID == message.SenderID || !slices.Contains(blockedBy, userID) {
+			receiverIDs = append(receiverIDs, userID)
+		}
+	}
+	return receiverIDs, nil
+}
+
 func (muc *MessageUsecaseController) CreateChat(req *models.ChatCreateRequest) (*models.ChatInfo, error) {
-	chatCreateInfo, err := muc.mediaRepo.CreateChat()
+	if _, err := muc.userRepo.GetUserInfoPublic(req.CompanionID); err != nil {
+		return nil, err
+	}
+
+	if err := muc.checkNotBlocked(req.UserID, req.CompanionID); err != nil {
+		return nil, err
+	}
+
+	// Existing chat of the pair is returned instead of creating a parallel one
+	chatCreateInfo, err := muc.mediaRepo.CreateDirectChat(req.UserID, req.CompanionID)
 	if err != nil {
 		return nil, err
 	}
+	return muc.getChatInfo(chatCreateInfo.ID)
+}
+
+func (muc *MessageUsecaseController) CreateGroupChat(req *models.GroupChatCreateRequest) (*models.ChatInfo, *models.MessageCreateInfo, error) {
+	memberIDs := make([]uint64, 0, len(req.MemberIDs))
+	seen := map[uint64]bool{req.CreatorID: true}
+	for _, memberID := range req.MemberIDs {
+		if seen[memberID] {
+			continue
+		}
+		seen[memberID] = true
+
+		if _, err := muc.userRepo.GetUserInfoPublic(memberID); err != nil {
+			return nil, nil, err
+		}
+		if err := muc.checkNotBlocked(req.CreatorID, memberID); err != nil {
+			return nil, nil, err
+		}
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	if !models.GroupChatMembersFit(len(memberIDs) + 1) {
+		return nil, nil, internal_errors.ErrChatMembersLimit
+	}
+
+	chat := &models.Chat{
+		Title:     &req.Title,
+		IsGroup:   true,
+		CreatorID: &req.CreatorID,
+	}
+	if req.AvatarUrl != "" {
+		chat.AvatarUrl = &req.AvatarUrl
+	}
+
+	// A chat without its members or the creation message is never left behind
+	chatCreateInfo, createdMessage, err := muc.mediaRepo.CreateGroupChat(chat, memberIDs,
+		systemMessage(0, req.CreatorID, models.ChatEventCreated, nil))
+	if err != nil {
+		return nil, nil, err
+	}
 	chatID := chatCreateInfo.ID
-	err = muc.mediaRepo.AddUserToChat(chatID, req.UserID)
+
+	chatInfo, err := muc.getChatInfo(chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chatInfo, createdMessage, nil
+}
+
+func (muc *MessageUsecaseController) GetChatInfo(userID, chatID uint64) (*models.ChatInfo, error) {
+	if _, err := muc.mediaRepo.GetChatMemberRole(chatID, userID); err != nil {
+		return nil, err
+	}
+
+	return muc.getChatInfo(chatID)
+}
+
+func (muc *MessageUsecaseController) UpdateChatInfo(req *models.ChatUpdateRequest) (*models.MessageCreateInfo, error) {
+	chat, err := muc.getGroupChatForAdmin(req.ChatID, req.UserID)
 	if err != nil {
 		return nil, err
 	}
-	err = muc.mediaRepo.AddUserToChat(chatID, req.CompanionID)
+
+	chat.Title = &req.Title
+	chat.AvatarUrl = nil
+	if req.AvatarUrl != "" {
+		chat.AvatarUrl = &req.AvatarUrl
+	}
+
+	if err := muc.mediaRepo.UpdateChatInfo(chat); err != nil {
+		return nil, err
+	}
+
+	return muc.addSystemMessage(req.ChatID, req.UserID, models.ChatEventInfoUpdated, nil)
+}
+
+func (muc *MessageUsecaseController) AddChatMember(actorID, chatID, userID uint64) (*models.MessageCreateInfo, error) {
+	var addedMessage *models.MessageCreateInfo
+	err := muc.updateGroupChatMembers(chatID, func(repo ChatMembersRepository) error {
+		if err := checkChatAdmin(repo, chatID, actorID); err != nil {
+			return err
+		}
+
+		_, err := repo.GetChatMemberRole(chatID, userID)
+		if err == nil {
+			return internal_errors.ErrUserIsAlreadyChatMember
+		}
+		if !errors.Is(err, internal_errors.ErrUserIsNotChatMember) {
+			return err
+		}
+
+		if _, err := muc.userRepo.GetUserInfoPublic(userID); err != nil {
+			return err
+		}
+
+		if err := muc.checkNotBlocked(actorID, userID); err != nil {
+			return err
+		}
+
+		members, err := repo.GetChatMembers(chatID)
+		if err != nil {
+			return err
+		}
+		if !models.GroupChatMembersFit(len(members) + 1) {
+			return internal_errors.ErrChatMembersLimit
+		}
+
+		if err := repo.AddUserToChat(chatID, userID, models.ChatRoleMember); err != nil {
+			return err
+		}
+
+		addedMessage, err = repo.CreateMessage(systemMessage(chatID, actorID, models.ChatEventMemberAdded, &userID))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	companionInfo, err := muc.userRepo.GetUserInfoPublic(req.CompanionID)
+	return addedMessage, nil
+}
+
+func (muc *MessageUsecaseController) RemoveChatMember(actorID, chatID, userID uint64) (*models.MessageCreateInfo, error) {
+	var removedMessage *models.MessageCreateInfo
+	err := muc.updateGroupChatMembers(chatID, func(repo ChatMembersRepository) error {
+		if err := checkChatAdmin(repo, chatID, actorID); err != nil {
+			return err
+		}
+
+		role, err := repo.GetChatMemberRole(chatID, userID)
+		if err != nil {
+			return err
+		}
+
+		// Admins can't kick each other, they have to be demoted first
+		if role == models.ChatRoleAdmin {
+			return internal_errors.ErrNotEnoughChatRights
+		}
+
+		if err := repo.RemoveUserFromChat(chatID, userID); err != nil {
+			return err
+		}
+
+		removedMessage, err = repo.CreateMessage(systemMessage(chatID, actorID, models.ChatEventMemberRemoved, &userID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removedMessage, nil
+}
+
+func (muc *MessageUsecaseController) LeaveChat(userID, chatID uint64) ([]*models.MessageCreateInfo, error) {
+	var systemMessages []*models.MessageCreateInfo
+	err := muc.updateGroupChatMembers(chatID, func(repo ChatMembersRepository) error {
+		role, err := repo.GetChatMemberRole(chatID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.RemoveUserFromChat(chatID, userID); err != nil {
+			return err
+		}
+
+		members, err := repo.GetChatMembers(chatID)
+		if err != nil {
+			return err
+		}
+
+		// Nobody left to talk to
+		if len(members) == 0 {
+			return repo.DeleteChat(chatID)
+		}
+
+		leftMessage, err := repo.CreateMessage(systemMessage(chatID, userID, models.ChatEventMemberLeft, &userID))
+		if err != nil {
+			return err
+		}
+		systemMessages = append(systemMessages, leftMessage)
+
+		if role != models.ChatRoleAdmin || hasChatAdmin(members) {
+			return nil
+		}
+
+		// The last admin has left, so the oldest member takes over the chat
+		successor := members[0]
+		if err := repo.UpdateChatMemberRole(chatID, successor.UserID, models.ChatRoleAdmin); err != nil {
+			return err
+		}
+
+		promotedMessage, err := repo.CreateMessage(systemMessage(chatID, userID, models.ChatEventAdminAdded, &successor.UserID))
+		if err != nil {
+			return err
+		}
+		systemMessages = append(systemMessages, promotedMessage)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return systemMessages, nil
+}
+
+func (muc *MessageUsecaseController) SetChatMemberRole(actorID, chatID, userID uint64, role string) (*models.MessageCreateInfo, error) {
+	var roleMessage *models.MessageCreateInfo
+	err := muc.updateGroupChatMembers(chatID, func(repo ChatMembersRepository) error {
+		if err := checkChatAdmin(repo, chatID, actorID); err != nil {
+			return err
+		}
+
+		currentRole, err := repo.GetChatMemberRole(chatID, userID)
+		if err != nil {
+			return err
+		}
+
+		if currentRole == role {
+			return nil
+		}
+
+		event := models.ChatEventAdminAdded
+		if role == models.ChatRoleMember {
+			event = models.ChatEventAdminRemoved
+
+			members, err := repo.GetChatMembers(chatID)
+			if err != nil {
+				return err
+			}
+
+			admins := 0
+			for _, member := range members {
+				if member.Role == models.ChatRoleAdmin {
+					admins++
+				}
+			}
+			if admins <= 1 {
+				return internal_errors.ErrLastChatAdmin
+			}
+		}
+
+		if err := repo.UpdateChatMemberRole(chatID, userID, role); err != nil {
+			return err
+		}
+
+		roleMessage, err = repo.CreateMessage(systemMessage(chatID, actorID, event, &userID))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &models.ChatInfo{ChatID: chatID, Companion: *companionInfo}, nil
+	return roleMessage, nil
 }
 
 func (muc *MessageUsecaseController) GetUserChats(userID uint64) ([]*models.ChatInfo, error) {
@@ -72,21 +563,155 @@ func (muc *MessageUsecaseController) GetUserChats(userID uint64) ([]*models.Chat
 	}
 	chats := make([]*models.ChatInfo, 0)
 	for _, chatID := range chatIDs {
-		chat := &models.ChatInfo{ChatID: chatID}
-		userIDs, err := muc.mediaRepo.GetChatUsers(chatID)
+		chat, err := muc.getChatInfo(chatID)
 		if err != nil {
 			return nil, err
 		}
-		for _, id := range userIDs {
-			if id != userID {
-				companion, err := muc.userRepo.GetUserInfoPublic(id)
-				if err != nil {
-					return nil, err
-				}
-				chat.Companion = *companion
-			}
-		}
 		chats = append(chats, chat)
 	}
 	return chats, nil
 }
+
+func (muc *MessageUsecaseController) getChatInfo(chatID uint64) (*models.ChatInfo, error) {
+	chat, err := muc.mediaRepo.GetChatByChatID(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := muc.mediaRepo.GetChatMembers(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ChatInfo{
+		ChatID:       chat.ChatID,
+		Title:        chat.Title,
+		AvatarUrl:    chat.AvatarUrl,
+		IsGroup:      chat.IsGroup,
+		Participants: members,
+	}, nil
+}
+
+func (muc *MessageUsecaseController) getGroupChatForAdmin(chatID, userID uint64) (*models.Chat, error) {
+	chat, err := muc.getGroupChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkChatAdmin(muc.mediaRepo, chatID, userID); err != nil {
+		return nil, err
+	}
+
+	return chat, nil
+}
+
+func (muc *MessageUsecaseController) getGroupChat(chatID uint64) (*models.Chat, error) {
+	chat, err := muc.mediaRepo.GetChatByChatID(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !chat.IsGroup {
+		return nil, internal_errors.ErrChatIsNotGroup
+	}
+
+	return chat, nil
+}
+
+// updateGroupChatMembers runs fn while the members of the group chat are locked,
+// so concurrent changes of the members can't break the checks of fn before its changes are written.
+func (muc *MessageUsecaseController) updateGroupChatMembers(chatID uint64, fn func(repo ChatMembersRepository) error) error {
+	if _, err := muc.getGroupChat(chatID); err != nil {
+		return err
+	}
+
+	return muc.mediaRepo.UpdateChatMembers(chatID, fn)
+}
+
+func checkChatAdmin(repo ChatMembersRepository, chatID, userID uint64) error {
+	role, err := repo.GetChatMemberRole(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role != models.ChatRoleAdmin {
+		return internal_errors.ErrNotEnoughChatRights
+	}
+
+	return nil
+}
+
+func (muc *MessageUsecaseController) addSystemMessage(chatID, actorID uint64, event string, targetID *uint64) (*models.MessageCreateInfo, error) {
+	return muc.mediaRepo.CreateMessage(systemMessage(chatID, actorID, event, targetID))
+}
+
+func systemMessage(chatID, actorID uint64, event string, targetID *uint64) *models.Message {
+	return &models.Message{
+		SenderID:  actorID,
+		ChatID:    chatID,
+		Content:   event,
+		Type:      models.MessageTypeSystem,
+		TargetID:  targetID,
+		CreatedAt: time.Now(),
+	}
+}
+
+// checkNotBlocked forbids any contact between users when either of them has blocked the other.
+func (muc *MessageUsecaseController) checkNotBlocked(userID, companionID uint64) error {
+	blocked, err := muc.userRepo.IsBlockedBetween(userID, companionID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return internal_errors.ErrUserIsBlocked
+	}
+	return nil
+}
+
+func (muc *MessageUsecaseController) checkDirectChatNotBlocked(chatID, senderID uint64) error {
+	chat, err := muc.mediaRepo.GetChatByChatID(chatID)
+	if err != nil {
+		return err
+	}
+	if chat.IsGroup {
+		return nil
+	}
+
+	chatUserIDs, err := muc.mediaRepo.GetChatUsers(chatID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range chatUserIDs {
+		if userID == senderID {
+			continue
+		}
+		if err := muc.checkNotBlocked(senderID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hideBlockedMessages drops text messages written by users the viewer has blocked.
+func (muc *MessageUsecaseController) hideBlockedMessages(viewerID uint64, messages []*models.MessageInfo) ([]*models.MessageInfo, error) {
+	blockedUserIDs, err := muc.userRepo.GetBlockedUserIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(blockedUserIDs) == 0 {
+		return messages, nil
+	}
+
+	return slices.DeleteFunc(messages, func(message *models.MessageInfo) bool {
+		return message.Type != models.MessageTypeSystem && slices.Contains(blockedUserIDs, message.SenderID)
+	}), nil
+}
+
+func hasChatAdmin(members []*models.ChatMember) bool {
+	for _, member := range members {
+		if member.Role == models.ChatRoleAdmin {
+			return true
+		}
+	}
+	return false
+}
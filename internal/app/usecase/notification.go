@@ -0,0 +1,17 @@
+package usecase
+
+import "pinset/internal/app/models"
+
+func (muc *MediaUsecaseController) GetNotifications(userID uint64) ([]*models.Notification, error) {
+	return muc.repo.GetNotifications(userID, models.MaxNotifications)
+}
+
+// pushNotification sends the stored notification to the recipient if they are online.
+// Offline users get it from the list of notifications.
+func (muc *MediaUsecaseController) pushNotification(notification *models.Notification) {
+	recipient := muc.userOnlineRepo.GetOnlineUser(notification.RecipientID)
+	if recipient == nil {
+		return
+	}
+	recipient.WriteJSON(models.WebSocketResponse{Type: models.WebSocketNotificationType, Data: notification})
+}
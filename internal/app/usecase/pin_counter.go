@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"fmt"
+	"pinset/configs"
+	"pinset/internal/app/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+type counterKey struct {
+	pinID  uint64
+	viewer string
+}
+
+type pinDay struct {
+	pinID uint64
+	day   time.Time
+}
+
+// PinCounter counts an event on a pin once per viewer within the dedup window
+// and buffers counted events in memory until they are flushed to the repository.
+// Viewers are remembered by the instance only, so each instance deduplicates its own requests.
+type PinCounter struct {
+	repo   UnitOfWork
+	params configs.PinCounterParams
+	// events names the counted events in errors
+	events string
+	write  func(repo TxRepository, pinID uint64, day time.Time, count uint64) error
+
+	mu      sync.Mutex
+	seen    map[counterKey]time.Time
+	pending map[pinDay]uint64
+}
+
+// NewViewCounter counts views of pins into their total and daily views.
+func NewViewCounter(repo UnitOfWork) *PinCounter {
+	return newPinCounter(repo, configs.NewViewCounterParams(), "views",
+		func(repo TxRepository, pinID uint64, day time.Time, count uint64) error {
+			return repo.AddPinViews(&models.PinViews{PinID: pinID, Day: day, Views: count})
+		})
+}
+
+// NewLinkClickCounter counts clicks on related links of pins into their daily clicks.
+func NewLinkClickCounter(repo UnitOfWork) *PinCounter {
+	return newPinCounter(repo, configs.NewLinkClickCounterParams(), "link clicks",
+		func(repo TxRepository, pinID uint64, day time.Time, count uint64) error {
+			return repo.AddPinLinkClicks(&models.PinLinkClicks{PinID: pinID, Day: day, Clicks: count})
+		})
+}
+
+func newPinCounter(repo UnitOfWork, params configs.PinCounterParams, events string,
+	write func(repo TxRepository, pinID uint64, day time.Time, count uint64) error) *PinCounter {
+	return &PinCounter{
+		repo:    repo,
+		params:  params,
+		events:  events,
+		write:   write,
+		seen:    make(map[counterKey]time.Time),
+		pending: make(map[pinDay]uint64),
+	}
+}
+
+func (pc *PinCounter) Interval() time.Duration {
+	return pc.params.FlushInterval
+}
+
+// Seen reports whether the event of the viewer has been counted within the dedup window.
+func (pc *PinCounter) Seen(pinID uint64, viewer string) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	countedAt, ok := pc.seen[counterKey{pinID, viewer}]
+	return ok && time.Since(countedAt) < pc.params.DedupWindow
+}
+
+// Record counts the event unless the viewer has been counted within the dedup window.
+// When too many viewers are remembered, events of new viewers are not counted,
+// so a flood of fingerprints cannot exhaust memory.
+func (pc *PinCounter) Record(pinID uint64, viewer string) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	now := time.Now()
+	key := counterKey{pinID, viewer}
+	if countedAt, ok := pc.seen[key]; ok && now.Sub(countedAt) < pc.params.DedupWindow {
+		return false
+	}
+	if len(pc.seen) >= pc.params.MaxTracked {
+		pc.forgetExpired(now)
+		if len(pc.seen) >= pc.params.MaxTracked {
+			return false
+		}
+	}
+
+	pc.seen[key] = now
+	pc.pending[pinDay{pinID, models.ViewDay(now)}]++
+	return true
+}
+
+// forgetExpired must be called with the mutex held.
+func (pc *PinCounter) forgetExpired(now time.Time) {
+	for key, countedAt := range pc.seen {
+		if now.Sub(countedAt) >= pc.params.DedupWindow {
+			delete(pc.seen, key)
+		}
+	}
+}
+
+// Flush writes the buffered events in one unit of work and returns their number.
+// Events that failed to be written are buffered again and retried by the next flush.
+func (pc *PinCounter) Flush() (uint64, error) {
+	pc.mu.Lock()
+	pending := pc.pending
+	pc.pending = make(map[pinDay]uint64)
+	pc.forgetExpired(time.Now())
+	pc.mu.Unlock()
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	// Pins are written in the order of IDs, so concurrent flushes of several instances do not deadlock
+	keys := make([]pinDay, 0, len(pending))
+	var total uint64
+	for key, count := range pending {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pinID != keys[j].pinID {
+			return keys[i].pinID < keys[j].pinID
+		}
+		return keys[i].day.Before(keys[j].day)
+	})
+
+	err := pc.repo.RunInTx(func(repo TxRepository) error {
+		for _, key := range keys {
+			if err := pc.write(repo, key.pinID, key.day, pending[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		pc.mu.Lock()
+		for key, count := range pending {
+			pc.pending[key] += count
+		}
+		pc.mu.Unlock()
+		return 0, fmt.Errorf("flush %s of %d pins: %w", pc.events, len(keys), err)
+	}
+	return total, nil
+}
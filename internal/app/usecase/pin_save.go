@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+	"slices"
+)
+
+// SavePin saves a published pin to a board of the user and notifies the author.
+func (muc *MediaUsecaseController) SavePin(req *models.PinSaveRequest) (*models.PinSave, error) {
+	pin, err := muc.visiblePin(req.PinID, req.SaverID)
+	if err != nil {
+		return nil, err
+	}
+	if pin.Status != models.PinStatusPublished {
+		return nil, internal_errors.ErrPinDoesntExists
+	}
+
+	board, err := muc.repo.GetBoardByBoardID(req.BoardID)
+	if err != nil {
+		return nil, err
+	}
+	if board.OwnerID != req.SaverID {
+		return nil, internal_errors.ErrBoardDoesntExists
+	}
+
+	save := &models.PinSave{
+		PinID:   pin.PinID,
+		BoardID: board.BoardID,
+		SaverID: req.SaverID,
+	}
+	if req.FromBoardID != 0 && req.FromBoardID != board.BoardID {
+		fromBoard, err := muc.GetBoard(req.FromBoardID, req.SaverID)
+		if err != nil {
+			return nil, err
+		}
+		saved, err := muc.repo.IsPinInBoard(fromBoard.BoardID, pin.PinID)
+		if err != nil {
+			return nil, err
+		}
+		if !saved {
+			return nil, internal_errors.ErrPinNotInBoard
+		}
+		save.FromBoardID = &fromBoard.BoardID
+		save.FromUserID = &fromBoard.OwnerID
+	}
+
+	notification, err := muc.pinSavedNotification(pin, board, req.SaverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := muc.repo.SavePin(save, notification); err != nil {
+		return nil, err
+	}
+	if notification != nil {
+		muc.pushNotification(notification)
+	}
+	return save, nil
+}
+
+// pinSavedNotification returns nil when the author saves their own pin or the users block each other.
+func (muc *MediaUsecaseController) pinSavedNotification(pin *models.Pin, board *models.Board, saverID uint64) (*models.Notification, error) {
+	if pin.AuthorID == saverID {
+		return nil, nil
+	}
+	blocked, err := muc.userRepo.IsBlockedBetween(pin.AuthorID, saverID)
+	if err != nil || blocked {
+		return nil, err
+	}
+
+	saver, err := muc.repo.GetPinAuthorNickNameByUserID(saverID)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := &models.Notification{
+		RecipientID:    pin.AuthorID,
+		ActorID:        saverID,
+		ActorNickName:  saver.NickName,
+		ActorAvatarUrl: saver.AvatarUrl,
+		Type:           models.NotificationTypePinSaved,
+		PinID:          pin.PinID,
+	}
+	if board.Public {
+		notification.BoardID = &board.BoardID
+	}
+	return notification, nil
+}
+
+// GetPinSavedFrom returns the attribution of the pin saved to the board, visible to the viewer.
+func (muc *MediaUsecaseController) GetPinSavedFrom(pinID, boardID, currUserID uint64) ([]*models.PinSaveLink, error) {
+	if _, err := muc.GetBoard(boardID, currUserID); err != nil {
+		return nil, err
+	}
+
+	links, err := muc.repo.GetPinSavedFrom(pinID, boardID, models.MaxSavedFromDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedUsers, err := blockedUsersSet(muc.userRepo, currUserID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(links, func(link *models.PinSaveLink) bool {
+		return blockedUsers[link.UserID]
+	}), nil
+}
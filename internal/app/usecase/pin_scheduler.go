@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"pinset/configs"
+	"time"
+)
+
+// PinScheduler publishes scheduled pins when their time comes.
+// Every instance of the server runs one, a pin is published by exactly one of them.
+type PinScheduler struct {
+	repo   MediaRepository
+	params configs.PinSchedulerParams
+}
+
+func NewPinScheduler(repo MediaRepository) *PinScheduler {
+	return &PinScheduler{
+		repo:   repo,
+		params: configs.NewPinSchedulerParams(),
+	}
+}
+
+func (ps *PinScheduler) Interval() time.Duration {
+	return ps.params.Interval
+}
+
+// PublishDue publishes all due pins in batches and returns the IDs of the published ones.
+func (ps *PinScheduler) PublishDue() ([]uint64, error) {
+	var published []uint64
+	for {
+		pinIDs, err := ps.repo.PublishDuePins(ps.params.BatchSize)
+		published = append(published, pinIDs...)
+		if err != nil {
+			return published, err
+		}
+		// Pins locked by another instance are left to it
+		if len(pinIDs) < ps.params.BatchSize {
+			return published, nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/mediaurl"
+)
+
+// PlaceholderBackfill computes placeholders of images uploaded before they were computed on upload.
+type PlaceholderBackfill struct {
+	repo      MediaRepository
+	batchSize int
+}
+
+// BackfillResult counts the images of a backfill run
+type BackfillResult struct {
+	Updated int
+	// Failed images can't be read or decoded, they are left without a placeholder
+	Failed int
+}
+
+func NewPlaceholderBackfill(repo MediaRepository, batchSize int) *PlaceholderBackfill {
+	return &PlaceholderBackfill{
+		repo:      repo,
+		batchSize: batchSize,
+	}
+}
+
+// Run goes through all registered images without a placeholder once,
+// then through image keys shown by pins and carousels without a placeholder, registered or not.
+// Images that failed are skipped, so a run always ends; running it again retries them.
+func (pb *PlaceholderBackfill) Run() (BackfillResult, error) {
+	var result BackfillResult
+	var errs []error
+
+	if err := pb.runRegistry(&result, &errs); err != nil {
+		return result, errors.Join(append(errs, err)...)
+	}
+	if err := pb.runImageKeys(&result, &errs); err != nil {
+		return result, errors.Join(append(errs, err)...)
+	}
+	return result, errors.Join(errs...)
+}
+
+func (pb *PlaceholderBackfill) runRegistry(result *BackfillResult, errs *[]error) error {
+	var lastMediaID uint64
+	for {
+		mediaList, err := pb.repo.GetMediaWithoutPlaceholder(lastMediaID, pb.batchSize)
+		if err != nil {
+			return fmt.Errorf("backfill placeholders: %w", err)
+		}
+		if len(mediaList) == 0 {
+			return nil
+		}
+
+		for _, media := range mediaList {
+			lastMediaID = media.MediaID
+			err := pb.backfill(media.BucketName, media.ObjectKey, func(placeholder imageproc.Placeholder, _, _ int) error {
+				return pb.repo.UpdateMediaPlaceholder(media.MediaID, placeholder)
+			})
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("media %d: %w", media.MediaID, err))
+				result.Failed++
+				continue
+			}
+			result.Updated++
+		}
+	}
+}
+
+// runImageKeys covers pins created before the media registry, their keys have no registry entry
+func (pb *PlaceholderBackfill) runImageKeys(result *BackfillResult, errs *[]error) error {
+	var lastMediaKey string
+	for {
+		mediaKeys, err := pb.repo.GetImageKeysWithoutPlaceholder(lastMediaKey, pb.batchSize)
+		if err != nil {
+			return fmt.Errorf("backfill image key placeholders: %w", err)
+		}
+		if len(mediaKeys) == 0 {
+			return nil
+		}
+
+		for _, mediaKey := range mediaKeys {
+			lastMediaKey = mediaKey
+			bucketName, objectKey, ok := mediaurl.SplitKey(mediaKey)
+			if !ok {
+				*errs = append(*errs, fmt.Errorf("media key %s: not a key of a stored object", mediaKey))
+				result.Failed++
+				continue
+			}
+			err := pb.backfill(bucketName, objectKey, func(placeholder imageproc.Placeholder, width, height int) error {
+				return pb.repo.UpdateImageKeyPlaceholder(mediaKey, width, height, placeholder)
+			})
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("media key %s: %w", mediaKey, err))
+				result.Failed++
+				continue
+			}
+			result.Updated++
+		}
+	}
+}
+
+func (pb *PlaceholderBackfill) backfill(bucketName, objectKey string, update func(placeholder imageproc.Placeholder, width, height int) error) error {
+	object, err := pb.repo.OpenObject(bucketName, objectKey)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+	placeholder, width, height, err := imageproc.DecodePlaceholder(data)
+	if err != nil {
+		return err
+	}
+	return update(placeholder, width, height)
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+)
+
+// ConnectUser marks the user online and refreshes last_seen.
+func (muc *MessageUsecaseController) ConnectUser(user *models.ChatUser) error {
+	muc.userOnlineRepo.AddOnlineUser(user)
+	return muc.userRepo.UpdateUserLastSeen(user.ID)
+}
+
+// DisconnectUser marks the user offline if the connection is still the active one.
+// wentOffline is false when the user has already reconnected with another connection.
+func (muc *MessageUsecaseController) DisconnectUser(user *models.ChatUser) (wentOffline bool, err error) {
+	if !muc.userOnlineRepo.DeleteOnlineUserConnection(user) {
+		return false, nil
+	}
+	return true, muc.userRepo.UpdateUserLastSeen(user.ID)
+}
+
+// GetPresenceReceivers returns the current presence of the user and the online companions
+// who should be notified about it. Presence is nil when the user hides it.
+func (muc *MessageUsecaseController) GetPresenceReceivers(userID uint64) (*models.Presence, []uint64, error) {
+	presenceInfo, err := muc.userRepo.GetUsersPresenceInfo([]uint64{userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(presenceInfo) == 0 || presenceInfo[0].HidePresence {
+		return nil, nil, nil
+	}
+
+	companionIDs, err := muc.mediaRepo.GetUserCompanions(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receiverIDs := make([]uint64, 0, len(companionIDs))
+	for _, companionID := range companionIDs {
+		if muc.userOnlineRepo.IsOnlineUser(companionID) {
+			receiverIDs = append(receiverIDs, companionID)
+		}
+	}
+
+	return muc.buildPresence(userID, presenceInfo[0]), receiverIDs, nil
+}
+
+// GetUsersPresence returns presence of the requested users in the order of userIDs.
+// Unknown users are skipped, users hiding presence are shown offline to everyone except themselves.
+func (muc *MessageUsecaseController) GetUsersPresence(viewerID uint64, userIDs []uint64) ([]*models.Presence, error) {
+	presenceInfo, err := muc.userRepo.GetUsersPresenceInfo(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	infoByUserID := make(map[uint64]*models.UserPresenceInfo, len(presenceInfo))
+	for _, info := range presenceInfo {
+		infoByUserID[info.UserID] = info
+	}
+
+	presences := make([]*models.Presence, 0, len(presenceInfo))
+	for _, userID := range userIDs {
+		info, ok := infoByUserID[userID]
+		if !ok {
+			continue
+		}
+		// Duplicated IDs are answered once
+		delete(infoByUserID, userID)
+
+		if info.HidePresence && userID != viewerID {
+			presences = append(presences, &models.Presence{UserID: userID})
+			continue
+		}
+		presences = append(presences, muc.buildPresence(userID, info))
+	}
+
+	return presences, nil
+}
+
+func (muc *MessageUsecaseController) UpdatePresenceSettings(userID uint64, settings *models.PresenceSettings) error {
+	return muc.userRepo.UpdateUserPresenceSettings(userID, settings)
+}
+
+func (muc *MessageUsecaseController) buildPresence(userID uint64, info *models.UserPresenceInfo) *models.Presence {
+	return &models.Presence{
+		UserID:   userID,
+		Online:   muc.userOnlineRepo.IsOnlineUser(userID),
+		LastSeen: info.LastSeen,
+	}
+}
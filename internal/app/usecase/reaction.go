@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"pinset/internal/app/models"
+	internal_errors "pinset/internal/errors"
+)
+
+// TogglePinReaction toggles the reaction of the user on a published pin and returns the updated reactions.
+func (muc *MediaUsecaseController) TogglePinReaction(pinID, userID uint64, req *models.PinReactionRequest) (*models.PinReactions, error) {
+	pin, err := muc.visiblePin(pinID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pin.Status != models.PinStatusPublished {
+		return nil, internal_errors.ErrPinDoesntExists
+	}
+
+	if _, err := muc.repo.TogglePinReaction(pin.PinID, userID, req.Type); err != nil {
+		return nil, err
+	}
+
+	reactions, err := muc.repo.GetPinsReactions([]uint64{pin.PinID}, userID)
+	if err != nil {
+		return nil, err
+	}
+	return reactions[pin.PinID], nil
+}
+
+// fillPinViewerState sets reactions of the pins and whether the viewer has bookmarked them.
+func (muc *MediaUsecaseController) fillPinViewerState(pins []*models.Pin, viewerID uint64) error {
+	if err := muc.fillPinReactions(pins, viewerID); err != nil {
+		return err
+	}
+
+	pinIDs := make([]uint64, 0, len(pins))
+	for _, pin := range pins {
+		pinIDs = append(pinIDs, pin.PinID)
+	}
+	bookmarked, err := muc.repo.GetBookmarkedPinIDs(viewerID, pinIDs)
+	if err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		pin.IsBookmarked = bookmarked[pin.PinID]
+	}
+	return nil
+}
+
+// fillPinReactions sets reaction counts of the pins and the reactions of the viewer.
+func (muc *MediaUsecaseController) fillPinReactions(pins []*models.Pin, viewerID uint64) error {
+	pinIDs := make([]uint64, 0, len(pins))
+	for _, pin := range pins {
+		pinIDs = append(pinIDs, pin.PinID)
+	}
+
+	reactions, err := muc.repo.GetPinsReactions(pinIDs, viewerID)
+	if err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		pin.PinReactions = *reactions[pin.PinID]
+	}
+	return nil
+}
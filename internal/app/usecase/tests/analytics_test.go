@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAnalyticsRepository returns the given daily stats of active days and follower snapshots.
+type fakeAnalyticsRepository struct {
+	*fakePinRepository
+
+	days      []*models.DailyPinStats
+	snapshots []*models.FollowerSnapshot
+}
+
+func (r *fakeAnalyticsRepository) GetPinStatsSeries(pinID uint64, statsRange models.AnalyticsRange) ([]*models.DailyPinStats, error) {
+	return r.days, nil
+}
+
+func (r *fakeAnalyticsRepository) GetTopPins(params *models.TopPinsParams) ([]*models.TopPin, error) {
+	return nil, nil
+}
+
+// GetFollowerSnapshots returns the snapshots up to the end of the range, the last one before the range included
+func (r *fakeAnalyticsRepository) GetFollowerSnapshots(userID uint64, statsRange models.AnalyticsRange) ([]*models.FollowerSnapshot, error) {
+	var snapshots []*models.FollowerSnapshot
+	for _, snapshot := range r.snapshots {
+		if !snapshot.Day.After(statsRange.To) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+// lastDays is the range of the days ending today, it returns the formatted days of the range
+func lastDays(days int) (models.AnalyticsRange, []string) {
+	statsRange := models.NewAnalyticsRange(days)
+	var formatted []string
+	for day := statsRange.From; !day.After(statsRange.To); day = day.AddDate(0, 0, 1) {
+		formatted = append(formatted, day.Format(models.AnalyticsDayLayout))
+	}
+	return statsRange, formatted
+}
+
+func TestGetPinAnalytics(t *testing.T) {
+	statsRange, days := lastDays(4)
+	repo := &fakeAnalyticsRepository{
+		fakePinRepository: newFakePinRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished}),
+		days: []*models.DailyPinStats{
+			{Day: days[1], PinStats: models.PinStats{Views: 10, Bookmarks: 1}},
+			{Day: days[3], PinStats: models.PinStats{Views: 5, LinkClicks: 2}},
+		},
+	}
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	analytics, err := muc.GetPinAnalytics(1, 1, statsRange)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, days[0], analytics.From)
+	assert.Equal(t, days[3], analytics.To)
+	assert.Equal(t, models.PinStats{Views: 15, Bookmarks: 1, LinkClicks: 2}, analytics.Totals)
+	if assert.Len(t, analytics.Series, 4, "days without activity are included") {
+		for i, day := range analytics.Series {
+			assert.Equal(t, days[i], day.Day)
+		}
+		assert.Equal(t, models.PinStats{}, analytics.Series[0].PinStats)
+		assert.Equal(t, uint64(10), analytics.Series[1].Views)
+	}
+
+	_, err = muc.GetPinAnalytics(1, 2, statsRange)
+	assert.ErrorIs(t, err, internal_errors.ErrAnalyticsNotAuthor)
+	_, err = muc.GetPinAnalytics(1, 1, models.AnalyticsRange{From: statsRange.To, To: statsRange.From})
+	assert.ErrorIs(t, err, internal_errors.ErrAnalyticsParamsInvalid)
+}
+
+func TestGetFollowerGrowth(t *testing.T) {
+	statsRange, days := lastDays(5)
+	day := func(i int) time.Time {
+		return statsRange.From.AddDate(0, 0, i)
+	}
+	repo := &fakeAnalyticsRepository{
+		fakePinRepository: newFakePinRepository(),
+		snapshots: []*models.FollowerSnapshot{
+			{Day: day(-3), Followers: 10},
+			{Day: day(1), Followers: 14},
+			{Day: day(2), Followers: 12},
+			{Day: day(4), Followers: 20},
+		},
+	}
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	growth, err := muc.GetFollowerGrowth(1, statsRange)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(10), growth.Growth, "from the last snapshot before the range")
+	assert.Equal(t, []*models.DailyFollowers{
+		{Day: days[0], Followers: 10, Change: 0},
+		{Day: days[1], Followers: 14, Change: 4},
+		{Day: days[2], Followers: 12, Change: -2},
+		{Day: days[3], Followers: 12, Change: 0},
+		{Day: days[4], Followers: 20, Change: 8},
+	}, growth.Series)
+
+	repo.snapshots = nil
+	growth, err = muc.GetFollowerGrowth(1, statsRange)
+	if assert.NoError(t, err) && assert.Len(t, growth.Series, 5) {
+		assert.Zero(t, growth.Growth, "users without followers have a flat series")
+		assert.Zero(t, growth.Series[4].Followers)
+	}
+}
+
+func TestGetTopPins(t *testing.T) {
+	repo := &fakeAnalyticsRepository{fakePinRepository: newFakePinRepository()}
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+	params := &models.TopPinsParams{
+		AuthorID: 1,
+		Range:    models.NewAnalyticsRange(models.DefaultAnalyticsRangeDays),
+		Metric:   models.AnalyticsMetricViews,
+		Limit:    models.DefaultTopPins,
+	}
+
+	pins, err := muc.GetTopPins(params)
+	assert.NoError(t, err)
+	assert.NotNil(t, pins, "no pins is an empty list, not null")
+	assert.Empty(t, pins)
+
+	params.Metric = "likes"
+	_, err = muc.GetTopPins(params)
+	assert.ErrorIs(t, err, internal_errors.ErrAnalyticsMetricInvalid)
+}
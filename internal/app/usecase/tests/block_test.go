@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockUserRejectsYourself(t *testing.T) {
+	uuc := usecase.NewUserUsecase(newFakeUserRepository(1), nil)
+	assert.ErrorIs(t, uuc.BlockUser(1, 1), internal_errors.ErrCantBlockYourself)
+}
+
+func TestBlockedAuthorsAreHidden(t *testing.T) {
+	users := newFakeUserRepository(1, 2, 3)
+	users.Block(1, 2)
+	pins := newFakePinRepository(
+		&models.Pin{PinID: 1, AuthorID: 2, Status: models.PinStatusPublished},
+		&models.Pin{PinID: 2, AuthorID: 3, Status: models.PinStatusPublished},
+	)
+	pins.comments[2] = []*models.Comment{
+		{CommentID: 1, PinID: 2, AuthorID: 2},
+		{CommentID: 2, PinID: 2, AuthorID: 3},
+	}
+	muc := usecase.NewMediaUsecase(pins, users, nil, nil, nil)
+
+	_, err := muc.GetPinPreviewInfo(1, 1)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists, "pins of blocked users look missing")
+	_, err = muc.GetPinPageInfo(1, 1)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists)
+
+	pin, err := muc.GetPinPreviewInfo(1, 3)
+	assert.NoError(t, err, "other users still see the pin")
+	if assert.NotNil(t, pin) {
+		assert.Equal(t, uint64(1), pin.PinID)
+	}
+
+	comments, err := muc.GetAllCommentaries(2, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 1) {
+		assert.Equal(t, uint64(3), comments[0].AuthorID)
+	}
+
+	comments, err = muc.GetAllCommentaries(2, 0)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2, "anonymous viewers have nobody blocked")
+}
+
+func TestAddChatMessageToBlockedCompanion(t *testing.T) {
+	users := newFakeUserRepository(1, 2, 3)
+	muc := usecase.NewMessageUsecase(nil, newFakeChatRepository(), users)
+
+	direct, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: 2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	group, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2, 3}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	users.Block(2, 1)
+
+	_, err = muc.AddChatMessage(&models.Message{SenderID: 1, ChatID: direct.ChatID, Content: "hello"})
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsBlocked)
+	_, err = muc.AddChatMessage(&models.Message{SenderID: 2, ChatID: direct.ChatID, Content: "hello"})
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsBlocked, "the blocker can't write either")
+
+	_, err = muc.AddChatMessage(&models.Message{SenderID: 1, ChatID: group.ChatID, Content: "hello"})
+	assert.NoError(t, err, "group chats are not closed by a block")
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"errors"
+	"maps"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"slices"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bookmarkKey struct {
+	ownerID, pinID uint64
+}
+
+// fakeBookmarkRepository keeps bookmarks and bookmark counters of pins,
+// units of work change copies of them that are kept only when the unit of work succeeds.
+type fakeBookmarkRepository struct {
+	*fakePinRepository
+
+	bookmarks map[bookmarkKey]bool
+	counters  map[uint64]uint64
+	// failCounters fails updates of counters within units of work
+	failCounters error
+	txCount      int
+}
+
+func newFakeBookmarkRepository(pins ...*models.Pin) *fakeBookmarkRepository {
+	repo := &fakeBookmarkRepository{
+		fakePinRepository: newFakePinRepository(pins...),
+		bookmarks:         make(map[bookmarkKey]bool),
+		counters:          make(map[uint64]uint64),
+	}
+	for _, pin := range pins {
+		repo.counters[pin.PinID] = 0
+	}
+	return repo
+}
+
+func (r *fakeBookmarkRepository) RunInTx(fn func(repo usecase.TxRepository) error) error {
+	r.txCount++
+	tx := &fakeBookmarkTx{
+		repo:      r,
+		bookmarks: maps.Clone(r.bookmarks),
+		counters:  maps.Clone(r.counters),
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.bookmarks, r.counters = tx.bookmarks, tx.counters
+	return nil
+}
+
+type fakeBookmarkTx struct {
+	usecase.TxRepository
+
+	repo      *fakeBookmarkRepository
+	bookmarks map[bookmarkKey]bool
+	counters  map[uint64]uint64
+}
+
+func (tx *fakeBookmarkTx) CreatePinBookmark(bookmark *models.Bookmark) (bool, error) {
+	key := bookmarkKey{bookmark.OwnerID, bookmark.PinID}
+	if tx.bookmarks[key] {
+		return false, nil
+	}
+	tx.bookmarks[key] = true
+	return true, nil
+}
+
+func (tx *fakeBookmarkTx) DeletePinBookmark(ownerID, pinID uint64) (bool, error) {
+	key := bookmarkKey{ownerID, pinID}
+	if !tx.bookmarks[key] {
+		return false, nil
+	}
+	delete(tx.bookmarks, key)
+	return true, nil
+}
+
+func (tx *fakeBookmarkTx) UpdateBookmarksCountIncrease(pinID uint64) error {
+	if tx.repo.failCounters != nil {
+		return tx.repo.failCounters
+	}
+	tx.counters[pinID]++
+	return nil
+}
+
+func (tx *fakeBookmarkTx) UpdateBookmarksCountDecrease(pinID uint64) error {
+	if tx.repo.failCounters != nil {
+		return tx.repo.failCounters
+	}
+	tx.counters[pinID]--
+	return nil
+}
+
+func (tx *fakeBookmarkTx) LockPinCounters(afterPinID uint64, limit int) ([]uint64, error) {
+	var pinIDs []uint64
+	for pinID := range tx.counters {
+		if pinID > afterPinID {
+			pinIDs = append(pinIDs, pinID)
+		}
+	}
+	slices.Sort(pinIDs)
+	return pinIDs[:min(limit, len(pinIDs))], nil
+}
+
+func (tx *fakeBookmarkTx) RecountPinBookmarks(pinIDs []uint64) (int, error) {
+	var fixed int
+	for _, pinID := range pinIDs {
+		var count uint64
+		for key := range tx.bookmarks {
+			if key.pinID == pinID {
+				count++
+			}
+		}
+		if tx.counters[pinID] != count {
+			tx.counters[pinID] = count
+			fixed++
+		}
+	}
+	return fixed, nil
+}
+
+func TestPinBookmarksAreIdempotent(t *testing.T) {
+	repo := newFakeBookmarkRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished})
+	muc := usecase.NewMediaUsecase(repo, newFakeUserRepository(1, 2, 3), nil, nil, nil)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, muc.CreatePinBookmark(&models.Bookmark{OwnerID: 2, PinID: 1}))
+	}
+	assert.NoError(t, muc.CreatePinBookmark(&models.Bookmark{OwnerID: 3, PinID: 1}))
+	assert.Equal(t, uint64(2), repo.counters[1], "a repeated bookmark is counted once")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, muc.DeletePinBookmarkByOwnerIDAndPinID(models.Bookmark{OwnerID: 2, PinID: 1}))
+	}
+	assert.Equal(t, uint64(1), repo.counters[1], "a missing bookmark is not uncounted")
+	assert.Equal(t, map[bookmarkKey]bool{{3, 1}: true}, repo.bookmarks)
+}
+
+func TestPinBookmarkRollsBackWithCounter(t *testing.T) {
+	repo := newFakeBookmarkRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished})
+	muc := usecase.NewMediaUsecase(repo, newFakeUserRepository(1, 2), nil, nil, nil)
+	assert.NoError(t, muc.CreatePinBookmark(&models.Bookmark{OwnerID: 1, PinID: 1}))
+
+	repo.failCounters = errors.New("connection reset")
+	assert.ErrorIs(t, muc.CreatePinBookmark(&models.Bookmark{OwnerID: 2, PinID: 1}), repo.failCounters)
+	assert.ErrorIs(t, muc.DeletePinBookmarkByOwnerIDAndPinID(models.Bookmark{OwnerID: 1, PinID: 1}), repo.failCounters)
+
+	assert.Equal(t, map[bookmarkKey]bool{{1, 1}: true}, repo.bookmarks, "bookmarks are kept in sync with the counter")
+	assert.Equal(t, uint64(1), repo.counters[1])
+}
+
+func TestPinBookmarkOfHiddenPin(t *testing.T) {
+	repo := newFakeBookmarkRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusDraft})
+	muc := usecase.NewMediaUsecase(repo, newFakeUserRepository(1, 2), nil, nil, nil)
+
+	assert.ErrorIs(t, muc.CreatePinBookmark(&models.Bookmark{OwnerID: 2, PinID: 1}), internal_errors.ErrPinDoesntExists)
+	assert.Zero(t, repo.txCount)
+}
+
+func TestBookmarkReconciler(t *testing.T) {
+	t.Setenv("BOOKMARK_RECONCILE_BATCH_SIZE", "2")
+
+	repo := newFakeBookmarkRepository()
+	for pinID := uint64(1); pinID <= 5; pinID++ {
+		repo.counters[pinID] = 1
+	}
+	repo.counters[1] = 3
+	repo.counters[4] = 0
+	for _, key := range []bookmarkKey{{1, 1}, {1, 2}, {1, 3}, {2, 3}, {1, 4}, {2, 5}} {
+		repo.bookmarks[key] = true
+	}
+
+	fixed, err := usecase.NewBookmarkReconciler(repo).Reconcile()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fixed, "pins 1, 3 and 4 have wrong counters")
+	assert.Equal(t, map[uint64]uint64{1: 1, 2: 1, 3: 2, 4: 1, 5: 1}, repo.counters)
+	assert.Equal(t, 3, repo.txCount, "the counters are recounted in batches")
+
+	fixed, err = usecase.NewBookmarkReconciler(repo).Reconcile()
+	assert.NoError(t, err)
+	assert.Zero(t, fixed)
+}
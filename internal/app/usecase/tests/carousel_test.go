@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func carouselItem(mediaUrl string) *models.PinMedia {
+	return &models.PinMedia{MediaUrl: &mediaUrl}
+}
+
+func TestCreateCarouselPin(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	pin := &models.Pin{
+		AuthorID: 1,
+		Carousel: []*models.PinMedia{
+			carouselItem("images/first.png"),
+			carouselItem("videos/second.mp4"),
+			carouselItem("https://example.com/third.jpg"),
+		},
+	}
+	if !assert.NoError(t, muc.CreatePin(pin)) {
+		return
+	}
+	if assert.Len(t, repo.pins, 1) {
+		created := repo.pins[0]
+		if assert.NotNil(t, created.MediaUrl) {
+			assert.Equal(t, "images/first.png", *created.MediaUrl, "the first item is the cover")
+		}
+		assert.Equal(t, models.PinStatusPublished, created.Status)
+	}
+}
+
+func TestCreateCarouselPinRejectsItems(t *testing.T) {
+	useMemoryBackend(t)
+
+	tooMany := make([]*models.PinMedia, models.MaxCarouselItems+1)
+	for i := range tooMany {
+		tooMany[i] = carouselItem("images/item.png")
+	}
+
+	tests := []struct {
+		name     string
+		carousel []*models.PinMedia
+		err      error
+	}{
+		{name: "too many items", carousel: tooMany, err: internal_errors.ErrTooManyCarouselItems},
+		{name: "audio item", carousel: []*models.PinMedia{carouselItem("images/a.png"), carouselItem("audios/b.mp3")}, err: internal_errors.ErrWrongMediaContentType},
+		{name: "missing item", carousel: []*models.PinMedia{carouselItem("images/a.png"), nil}, err: internal_errors.ErrPinDataInvalid},
+		{name: "item without media", carousel: []*models.PinMedia{{}}, err: internal_errors.ErrPinDataInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeMediaRepository(t)
+			muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+			err := muc.CreatePin(&models.Pin{AuthorID: 1, Carousel: tt.carousel})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Empty(t, repo.pins)
+		})
+	}
+}
+
+func TestCreateCarouselPinFromUploads(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	mediaKey := "images/uploaded.png"
+	completed := &models.Upload{UserID: 1, ContentType: "image/png", Size: 1}
+	pending := &models.Upload{UserID: 1, ContentType: "image/png", Size: 1}
+	for _, upload := range []*models.Upload{completed, pending} {
+		if err := repo.CreateUpload(upload); err != nil {
+			t.Fatalf("create upload: %s", err)
+		}
+	}
+	completed.MediaKey = &mediaKey
+	if err := repo.CompleteUpload(completed); err != nil {
+		t.Fatalf("complete upload: %s", err)
+	}
+
+	err := muc.CreatePin(&models.Pin{AuthorID: 2, Carousel: []*models.PinMedia{{UploadID: &completed.UploadID}}})
+	assert.ErrorIs(t, err, internal_errors.ErrUploadDoesntExists, "uploads of other users can't be used")
+
+	err = muc.CreatePin(&models.Pin{AuthorID: 1, Carousel: []*models.PinMedia{{UploadID: &pending.UploadID}}})
+	assert.ErrorIs(t, err, internal_errors.ErrUploadNotCompleted)
+
+	pin := &models.Pin{AuthorID: 1, Carousel: []*models.PinMedia{carouselItem("images/a.png"), {UploadID: &completed.UploadID}}}
+	if assert.NoError(t, muc.CreatePin(pin)) {
+		assert.Equal(t, &mediaKey, pin.Carousel[1].MediaUrl, "the item takes the media of the upload")
+	}
+}
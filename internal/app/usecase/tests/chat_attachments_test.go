@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"pinset/internal/app/delivery/http"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAttachmentRepository adds pins, boards and uploaded images to the chats
+type fakeAttachmentRepository struct {
+	*fakeChatRepository
+
+	pins   map[uint64]*models.Pin
+	boards map[uint64]*models.Board
+	images map[string]bool
+}
+
+func (r *fakeAttachmentRepository) GetPinPreviewInfoByPinID(pinID uint64) (*models.Pin, error) {
+	pin, ok := r.pins[pinID]
+	if !ok {
+		return nil, internal_errors.ErrPinDoesntExists
+	}
+	preview := *pin
+	return &preview, nil
+}
+
+func (r *fakeAttachmentRepository) GetBoardByBoardID(boardID uint64) (*models.Board, error) {
+	board, ok := r.boards[boardID]
+	if !ok {
+		return nil, internal_errors.ErrBoardDoesntExists
+	}
+	return board, nil
+}
+
+func (r *fakeAttachmentRepository) IsImageMediaUrl(mediaUrl string) bool {
+	return r.images[mediaUrl]
+}
+
+func newAttachmentsChat(t *testing.T, users *fakeUserRepository) (delivery.MessageUsecase, uint64) {
+	t.Helper()
+
+	repo := &fakeAttachmentRepository{
+		fakeChatRepository: newFakeChatRepository(),
+		pins: map[uint64]*models.Pin{
+			1: {PinID: 1, AuthorID: 3, Status: models.PinStatusPublished},
+			2: {PinID: 2, AuthorID: 3, Status: models.PinStatusDraft},
+			3: {PinID: 3, AuthorID: 1, Status: models.PinStatusDraft},
+			4: {PinID: 4, AuthorID: 4, Status: models.PinStatusPublished},
+		},
+		boards: map[uint64]*models.Board{
+			1: {BoardID: 1, OwnerID: 3, Public: true},
+			2: {BoardID: 2, OwnerID: 3, Public: false},
+			3: {BoardID: 3, OwnerID: 1, Public: false},
+		},
+		images: map[string]bool{"https://media.pinset.ru/images/chat.png": true},
+	}
+	muc := usecase.NewMessageUsecase(nil, repo, users)
+
+	chat, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: 2})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return muc, chat.ChatID
+}
+
+func TestAddChatMessageAttachments(t *testing.T) {
+	users := newFakeUserRepository(1, 2, 3, 4)
+	users.Block(4, 1)
+	muc, chatID := newAttachmentsChat(t, users)
+
+	id := func(id uint64) *uint64 { return &id }
+	mediaUrl := func(url string) *string { return &url }
+
+	tests := []struct {
+		name       string
+		attachment *models.MessageAttachment
+		err        error
+	}{
+		{name: "published pin", attachment: &models.MessageAttachment{Type: models.AttachmentTypePin, PinID: id(1)}},
+		{name: "draft of another user", attachment: &models.MessageAttachment{Type: models.AttachmentTypePin, PinID: id(2)}, err: internal_errors.ErrPinDoesntExists},
+		{name: "own draft", attachment: &models.MessageAttachment{Type: models.AttachmentTypePin, PinID: id(3)}},
+		{name: "pin of a user who blocked the sender", attachment: &models.MessageAttachment{Type: models.AttachmentTypePin, PinID: id(4)}, err: internal_errors.ErrPinDoesntExists},
+		{name: "missing pin", attachment: &models.MessageAttachment{Type: models.AttachmentTypePin, PinID: id(5)}, err: internal_errors.ErrPinDoesntExists},
+		{name: "public board", attachment: &models.MessageAttachment{Type: models.AttachmentTypeBoard, BoardID: id(1)}},
+		{name: "private board of another user", attachment: &models.MessageAttachment{Type: models.AttachmentTypeBoard, BoardID: id(2)}, err: internal_errors.ErrBoardDoesntExists},
+		{name: "own private board", attachment: &models.MessageAttachment{Type: models.AttachmentTypeBoard, BoardID: id(3)}},
+		{name: "uploaded image", attachment: &models.MessageAttachment{Type: models.AttachmentTypeImage, MediaUrl: mediaUrl("https://media.pinset.ru/images/chat.png")}},
+		{name: "foreign image", attachment: &models.MessageAttachment{Type: models.AttachmentTypeImage, MediaUrl: mediaUrl("https://example.com/image.png")}, err: internal_errors.ErrMessageDataInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := muc.AddChatMessage(&models.Message{
+				SenderID:    1,
+				ChatID:      chatID,
+				Attachments: []*models.MessageAttachment{tt.attachment},
+			})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, message)
+		})
+	}
+}
+
+func TestAddChatMessageFillsPreviews(t *testing.T) {
+	muc, chatID := newAttachmentsChat(t, newFakeUserRepository(1, 2, 3))
+	pinID, boardID := uint64(1), uint64(3)
+
+	message, err := muc.AddChatMessage(&models.Message{
+		SenderID: 1,
+		ChatID:   chatID,
+		Content:  "look",
+		Attachments: []*models.MessageAttachment{
+			{Type: models.AttachmentTypePin, PinID: &pinID},
+			{Type: models.AttachmentTypeBoard, BoardID: &boardID},
+		},
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, message.Attachments, 2) {
+		return
+	}
+	if assert.NotNil(t, message.Attachments[0].Pin) {
+		assert.Equal(t, pinID, message.Attachments[0].Pin.PinID)
+	}
+	if assert.NotNil(t, message.Attachments[1].Board) {
+		assert.Equal(t, boardID, message.Attachments[1].Board.BoardID)
+	}
+}
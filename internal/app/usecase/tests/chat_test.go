@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"errors"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"sync"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateGroupChat(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3))
+
+	chat, message, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{
+		CreatorID: 1,
+		Title:     "Trip",
+		MemberIDs: []uint64{2, 3, 2, 1},
+	})
+	assert.NoError(t, err)
+	if assert.NotNil(t, chat) {
+		assert.True(t, chat.IsGroup)
+		assert.Equal(t, []*models.ChatMember{
+			{UserID: 1, Role: models.ChatRoleAdmin},
+			{UserID: 2, Role: models.ChatRoleMember},
+			{UserID: 3, Role: models.ChatRoleMember},
+		}, chat.Participants, "the creator is the admin, repeated members are added once")
+	}
+	if assert.NotNil(t, message) {
+		assert.Equal(t, models.MessageTypeSystem, message.Type)
+		assert.Equal(t, models.ChatEventCreated, message.Content)
+		assert.Equal(t, uint64(1), message.SenderID)
+	}
+}
+
+func TestCreateGroupChatRejectsMembers(t *testing.T) {
+	users := newFakeUserRepository(1, 2, 3)
+	users.Block(3, 1)
+
+	tests := []struct {
+		name      string
+		memberIDs []uint64
+		err       error
+	}{
+		{name: "unknown user", memberIDs: []uint64{2, 4}, err: internal_errors.ErrUserDoesntExists},
+		{name: "member blocked the creator", memberIDs: []uint64{2, 3}, err: internal_errors.ErrUserIsBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats := newFakeChatRepository()
+			muc := usecase.NewMessageUsecase(nil, chats, users)
+
+			_, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: tt.memberIDs})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Zero(t, chats.ChatCount())
+		})
+	}
+}
+
+func TestCreateGroupChatLimitsMembers(t *testing.T) {
+	users := newFakeUserRepository(1)
+	memberIDs := make([]uint64, 0, models.MaxGroupChatMembers)
+	for userID := uint64(2); userID <= models.MaxGroupChatMembers+1; userID++ {
+		users.users[userID] = true
+		memberIDs = append(memberIDs, userID)
+	}
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, users)
+
+	_, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Crowd", MemberIDs: memberIDs})
+	assert.ErrorIs(t, err, internal_errors.ErrChatMembersLimit)
+	assert.Zero(t, chats.ChatCount())
+}
+
+func TestCreateGroupChatRollsBack(t *testing.T) {
+	chats := newFakeChatRepository()
+	errDatabase := errors.New("database is down")
+	chats.failAddUser[3] = errDatabase
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3))
+
+	_, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2, 3}})
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Zero(t, chats.ChatCount(), "a chat without all of its members is not left behind")
+}
+
+func TestAddChatMember(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3, 4))
+
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	message, err := muc.AddChatMember(1, chat.ChatID, 3)
+	assert.NoError(t, err)
+	if assert.NotNil(t, message) {
+		assert.Equal(t, models.ChatEventMemberAdded, message.Content)
+		assert.Equal(t, uint64(3), *message.TargetID)
+	}
+	role, err := chats.GetChatMemberRole(chat.ChatID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ChatRoleMember, role)
+
+	_, err = muc.AddChatMember(1, chat.ChatID, 3)
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsAlreadyChatMember)
+
+	_, err = muc.AddChatMember(2, chat.ChatID, 4)
+	assert.ErrorIs(t, err, internal_errors.ErrNotEnoughChatRights, "only admins add members")
+
+	_, err = muc.AddChatMember(1, chat.ChatID, 5)
+	assert.ErrorIs(t, err, internal_errors.ErrUserDoesntExists)
+}
+
+func TestAddChatMemberLimitsConcurrentMembers(t *testing.T) {
+	users := newFakeUserRepository(1)
+	memberIDs := make([]uint64, 0, models.MaxGroupChatMembers)
+	for userID := uint64(2); userID <= models.MaxGroupChatMembers+3; userID++ {
+		users.users[userID] = true
+		if userID < models.MaxGroupChatMembers {
+			memberIDs = append(memberIDs, userID)
+		}
+	}
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, users)
+
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Crowd", MemberIDs: memberIDs})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for userID := uint64(models.MaxGroupChatMembers); userID <= models.MaxGroupChatMembers+3; userID++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := muc.AddChatMember(1, chat.ChatID, userID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	added := 0
+	for err := range errs {
+		if err == nil {
+			added++
+			continue
+		}
+		assert.ErrorIs(t, err, internal_errors.ErrChatMembersLimit)
+	}
+	assert.Equal(t, 1, added, "the chat has room for one more member")
+
+	members, _ := chats.GetChatMembers(chat.ChatID)
+	assert.Len(t, members, models.MaxGroupChatMembers)
+}
+
+func TestRemoveChatMember(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3))
+
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2, 3}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = muc.RemoveChatMember(2, chat.ChatID, 3)
+	assert.ErrorIs(t, err, internal_errors.ErrNotEnoughChatRights, "only admins remove members")
+
+	message, err := muc.RemoveChatMember(1, chat.ChatID, 3)
+	assert.NoError(t, err)
+	if assert.NotNil(t, message) {
+		assert.Equal(t, models.ChatEventMemberRemoved, message.Content)
+	}
+	_, err = chats.GetChatMemberRole(chat.ChatID, 3)
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsNotChatMember)
+
+	_, err = muc.RemoveChatMember(1, chat.ChatID, 3)
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsNotChatMember)
+}
+
+func TestLeaveChat(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3))
+
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2, 3}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	messages, err := muc.LeaveChat(1, chat.ChatID)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, models.ChatEventMemberLeft, messages[0].Content)
+		assert.Equal(t, models.ChatEventAdminAdded, messages[1].Content)
+	}
+	role, _ := chats.GetChatMemberRole(chat.ChatID, 2)
+	assert.Equal(t, models.ChatRoleAdmin, role, "the oldest member takes over after the last admin")
+
+	_, err = muc.LeaveChat(2, chat.ChatID)
+	assert.NoError(t, err)
+	messages, err = muc.LeaveChat(3, chat.ChatID)
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+	assert.Zero(t, chats.ChatCount(), "the chat is deleted with its last member")
+}
+
+func TestSetChatMemberRoleKeepsLastAdmin(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2))
+
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "Trip", MemberIDs: []uint64{2}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if _, err := muc.SetChatMemberRole(1, chat.ChatID, 2, models.ChatRoleAdmin); !assert.NoError(t, err) {
+		return
+	}
+
+	// Both admins demote each other at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, pair := range [][2]uint64{{1, 2}, {2, 1}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := muc.SetChatMemberRole(pair[0], chat.ChatID, pair[1], models.ChatRoleMember)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	failed := 0
+	for err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed, "one of the demotions sees it would leave the chat without admins")
+
+	members, _ := chats.GetChatMembers(chat.ChatID)
+	admins := 0
+	for _, member := range members {
+		if member.Role == models.ChatRoleAdmin {
+			admins++
+		}
+	}
+	assert.Equal(t, 1, admins)
+}
+
+func TestCreateDirectChatReturnsExistingChat(t *testing.T) {
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, newFakeUserRepository(1, 2, 3))
+
+	first, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: 2})
+	assert.NoError(t, err)
+	second, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 2, CompanionID: 1})
+	assert.NoError(t, err)
+	if assert.NotNil(t, first) && assert.NotNil(t, second) {
+		assert.Equal(t, first.ChatID, second.ChatID, "the pair has one direct chat whoever starts it")
+		assert.False(t, first.IsGroup)
+	}
+
+	other, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: 3})
+	assert.NoError(t, err)
+	if assert.NotNil(t, other) {
+		assert.NotEqual(t, first.ChatID, other.ChatID)
+	}
+	assert.Equal(t, 2, chats.ChatCount())
+}
+
+func TestCreateDirectChatRejectsCompanions(t *testing.T) {
+	users := newFakeUserRepository(1, 2)
+	users.Block(1, 2)
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(nil, chats, users)
+
+	_, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 2, CompanionID: 1})
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsBlocked)
+
+	_, err = muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: 3})
+	assert.ErrorIs(t, err, internal_errors.ErrUserDoesntExists)
+	assert.Zero(t, chats.ChatCount())
+}
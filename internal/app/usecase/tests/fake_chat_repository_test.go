@@ -0,0 +1,324 @@
+package tests
+
+import (
+	"maps"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"slices"
+	"sync"
+
+	internal_errors "pinset/internal/errors"
+)
+
+// chatState is the data of chats
+type chatState struct {
+	chats map[uint64]*models.Chat
+	// roles by chat, then user
+	roles map[uint64]map[uint64]string
+	// direct chats by the pair of users, the lower ID first
+	direct   map[[2]uint64]uint64
+	messages []*models.MessageCreateInfo
+	lastID   uint64
+}
+
+func (s *chatState) clone() *chatState {
+	clone := &chatState{
+		chats:    maps.Clone(s.chats),
+		roles:    make(map[uint64]map[uint64]string, len(s.roles)),
+		direct:   maps.Clone(s.direct),
+		messages: slices.Clone(s.messages),
+		lastID:   s.lastID,
+	}
+	for chatID, roles := range s.roles {
+		clone.roles[chatID] = maps.Clone(roles)
+	}
+	return clone
+}
+
+func (s *chatState) nextID() uint64 {
+	s.lastID++
+	return s.lastID
+}
+
+// fakeChatRepository keeps chats in memory.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeChatRepository struct {
+	usecase.MediaRepository
+
+	mu    sync.Mutex
+	state *chatState
+	// members serializes the updates of chat members like the locks of their rows
+	members sync.Mutex
+	// failAddUser fails adding the user to a chat
+	failAddUser map[uint64]error
+}
+
+func newFakeChatRepository() *fakeChatRepository {
+	return &fakeChatRepository{
+		state: &chatState{
+			chats:  make(map[uint64]*models.Chat),
+			roles:  make(map[uint64]map[uint64]string),
+			direct: make(map[[2]uint64]uint64),
+		},
+		failAddUser: make(map[uint64]error),
+	}
+}
+
+// CreateGroupChat changes a copy of the state that replaces it only when all of the chat is created
+func (r *fakeChatRepository) CreateGroupChat(chat *models.Chat, memberIDs []uint64, msg *models.Message) (*models.ChatCreateInfo, *models.MessageCreateInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state.clone()
+	chatID := state.nextID()
+	created := *chat
+	created.ChatID = chatID
+	state.chats[chatID] = &created
+	state.roles[chatID] = make(map[uint64]string)
+
+	if err := addUserToChat(state, r.failAddUser, chatID, *chat.CreatorID, models.ChatRoleAdmin); err != nil {
+		return nil, nil, err
+	}
+	for _, memberID := range memberIDs {
+		if err := addUserToChat(state, r.failAddUser, chatID, memberID, models.ChatRoleMember); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	msg.ChatID = chatID
+	message := createMessage(state, msg)
+	r.state = state
+	return &models.ChatCreateInfo{ID: chatID}, message, nil
+}
+
+// UpdateChatMembers runs fn on a copy of the state that replaces it only if fn succeeds
+func (r *fakeChatRepository) UpdateChatMembers(chatID uint64, fn func(repo usecase.ChatMembersRepository) error) error {
+	r.members.Lock()
+	defer r.members.Unlock()
+
+	r.mu.Lock()
+	tx := &fakeChatRepository{state: r.state.clone(), failAddUser: r.failAddUser}
+	r.mu.Unlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = tx.state
+	return nil
+}
+
+func (r *fakeChatRepository) CreateDirectChat(firstUserID, secondUserID uint64) (*models.ChatCreateInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pair := [2]uint64{min(firstUserID, secondUserID), max(firstUserID, secondUserID)}
+	if chatID, ok := r.state.direct[pair]; ok {
+		return &models.ChatCreateInfo{ID: chatID}, nil
+	}
+
+	chatID := r.state.nextID()
+	r.state.chats[chatID] = &models.Chat{ChatID: chatID, CreatorID: &firstUserID}
+	r.state.roles[chatID] = map[uint64]string{firstUserID: models.ChatRoleMember, secondUserID: models.ChatRoleMember}
+	r.state.direct[pair] = chatID
+	return &models.ChatCreateInfo{ID: chatID}, nil
+}
+
+func (r *fakeChatRepository) GetChatByChatID(chatID uint64) (*models.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chat, ok := r.state.chats[chatID]
+	if !ok {
+		return nil, internal_errors.ErrChatDoesntExists
+	}
+	return chat, nil
+}
+
+func (r *fakeChatRepository) GetChatMemberRole(chatID, userID uint64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.state.roles[chatID][userID]
+	if !ok {
+		return "", internal_errors.ErrUserIsNotChatMember
+	}
+	return role, nil
+}
+
+func (r *fakeChatRepository) GetChatUsers(chatID uint64) ([]uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userIDs := make([]uint64, 0, len(r.state.roles[chatID]))
+	for userID := range r.state.roles[chatID] {
+		userIDs = append(userIDs, userID)
+	}
+	slices.Sort(userIDs)
+	return userIDs, nil
+}
+
+func (r *fakeChatRepository) GetChatMembers(chatID uint64) ([]*models.ChatMember, error) {
+	userIDs, _ := r.GetChatUsers(chatID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := make([]*models.ChatMember, 0, len(userIDs))
+	for _, userID := range userIDs {
+		members = append(members, &models.ChatMember{UserID: userID, Role: r.state.roles[chatID][userID]})
+	}
+	return members, nil
+}
+
+func (r *fakeChatRepository) GetUserCompanions(userID uint64) ([]uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var companionIDs []uint64
+	for _, roles := range r.state.roles {
+		if _, ok := roles[userID]; !ok {
+			continue
+		}
+		for companionID := range roles {
+			if companionID != userID && !slices.Contains(companionIDs, companionID) {
+				companionIDs = append(companionIDs, companionID)
+			}
+		}
+	}
+	slices.Sort(companionIDs)
+	return companionIDs, nil
+}
+
+func (r *fakeChatRepository) AddUserToChat(chatID, userID uint64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return addUserToChat(r.state, r.failAddUser, chatID, userID, role)
+}
+
+func (r *fakeChatRepository) RemoveUserFromChat(chatID, userID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.state.roles[chatID], userID)
+	return nil
+}
+
+func (r *fakeChatRepository) UpdateChatMemberRole(chatID, userID uint64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.roles[chatID][userID] = role
+	return nil
+}
+
+func (r *fakeChatRepository) DeleteChat(chatID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.state.chats, chatID)
+	delete(r.state.roles, chatID)
+	return nil
+}
+
+func (r *fakeChatRepository) CreateMessage(msg *models.Message) (*models.MessageCreateInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return createMessage(r.state, msg), nil
+}
+
+func (r *fakeChatRepository) GetChatMessages(chatID uint64, params *models.MessagesPageParams) ([]*models.MessageInfo, error) {
+	var page []*models.MessageInfo
+	for _, message := range r.Messages(chatID) {
+		if params.BeforeID != nil && message.ID >= *params.BeforeID || params.AfterID != nil && message.ID <= *params.AfterID {
+			continue
+		}
+		page = append(page, messageInfo(message))
+	}
+
+	// Pages going back in history end with the newest messages
+	if params.AfterID == nil && len(page) > params.Limit {
+		return page[len(page)-params.Limit:], nil
+	}
+	return page[:min(len(page), params.Limit)], nil
+}
+
+func (r *fakeChatRepository) GetChatMessage(chatID, messageID uint64) (*models.MessageInfo, error) {
+	for _, message := range r.Messages(chatID) {
+		if message.ID == messageID {
+			return messageInfo(message), nil
+		}
+	}
+	return nil, internal_errors.ErrMessageDoesntExists
+}
+
+func (r *fakeChatRepository) GetMessagesAttachments(messageIDs []uint64) (map[uint64][]*models.MessageAttachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attachments := make(map[uint64][]*models.MessageAttachment)
+	for _, message := range r.state.messages {
+		if slices.Contains(messageIDs, message.ID) && len(message.Attachments) > 0 {
+			attachments[message.ID] = message.Attachments
+		}
+	}
+	return attachments, nil
+}
+
+// ChatCount returns the number of chats
+func (r *fakeChatRepository) ChatCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.state.chats)
+}
+
+// Messages returns the messages of the chat
+func (r *fakeChatRepository) Messages(chatID uint64) []*models.MessageCreateInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var messages []*models.MessageCreateInfo
+	for _, message := range r.state.messages {
+		if message.ChatID == chatID {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func addUserToChat(state *chatState, failAddUser map[uint64]error, chatID, userID uint64, role string) error {
+	if err := failAddUser[userID]; err != nil {
+		return err
+	}
+	if state.roles[chatID] == nil {
+		state.roles[chatID] = make(map[uint64]string)
+	}
+	state.roles[chatID][userID] = role
+	return nil
+}
+
+func createMessage(state *chatState, msg *models.Message) *models.MessageCreateInfo {
+	message := &models.MessageCreateInfo{
+		ID:          state.nextID(),
+		SenderID:    msg.SenderID,
+		ChatID:      msg.ChatID,
+		Content:     msg.Content,
+		Type:        msg.Type,
+		TargetID:    msg.TargetID,
+		Attachments: msg.Attachments,
+		CreatedAt:   msg.CreatedAt,
+	}
+	state.messages = append(state.messages, message)
+	return message
+}
+
+func messageInfo(message *models.MessageCreateInfo) *models.MessageInfo {
+	return &models.MessageInfo{
+		ID:        message.ID,
+		SenderID:  message.SenderID,
+		ChatID:    message.ChatID,
+		Content:   message.Content,
+		Type:      message.Type,
+		TargetID:  message.TargetID,
+		CreatedAt: message.CreatedAt,
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"pinset/configs/s3"
+	"pinset/internal/app/models"
+	mediarepository "pinset/internal/app/repository/media_repository"
+	"pinset/internal/app/usecase"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/mediaurl"
+	"pinset/pkg/objectstore"
+	"strconv"
+	"sync"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeMediaRepository runs the media repository against the memory object store
+// and keeps the registry of media in memory instead of PostgreSQL.
+// Methods that are not overridden go to the real repository and must not touch the database.
+type fakeMediaRepository struct {
+	usecase.MediaRepository
+
+	Store *objectstore.MemoryStore
+
+	mu sync.Mutex
+	// media by checksum, media without checksum by key
+	media   map[string]*models.Media
+	uploads map[string]*models.Upload
+	// pins created by the usecase
+	pins     []*models.Pin
+	objectID int
+}
+
+// newFakeMediaRepository creates the repository with the storage config of the environment,
+// so tests select the backend with t.Setenv before calling it.
+func newFakeMediaRepository(t *testing.T) *fakeMediaRepository {
+	t.Helper()
+
+	store := objectstore.NewMemoryStore(objectstore.NewURLSigner("http://pinset.test/media", []byte("test signing key")))
+	for _, bucketName := range []string{"images", "videos", "audios"} {
+		if err := store.EnsureBucket(bucketName); err != nil {
+			t.Fatalf("ensure bucket %s: %s", bucketName, err)
+		}
+	}
+
+	minioParams := s3.NewMinioParams()
+	urls := mediaurl.NewBuilder(s3.NewStorageParams().MediaBaseUrl(minioParams), minioParams.Buckets()...)
+
+	return &fakeMediaRepository{
+		MediaRepository: mediarepository.NewMediaRepository(nil, logrus.New(), store, urls),
+		Store:           store,
+		media:           make(map[string]*models.Media),
+		uploads:         make(map[string]*models.Upload),
+	}
+}
+
+func (r *fakeMediaRepository) nextObjectID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.objectID++
+	return "object" + strconv.Itoa(r.objectID)
+}
+
+func (r *fakeMediaRepository) register(media *models.Media) {
+	media.MediaKey = mediaurl.JoinKey(media.BucketName, media.ObjectKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if media.Checksum != nil {
+		r.media[*media.Checksum] = media
+	} else {
+		r.media[media.MediaKey] = media
+	}
+}
+
+func (r *fakeMediaRepository) ReuseMediaByChecksum(bucketName, checksum string) (*models.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	media, ok := r.media[checksum]
+	if !ok || media.BucketName != bucketName {
+		return nil, nil
+	}
+	return media, nil
+}
+
+func (r *fakeMediaRepository) UploadMedia(media *models.Media, content io.Reader) error {
+	media.ObjectKey = r.nextObjectID()
+	if err := r.Store.Put(media.BucketName, media.ObjectKey, content, media.Size, media.ContentType); err != nil {
+		return err
+	}
+	r.register(media)
+	return nil
+}
+
+// UploadImage stores the original only, variants are the business of imageproc
+func (r *fakeMediaRepository) UploadImage(media *models.Media, img *imageproc.Result) error {
+	media.ObjectKey = r.nextObjectID() + imageproc.Extension(img.Original.Format)
+	media.ContentType = img.Original.ContentType
+	media.Size = int64(len(img.Original.Data))
+	err := r.Store.Put(media.BucketName, media.ObjectKey, bytes.NewReader(img.Original.Data), media.Size, media.ContentType)
+	if err != nil {
+		return err
+	}
+	r.register(media)
+	return nil
+}
+
+func (r *fakeMediaRepository) CreateMedia(media *models.Media) error {
+	r.register(media)
+	return nil
+}
+
+func (r *fakeMediaRepository) CreateUpload(upload *models.Upload) error {
+	upload.UploadID = r.nextObjectID()
+	upload.ObjectName = r.nextObjectID()
+	upload.Status = models.UploadStatusPending
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *upload
+	r.uploads[upload.UploadID] = &stored
+	return nil
+}
+
+func (r *fakeMediaRepository) GetUpload(uploadID string) (*models.Upload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	upload, ok := r.uploads[uploadID]
+	if !ok {
+		return nil, internal_errors.ErrUploadDoesntExists
+	}
+	stored := *upload
+	return &stored, nil
+}
+
+func (r *fakeMediaRepository) CompleteUpload(upload *models.Upload) error {
+	upload.Status = models.UploadStatusCompleted
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *upload
+	r.uploads[upload.UploadID] = &stored
+	return nil
+}
+
+func (r *fakeMediaRepository) CreatePin(pin *models.Pin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pins = append(r.pins, pin)
+	return nil
+}
+
+// MediaCount returns the number of registered media
+func (r *fakeMediaRepository) MediaCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.media)
+}
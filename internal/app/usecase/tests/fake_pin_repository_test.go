@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"slices"
+	"sync"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+)
+
+// fakePinRepository keeps pins and their comments in memory.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakePinRepository struct {
+	usecase.MediaRepository
+
+	mu       sync.Mutex
+	pins     map[uint64]*models.Pin
+	comments map[uint64][]*models.Comment
+}
+
+func newFakePinRepository(pins ...*models.Pin) *fakePinRepository {
+	repo := &fakePinRepository{
+		pins:     make(map[uint64]*models.Pin),
+		comments: make(map[uint64][]*models.Comment),
+	}
+	for _, pin := range pins {
+		repo.pins[pin.PinID] = pin
+	}
+	return repo
+}
+
+// pin returns a copy of the pin, so the usecase can't change the stored one
+func (r *fakePinRepository) pin(pinID uint64) (*models.Pin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pin, ok := r.pins[pinID]
+	if !ok {
+		return nil, internal_errors.ErrPinDoesntExists
+	}
+	copied := *pin
+	return &copied, nil
+}
+
+func (r *fakePinRepository) GetPinPreviewInfoByPinID(pinID uint64) (*models.Pin, error) {
+	return r.pin(pinID)
+}
+
+func (r *fakePinRepository) GetPinPageInfoByPinID(pinID uint64) (*models.Pin, error) {
+	return r.pin(pinID)
+}
+
+func (r *fakePinRepository) GetAllCommentariesByPinID(pinID uint64) ([]*models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.Comment(nil), r.comments[pinID]...), nil
+}
+
+func (r *fakePinRepository) GetPinsReactions(pinIDs []uint64, viewerID uint64) (map[uint64]*models.PinReactions, error) {
+	reactions := make(map[uint64]*models.PinReactions, len(pinIDs))
+	for _, pinID := range pinIDs {
+		reactions[pinID] = &models.PinReactions{Counts: map[string]uint64{}}
+	}
+	return reactions, nil
+}
+
+func (r *fakePinRepository) GetBookmarkedPinIDs(ownerID uint64, pinIDs []uint64) (map[uint64]bool, error) {
+	return map[uint64]bool{}, nil
+}
+
+// UpdatePinInfoByPinID keeps the status and the publishing time unless a status is given
+func (r *fakePinRepository) UpdatePinInfoByPinID(pin *models.Pin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.pins[pin.PinID]
+	if !ok {
+		return internal_errors.ErrPinDoesntExists
+	}
+	updated := *pin
+	if updated.Status == "" {
+		updated.Status, updated.PublishAt = current.Status, current.PublishAt
+	}
+	r.pins[pin.PinID] = &updated
+	return nil
+}
+
+// PublishDuePins publishes scheduled pins in order of their publishing time
+func (r *fakePinRepository) PublishDuePins(limit int) ([]uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*models.Pin
+	for _, pin := range r.pins {
+		if pin.Status == models.PinStatusScheduled && !pin.PublishAt.After(time.Now()) {
+			due = append(due, pin)
+		}
+	}
+	slices.SortFunc(due, func(a, b *models.Pin) int {
+		return a.PublishAt.Compare(*b.PublishAt)
+	})
+
+	var pinIDs []uint64
+	for _, pin := range due[:min(limit, len(due))] {
+		pin.Status = models.PinStatusPublished
+		pinIDs = append(pinIDs, pin.PinID)
+	}
+	return pinIDs, nil
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"sync"
+)
+
+// dayCounts are counts of pins by pin and day
+type dayCounts map[uint64]map[string]uint64
+
+func (c dayCounts) add(pinID uint64, day string, count uint64) {
+	if c[pinID] == nil {
+		c[pinID] = make(map[string]uint64)
+	}
+	c[pinID][day] += count
+}
+
+// fakeTxRepository runs units of work in memory: the writes of a unit of work
+// are kept only when it succeeds, like a committed transaction.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeTxRepository struct {
+	usecase.MediaRepository
+	usecase.TxRepository
+
+	mu sync.Mutex
+	// committed counts
+	views, linkClicks dayCounts
+	// fail makes the next units of work fail and roll back
+	fail error
+
+	txViews, txLinkClicks dayCounts
+}
+
+func newFakeTxRepository() *fakeTxRepository {
+	return &fakeTxRepository{views: dayCounts{}, linkClicks: dayCounts{}}
+}
+
+func (r *fakeTxRepository) RunInTx(fn func(repo usecase.TxRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.txViews, r.txLinkClicks = dayCounts{}, dayCounts{}
+	if err := fn(r); err != nil {
+		return err
+	}
+	if r.fail != nil {
+		return r.fail
+	}
+	commit(r.views, r.txViews)
+	commit(r.linkClicks, r.txLinkClicks)
+	return nil
+}
+
+func commit(committed, tx dayCounts) {
+	for pinID, days := range tx {
+		for day, count := range days {
+			committed.add(pinID, day, count)
+		}
+	}
+}
+
+func (r *fakeTxRepository) AddPinViews(views *models.PinViews) error {
+	r.txViews.add(views.PinID, views.Day.Format(models.AnalyticsDayLayout), views.Views)
+	return nil
+}
+
+func (r *fakeTxRepository) AddPinLinkClicks(clicks *models.PinLinkClicks) error {
+	r.txLinkClicks.add(clicks.PinID, clicks.Day.Format(models.AnalyticsDayLayout), clicks.Clicks)
+	return nil
+}
+
+// Views returns the committed views of the pin on the day
+func (r *fakeTxRepository) Views(pinID uint64, day string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.views[pinID][day]
+}
+
+// LinkClicks returns the committed link clicks of the pin on the day
+func (r *fakeTxRepository) LinkClicks(pinID uint64, day string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.linkClicks[pinID][day]
+}
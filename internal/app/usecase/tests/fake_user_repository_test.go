@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/models/response"
+	"pinset/internal/app/usecase"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+)
+
+// fakeUserRepository knows which users exist and who blocked whom.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeUserRepository struct {
+	usecase.UserRepository
+
+	users map[uint64]bool
+	// blocks by blocker, then blocked
+	blocks map[uint64]map[uint64]bool
+	// lastSeen and hidePresence of users by ID
+	lastSeen     map[uint64]time.Time
+	hidePresence map[uint64]bool
+}
+
+func newFakeUserRepository(userIDs ...uint64) *fakeUserRepository {
+	repo := &fakeUserRepository{
+		users:        make(map[uint64]bool),
+		blocks:       make(map[uint64]map[uint64]bool),
+		lastSeen:     make(map[uint64]time.Time),
+		hidePresence: make(map[uint64]bool),
+	}
+	for _, userID := range userIDs {
+		repo.users[userID] = true
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) Block(blockerID, blockedID uint64) {
+	if r.blocks[blockerID] == nil {
+		r.blocks[blockerID] = make(map[uint64]bool)
+	}
+	r.blocks[blockerID][blockedID] = true
+}
+
+func (r *fakeUserRepository) GetUserInfoPublic(userID uint64) (*response.UserProfileResponse, error) {
+	if !r.users[userID] {
+		return nil, internal_errors.ErrUserDoesntExists
+	}
+	return &response.UserProfileResponse{}, nil
+}
+
+func (r *fakeUserRepository) GetBlockedUserIDs(blockerID uint64) ([]uint64, error) {
+	var blockedIDs []uint64
+	for blockedID := range r.blocks[blockerID] {
+		blockedIDs = append(blockedIDs, blockedID)
+	}
+	return blockedIDs, nil
+}
+
+func (r *fakeUserRepository) GetBlockRelatedUserIDs(userID uint64) ([]uint64, error) {
+	related, _ := r.GetBlockedUserIDs(userID)
+	for blockerID, blocked := range r.blocks {
+		if blocked[userID] {
+			related = append(related, blockerID)
+		}
+	}
+	return related, nil
+}
+
+func (r *fakeUserRepository) IsBlockedBetween(firstUserID, secondUserID uint64) (bool, error) {
+	return r.blocks[firstUserID][secondUserID] || r.blocks[secondUserID][firstUserID], nil
+}
+
+func (r *fakeUserRepository) UpdateUserLastSeen(userID uint64) error {
+	r.lastSeen[userID] = time.Now()
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateUserPresenceSettings(userID uint64, settings *models.PresenceSettings) error {
+	r.hidePresence[userID] = settings.HidePresence
+	return nil
+}
+
+func (r *fakeUserRepository) GetUsersPresenceInfo(userIDs []uint64) ([]*models.UserPresenceInfo, error) {
+	var presenceInfo []*models.UserPresenceInfo
+	for _, userID := range userIDs {
+		if !r.users[userID] {
+			continue
+		}
+		info := &models.UserPresenceInfo{UserID: userID, HidePresence: r.hidePresence[userID]}
+		if lastSeen, ok := r.lastSeen[userID]; ok {
+			info.LastSeen = &lastSeen
+		}
+		presenceInfo = append(presenceInfo, info)
+	}
+	return presenceInfo, nil
+}
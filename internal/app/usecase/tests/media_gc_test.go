@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"errors"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSweepRepository returns the given unreferenced media and expired uploads and records removed objects.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeSweepRepository struct {
+	usecase.MediaRepository
+
+	media   []*models.Media
+	uploads []*models.Upload
+	// referenced media got a reference in the middle of the sweep
+	referenced map[uint64]bool
+	// completed uploads got completed in the middle of the sweep
+	completed  map[string]bool
+	failRemove map[string]error
+	removed    []string
+	threshold  time.Time
+}
+
+func (r *fakeSweepRepository) GetUnreferencedMedia(unreferencedBefore time.Time, limit int) ([]*models.Media, error) {
+	r.threshold = unreferencedBefore
+	return r.media[:min(limit, len(r.media))], nil
+}
+
+func (r *fakeSweepRepository) DeleteUnreferencedMedia(mediaID uint64, unreferencedBefore time.Time) (bool, error) {
+	return !r.referenced[mediaID], nil
+}
+
+func (r *fakeSweepRepository) GetExpiredUploads(expiredBefore time.Time, limit int) ([]*models.Upload, error) {
+	return r.uploads[:min(limit, len(r.uploads))], nil
+}
+
+func (r *fakeSweepRepository) DeletePendingUpload(uploadID string) (bool, error) {
+	return !r.completed[uploadID], nil
+}
+
+func (r *fakeSweepRepository) RemoveObject(bucketName, objectName string) error {
+	if err := r.failRemove[objectName]; err != nil {
+		return err
+	}
+	r.removed = append(r.removed, bucketName+"/"+objectName)
+	return nil
+}
+
+func TestMediaSweeperSweep(t *testing.T) {
+	t.Setenv("MEDIA_GC_GRACE_PERIOD", "1h")
+	repo := &fakeSweepRepository{
+		media: []*models.Media{
+			{MediaID: 1, BucketName: "images", ObjectKey: "a.png", VariantKeys: []string{"a_236.png", "a_236.webp"}},
+			{MediaID: 2, BucketName: "images", ObjectKey: "b.png"},
+			{MediaID: 3, BucketName: "videos", ObjectKey: "c.mp4"},
+		},
+		uploads: []*models.Upload{
+			{UploadID: "u1", BucketName: "images", ObjectName: "u1.png"},
+			{UploadID: "u2", BucketName: "images", ObjectName: "u2.png"},
+		},
+		referenced: map[uint64]bool{2: true},
+		completed:  map[string]bool{"u2": true},
+	}
+
+	result, err := usecase.NewMediaSweeper(repo).Sweep()
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.SweepResult{Media: 2, Uploads: 1}, result)
+	assert.Equal(t, []string{
+		"images/a.png", "images/a_236.png", "images/a_236.webp",
+		"videos/c.mp4",
+		"images/u1.png",
+	}, repo.removed, "objects of entries referenced during the sweep are kept")
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), repo.threshold, time.Minute, "fresh media is protected by the grace period")
+}
+
+func TestMediaSweeperGoesOnAfterFailures(t *testing.T) {
+	errStorage := errors.New("storage is down")
+	repo := &fakeSweepRepository{
+		media: []*models.Media{
+			{MediaID: 1, BucketName: "images", ObjectKey: "a.png"},
+			{MediaID: 2, BucketName: "images", ObjectKey: "b.png"},
+		},
+		uploads:    []*models.Upload{{UploadID: "u1", BucketName: "images", ObjectName: "u1.png"}},
+		failRemove: map[string]error{"a.png": errStorage},
+	}
+
+	result, err := usecase.NewMediaSweeper(repo).Sweep()
+	assert.ErrorIs(t, err, errStorage)
+	assert.Equal(t, usecase.SweepResult{Media: 2, Uploads: 1}, result, "a leaked object doesn't stop the sweep")
+	assert.Equal(t, []string{"images/b.png", "images/u1.png"}, repo.removed)
+}
+
+func TestMediaSweeperLimitsBatch(t *testing.T) {
+	t.Setenv("MEDIA_GC_BATCH_SIZE", "1")
+	repo := &fakeSweepRepository{
+		media: []*models.Media{
+			{MediaID: 1, BucketName: "images", ObjectKey: "a.png"},
+			{MediaID: 2, BucketName: "images", ObjectKey: "b.png"},
+		},
+	}
+
+	result, err := usecase.NewMediaSweeper(repo).Sweep()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Media)
+}
@@ -1 +1,141 @@
 package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"pinset/internal/app/usecase"
+	"pinset/pkg/mediaurl"
+	"strings"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const localMediaBaseUrl = "http://pinset.test/media"
+
+func useMemoryBackend(t *testing.T) {
+	t.Setenv("OBJECT_STORE_BACKEND", "memory")
+	t.Setenv("OBJECT_STORE_LOCAL_BASE_URL", localMediaBaseUrl)
+}
+
+func testPNG(t *testing.T, fill color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// multipartFiles turns the contents into file headers the way ParseMultipartForm does
+func multipartFiles(t *testing.T, contents ...[]byte) []*multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i, content := range contents {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="files"; filename="file`+string(rune('a'+i))+`"`)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatalf("create part: %s", err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("read form: %s", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["files"]
+}
+
+func TestUploadMediaMemoryBackend(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	urls, err := muc.UploadMedia(1, multipartFiles(t, testPNG(t, color.RGBA{R: 200, A: 255})))
+	assert.NoError(t, err)
+	if assert.Len(t, urls, 1) {
+		assert.True(t, strings.HasPrefix(urls[0], localMediaBaseUrl+"/images/"), "url %s is not served by the main server", urls[0])
+
+		bucketName, objectName, ok := mediaurl.SplitKey(strings.TrimPrefix(urls[0], localMediaBaseUrl+"/"))
+		assert.True(t, ok)
+		info, err := repo.Store.Stat(bucketName, objectName)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", info.ContentType)
+	}
+}
+
+func TestUploadMediaReusesDuplicates(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	image := testPNG(t, color.RGBA{G: 200, A: 255})
+	first, err := muc.UploadMedia(1, multipartFiles(t, image))
+	assert.NoError(t, err)
+	second, err := muc.UploadMedia(2, multipartFiles(t, image))
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, repo.MediaCount())
+}
+
+func TestUploadMediaRejectsWrongContentType(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	_, err := muc.UploadMedia(1, multipartFiles(t, []byte("just some text, not an image")))
+	assert.ErrorIs(t, err, internal_errors.ErrWrongMediaContentType)
+	assert.Equal(t, 0, repo.MediaCount())
+}
+
+func TestUploadMediaRejectsTooLargeFiles(t *testing.T) {
+	useMemoryBackend(t)
+	t.Setenv("MEDIA_MAX_IMAGE_SIZE", "64")
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	_, err := muc.UploadMedia(1, multipartFiles(t, testPNG(t, color.RGBA{B: 200, A: 255})))
+	assert.ErrorIs(t, err, internal_errors.ErrMediaTooLarge)
+	assert.Equal(t, 0, repo.MediaCount())
+}
+
+func TestUploadMediaAppliesLimitOfTheContentType(t *testing.T) {
+	useMemoryBackend(t)
+	t.Setenv("MEDIA_MAX_VIDEO_SIZE", "64")
+	t.Setenv("MEDIA_MAX_AUDIO_SIZE", "64")
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	urls, err := muc.UploadMedia(1, multipartFiles(t, testPNG(t, color.RGBA{R: 100, G: 100, A: 255})))
+	assert.NoError(t, err, "limits of other types don't apply to images")
+	assert.Len(t, urls, 1)
+}
+
+func TestUploadMediaRejectsBatchWithWrongFile(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	_, err := muc.UploadMedia(1, multipartFiles(t, testPNG(t, color.RGBA{R: 50, A: 255}), []byte("plain text")))
+	assert.ErrorIs(t, err, internal_errors.ErrWrongMediaContentType)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"pinset/internal/app/delivery/http"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newHistoryChat creates a group chat of users 1, 2 and 3 where every user in turn writes a message
+func newHistoryChat(t *testing.T, users *fakeUserRepository, count int) (delivery.MessageUsecase, uint64, []uint64) {
+	t.Helper()
+
+	muc := usecase.NewMessageUsecase(nil, newFakeChatRepository(), users)
+	chat, _, err := muc.CreateGroupChat(&models.GroupChatCreateRequest{CreatorID: 1, Title: "History", MemberIDs: []uint64{2, 3}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	messageIDs := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		message, err := muc.AddChatMessage(&models.Message{SenderID: uint64(i%3 + 1), ChatID: chat.ChatID, Content: "hello"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		messageIDs = append(messageIDs, message.ID)
+	}
+	return muc, chat.ChatID, messageIDs
+}
+
+func ids(messages []*models.MessageInfo) []uint64 {
+	messageIDs := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		messageIDs = append(messageIDs, message.ID)
+	}
+	return messageIDs
+}
+
+func TestGetChatMessagesAround(t *testing.T) {
+	muc, chatID, messageIDs := newHistoryChat(t, newFakeUserRepository(1, 2, 3), 9)
+
+	messages, err := muc.GetChatMessagesAround(1, chatID, messageIDs[4], 4)
+	assert.NoError(t, err)
+	assert.Equal(t, messageIDs[2:7], ids(messages), "the message is in the middle of limit/2 messages on each side")
+
+	messages, err = muc.GetChatMessagesAround(1, chatID, messageIDs[8], 4)
+	assert.NoError(t, err)
+	assert.Equal(t, messageIDs[6:9], ids(messages))
+
+	_, err = muc.GetChatMessagesAround(1, chatID, messageIDs[8]+1, 4)
+	assert.ErrorIs(t, err, internal_errors.ErrMessageDoesntExists)
+
+	_, err = muc.GetChatMessagesAround(4, chatID, messageIDs[4], 4)
+	assert.ErrorIs(t, err, internal_errors.ErrUserIsNotChatMember)
+
+	_, err = muc.GetChatMessagesAround(1, chatID, messageIDs[4], models.MaxMessagesPageSize+1)
+	assert.ErrorIs(t, err, internal_errors.ErrMessagesPageInvalid)
+}
+
+func TestGetChatMessagesHidesBlockedSenders(t *testing.T) {
+	users := newFakeUserRepository(1, 2, 3)
+	muc, chatID, messageIDs := newHistoryChat(t, users, 6)
+	users.Block(1, 2)
+
+	messages, err := muc.GetChatMessages(1, chatID, &models.MessagesPageParams{Limit: models.DefaultMessagesPageSize})
+	assert.NoError(t, err)
+	for _, message := range messages {
+		assert.False(t, message.Type == models.MessageTypeText && message.SenderID == 2, "messages of blocked users are hidden")
+	}
+	assert.Contains(t, ids(messages), messageIDs[0])
+	assert.NotContains(t, ids(messages), messageIDs[1])
+
+	messages, err = muc.GetChatMessages(2, chatID, &models.MessagesPageParams{BeforeID: &messageIDs[2], Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{messageIDs[1]}, ids(messages), "the blocker's messages are still shown to the blocked user")
+
+	_, err = muc.GetChatMessages(1, chatID, &models.MessagesPageParams{BeforeID: &messageIDs[2], AfterID: &messageIDs[0], Limit: 1})
+	assert.ErrorIs(t, err, internal_errors.ErrMessagesPageInvalid)
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"errors"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPinCounter(t *testing.T, dedupWindow string, maxTracked string) (*usecase.PinCounter, *fakeTxRepository) {
+	t.Setenv("VIEW_DEDUP_WINDOW", dedupWindow)
+	t.Setenv("VIEW_MAX_TRACKED", maxTracked)
+	repo := newFakeTxRepository()
+	return usecase.NewViewCounter(repo), repo
+}
+
+func today() string {
+	return models.ViewDay(time.Now()).Format(models.AnalyticsDayLayout)
+}
+
+func TestPinCounterDeduplicatesViewers(t *testing.T) {
+	views, _ := newTestPinCounter(t, "1h", "100")
+
+	assert.False(t, views.Seen(1, "user:1"))
+	assert.True(t, views.Record(1, "user:1"))
+	assert.True(t, views.Seen(1, "user:1"))
+	assert.False(t, views.Record(1, "user:1"))
+
+	assert.True(t, views.Record(1, "user:2"), "another viewer is counted")
+	assert.True(t, views.Record(2, "user:1"), "another pin is counted")
+	assert.False(t, views.Seen(3, "user:1"))
+}
+
+func TestPinCounterCountsAgainAfterDedupWindow(t *testing.T) {
+	views, _ := newTestPinCounter(t, "20ms", "100")
+
+	assert.True(t, views.Record(1, "user:1"))
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, views.Seen(1, "user:1"))
+	assert.True(t, views.Record(1, "user:1"))
+}
+
+func TestPinCounterLimitsTrackedViews(t *testing.T) {
+	views, _ := newTestPinCounter(t, "20ms", "2")
+
+	assert.True(t, views.Record(1, "user:1"))
+	assert.True(t, views.Record(1, "user:2"))
+	assert.False(t, views.Record(1, "user:3"), "new viewers are not counted when the counter is full")
+	assert.False(t, views.Seen(1, "user:3"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, views.Record(1, "user:3"), "expired views make room for new viewers")
+}
+
+func TestPinCounterFlush(t *testing.T) {
+	views, repo := newTestPinCounter(t, "1h", "100")
+
+	flushed, err := views.Flush()
+	assert.NoError(t, err)
+	assert.Zero(t, flushed)
+
+	views.Record(1, "user:1")
+	views.Record(1, "user:2")
+	views.Record(2, "user:1")
+
+	flushed, err = views.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), flushed)
+	assert.Equal(t, uint64(2), repo.Views(1, today()))
+	assert.Equal(t, uint64(1), repo.Views(2, today()))
+
+	flushed, err = views.Flush()
+	assert.NoError(t, err)
+	assert.Zero(t, flushed, "flushed views are not written twice")
+	assert.Equal(t, uint64(2), repo.Views(1, today()))
+}
+
+func TestPinCounterRebuffersFailedFlush(t *testing.T) {
+	views, repo := newTestPinCounter(t, "1h", "100")
+
+	views.Record(1, "user:1")
+	views.Record(1, "user:2")
+
+	errDatabase := errors.New("database is down")
+	repo.fail = errDatabase
+	flushed, err := views.Flush()
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Zero(t, flushed)
+	assert.Zero(t, repo.Views(1, today()))
+
+	views.Record(1, "user:3")
+	repo.fail = nil
+	flushed, err = views.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), flushed)
+	assert.Equal(t, uint64(3), repo.Views(1, today()))
+}
+
+func TestLinkClickCounter(t *testing.T) {
+	t.Setenv("LINK_CLICK_DEDUP_WINDOW", "1h")
+	repo := newFakeTxRepository()
+	clicks := usecase.NewLinkClickCounter(repo)
+
+	assert.True(t, clicks.Record(1, "anon:1"))
+	assert.False(t, clicks.Record(1, "anon:1"), "repeated clicks are not counted")
+	assert.True(t, clicks.Record(1, "user:1"))
+
+	flushed, err := clicks.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), flushed)
+	assert.Equal(t, uint64(2), repo.LinkClicks(1, today()))
+	assert.Zero(t, repo.Views(1, today()), "clicks are not written as views")
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	UserOnlineRepository "pinset/internal/app/repository/user_online_repository"
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSaveRepository adds boards and saves of pins to the pin repository.
+type fakeSaveRepository struct {
+	*fakePinRepository
+
+	boards    map[uint64]*models.Board
+	boardPins map[uint64]map[uint64]bool
+	saves     map[uint64]uint64
+	// notifications stored with the saves
+	notifications []*models.Notification
+	savedFrom     []*models.PinSaveLink
+}
+
+func newFakeSaveRepository(pins ...*models.Pin) *fakeSaveRepository {
+	return &fakeSaveRepository{
+		fakePinRepository: newFakePinRepository(pins...),
+		boards:            make(map[uint64]*models.Board),
+		boardPins:         make(map[uint64]map[uint64]bool),
+		saves:             make(map[uint64]uint64),
+	}
+}
+
+func (r *fakeSaveRepository) addBoard(board *models.Board, pinIDs ...uint64) {
+	r.boards[board.BoardID] = board
+	r.boardPins[board.BoardID] = make(map[uint64]bool)
+	for _, pinID := range pinIDs {
+		r.boardPins[board.BoardID][pinID] = true
+	}
+}
+
+func (r *fakeSaveRepository) GetBoardByBoardID(boardID uint64) (*models.Board, error) {
+	board, ok := r.boards[boardID]
+	if !ok {
+		return nil, internal_errors.ErrBoardDoesntExists
+	}
+	copied := *board
+	return &copied, nil
+}
+
+func (r *fakeSaveRepository) IsBoardSharedWithUser(boardID, userID uint64) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeSaveRepository) IsPinInBoard(boardID, pinID uint64) (bool, error) {
+	return r.boardPins[boardID][pinID], nil
+}
+
+func (r *fakeSaveRepository) GetPinAuthorNickNameByUserID(userID uint64) (*models.UserPin, error) {
+	return &models.UserPin{UserID: userID, NickName: "user"}, nil
+}
+
+func (r *fakeSaveRepository) SavePin(save *models.PinSave, notification *models.Notification) error {
+	r.boardPins[save.BoardID][save.PinID] = true
+	r.saves[save.PinID]++
+	save.Saves = r.saves[save.PinID]
+	if notification != nil {
+		r.notifications = append(r.notifications, notification)
+	}
+	return nil
+}
+
+func (r *fakeSaveRepository) GetPinSavedFrom(pinID, boardID uint64, maxDepth int) ([]*models.PinSaveLink, error) {
+	return append([]*models.PinSaveLink(nil), r.savedFrom[:min(maxDepth, len(r.savedFrom))]...), nil
+}
+
+// newSaveFixture has the pin of user 1 on the public board of user 2 and the private board of user 3,
+// user 4 saves pins to their board 4
+func newSaveFixture() (*fakeSaveRepository, *fakeUserRepository) {
+	repo := newFakeSaveRepository(
+		&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished},
+		&models.Pin{PinID: 2, AuthorID: 1, Status: models.PinStatusDraft},
+		&models.Pin{PinID: 3, AuthorID: 4, Status: models.PinStatusPublished},
+	)
+	repo.addBoard(&models.Board{BoardID: 2, OwnerID: 2, Public: true}, 1)
+	repo.addBoard(&models.Board{BoardID: 3, OwnerID: 3}, 1)
+	repo.addBoard(&models.Board{BoardID: 4, OwnerID: 4, Public: true})
+	repo.addBoard(&models.Board{BoardID: 5, OwnerID: 4})
+	return repo, newFakeUserRepository(1, 2, 3, 4)
+}
+
+func TestSavePin(t *testing.T) {
+	repo, users := newSaveFixture()
+	muc := usecase.NewMediaUsecase(repo, users, UserOnlineRepository.NewUserOnlineRepository(), nil, nil)
+
+	save, err := muc.SavePin(&models.PinSaveRequest{PinID: 1, SaverID: 4, BoardID: 4, FromBoardID: 2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint64(1), save.Saves)
+	if assert.NotNil(t, save.FromBoardID) && assert.NotNil(t, save.FromUserID) {
+		assert.Equal(t, uint64(2), *save.FromBoardID)
+		assert.Equal(t, uint64(2), *save.FromUserID, "the pin is attributed to the owner of the board")
+	}
+	assert.True(t, repo.boardPins[4][1])
+
+	if assert.Len(t, repo.notifications, 1) {
+		notification := repo.notifications[0]
+		assert.Equal(t, uint64(1), notification.RecipientID)
+		assert.Equal(t, uint64(4), notification.ActorID)
+		assert.Equal(t, models.NotificationTypePinSaved, notification.Type)
+		if assert.NotNil(t, notification.BoardID) {
+			assert.Equal(t, uint64(4), *notification.BoardID)
+		}
+	}
+
+	save, err = muc.SavePin(&models.PinSaveRequest{PinID: 1, SaverID: 4, BoardID: 5, FromBoardID: 4})
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(2), save.Saves)
+	}
+	if assert.Len(t, repo.notifications, 2) {
+		assert.Nil(t, repo.notifications[1].BoardID, "private boards are not shown to the author")
+	}
+}
+
+func TestSavePinWithoutNotification(t *testing.T) {
+	repo, users := newSaveFixture()
+	users.Block(1, 4)
+	muc := usecase.NewMediaUsecase(repo, users, UserOnlineRepository.NewUserOnlineRepository(), nil, nil)
+
+	_, err := muc.SavePin(&models.PinSaveRequest{PinID: 3, SaverID: 4, BoardID: 4})
+	assert.NoError(t, err, "authors save their own pins")
+	_, err = muc.SavePin(&models.PinSaveRequest{PinID: 1, SaverID: 4, BoardID: 4})
+	assert.NoError(t, err, "the pin of a user who blocked the saver is saved silently")
+	assert.Empty(t, repo.notifications)
+}
+
+func TestSavePinRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.PinSaveRequest
+		err  error
+	}{
+		{name: "missing pin", req: models.PinSaveRequest{PinID: 9, SaverID: 4, BoardID: 4}, err: internal_errors.ErrPinDoesntExists},
+		{name: "draft of another user", req: models.PinSaveRequest{PinID: 2, SaverID: 4, BoardID: 4}, err: internal_errors.ErrPinDoesntExists},
+		{name: "board of another user", req: models.PinSaveRequest{PinID: 1, SaverID: 4, BoardID: 2}, err: internal_errors.ErrBoardDoesntExists},
+		{name: "from a private board", req: models.PinSaveRequest{PinID: 1, SaverID: 4, BoardID: 4, FromBoardID: 3}, err: internal_errors.ErrBoardDoesntExists},
+		{name: "from a board without the pin", req: models.PinSaveRequest{PinID: 3, SaverID: 4, BoardID: 4, FromBoardID: 2}, err: internal_errors.ErrPinNotInBoard},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, users := newSaveFixture()
+			muc := usecase.NewMediaUsecase(repo, users, UserOnlineRepository.NewUserOnlineRepository(), nil, nil)
+
+			_, err := muc.SavePin(&test.req)
+			assert.ErrorIs(t, err, test.err)
+			assert.Empty(t, repo.saves)
+			assert.Empty(t, repo.notifications)
+		})
+	}
+}
+
+func TestGetPinSavedFrom(t *testing.T) {
+	repo, users := newSaveFixture()
+	users.Block(4, 3)
+	boardID := uint64(2)
+	repo.savedFrom = []*models.PinSaveLink{
+		{UserID: 2, BoardID: &boardID},
+		{UserID: 3},
+		{UserID: 1},
+	}
+	muc := usecase.NewMediaUsecase(repo, users, nil, nil, nil)
+
+	links, err := muc.GetPinSavedFrom(1, 4, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.PinSaveLink{repo.savedFrom[0], repo.savedFrom[2]}, links, "blocked users are skipped")
+
+	_, err = muc.GetPinSavedFrom(1, 5, 1)
+	assert.ErrorIs(t, err, internal_errors.ErrBoardDoesntExists, "the board must be visible to the viewer")
+}
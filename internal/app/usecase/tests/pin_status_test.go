@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePinStatus(t *testing.T) {
+	useMemoryBackend(t)
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name      string
+		status    string
+		publishAt *time.Time
+		want      string
+		err       error
+	}{
+		{name: "published by default", want: models.PinStatusPublished},
+		{name: "draft", status: models.PinStatusDraft, want: models.PinStatusDraft},
+		{name: "draft drops the publishing time", status: models.PinStatusDraft, publishAt: &future, want: models.PinStatusDraft},
+		{name: "only a publishing time", publishAt: &future, want: models.PinStatusScheduled},
+		{name: "scheduled", status: models.PinStatusScheduled, publishAt: &future, want: models.PinStatusScheduled},
+		{name: "scheduled without a time", status: models.PinStatusScheduled, err: internal_errors.ErrPinPublishAtInvalid},
+		{name: "scheduled in the past", publishAt: &past, err: internal_errors.ErrPinPublishAtInvalid},
+		{name: "unknown status", status: "archived", err: internal_errors.ErrPinStatusInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newFakeMediaRepository(t)
+			muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+			err := muc.CreatePin(&models.Pin{AuthorID: 1, Status: test.status, PublishAt: test.publishAt})
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				assert.Empty(t, repo.pins)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, repo.pins, 1) {
+				assert.Equal(t, test.want, repo.pins[0].Status)
+				if test.want == models.PinStatusScheduled {
+					assert.Equal(t, test.publishAt, repo.pins[0].PublishAt)
+				} else {
+					assert.Nil(t, repo.pins[0].PublishAt)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdatePinStatus(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+	repo := newFakePinRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusScheduled, PublishAt: &publishAt})
+	muc := usecase.NewMediaUsecase(repo, newFakeUserRepository(1, 2), nil, nil, nil)
+
+	assert.ErrorIs(t, muc.UpdatePinInfo(&models.Pin{PinID: 1, AuthorID: 2, Status: models.PinStatusPublished}),
+		internal_errors.ErrUserIsNotPinAuthor)
+	assert.ErrorIs(t, muc.UpdatePinInfo(&models.Pin{PinID: 1, AuthorID: 1, Status: "archived"}),
+		internal_errors.ErrPinStatusInvalid)
+
+	assert.NoError(t, muc.UpdatePinInfo(&models.Pin{PinID: 1, AuthorID: 1}))
+	assert.Equal(t, models.PinStatusScheduled, repo.pins[1].Status, "the status is kept unless given")
+	assert.Equal(t, &publishAt, repo.pins[1].PublishAt)
+
+	_, err := muc.GetPinPreviewInfo(1, 2)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists, "unpublished pins are seen only by their author")
+	_, err = muc.GetPinPreviewInfo(1, 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, muc.UpdatePinInfo(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished}))
+	assert.Equal(t, models.PinStatusPublished, repo.pins[1].Status)
+	assert.Nil(t, repo.pins[1].PublishAt)
+
+	_, err = muc.GetPinPreviewInfo(1, 2)
+	assert.NoError(t, err)
+}
+
+func TestPinSchedulerPublishesDuePins(t *testing.T) {
+	t.Setenv("PIN_SCHEDULER_BATCH_SIZE", "2")
+
+	at := func(offset time.Duration) *time.Time {
+		publishAt := time.Now().Add(offset)
+		return &publishAt
+	}
+	repo := newFakePinRepository(
+		&models.Pin{PinID: 1, Status: models.PinStatusScheduled, PublishAt: at(-time.Minute)},
+		&models.Pin{PinID: 2, Status: models.PinStatusScheduled, PublishAt: at(-time.Hour)},
+		&models.Pin{PinID: 3, Status: models.PinStatusScheduled, PublishAt: at(-2 * time.Hour)},
+		&models.Pin{PinID: 4, Status: models.PinStatusScheduled, PublishAt: at(time.Hour)},
+		&models.Pin{PinID: 5, Status: models.PinStatusDraft},
+	)
+	scheduler := usecase.NewPinScheduler(repo)
+
+	published, err := scheduler.PublishDue()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 2, 1}, published, "all due pins are published over several batches")
+	assert.Equal(t, models.PinStatusScheduled, repo.pins[4].Status)
+	assert.Equal(t, models.PinStatusDraft, repo.pins[5].Status)
+
+	published, err = scheduler.PublishDue()
+	assert.NoError(t, err)
+	assert.Empty(t, published, "published pins are not published again")
+}
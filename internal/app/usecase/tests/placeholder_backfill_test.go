@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/mediaurl"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackfillRepository pages through media and image keys without a placeholder
+// and keeps objects in memory by media key.
+// Methods that are not overridden panic, the tests must not reach them.
+type fakeBackfillRepository struct {
+	usecase.MediaRepository
+
+	media     []*models.Media
+	imageKeys []string
+	objects   map[string][]byte
+
+	mediaPlaceholders map[uint64]imageproc.Placeholder
+	keyPlaceholders   map[string]imageproc.Placeholder
+	keySizes          map[string][2]int
+	pages             int
+}
+
+func newFakeBackfillRepository() *fakeBackfillRepository {
+	return &fakeBackfillRepository{
+		objects:           make(map[string][]byte),
+		mediaPlaceholders: make(map[uint64]imageproc.Placeholder),
+		keyPlaceholders:   make(map[string]imageproc.Placeholder),
+		keySizes:          make(map[string][2]int),
+	}
+}
+
+func (r *fakeBackfillRepository) GetMediaWithoutPlaceholder(afterMediaID uint64, limit int) ([]*models.Media, error) {
+	r.pages++
+	var page []*models.Media
+	for _, media := range r.media {
+		if _, ok := r.mediaPlaceholders[media.MediaID]; ok || media.MediaID <= afterMediaID {
+			continue
+		}
+		if page = append(page, media); len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+func (r *fakeBackfillRepository) UpdateMediaPlaceholder(mediaID uint64, placeholder imageproc.Placeholder) error {
+	r.mediaPlaceholders[mediaID] = placeholder
+	return nil
+}
+
+func (r *fakeBackfillRepository) GetImageKeysWithoutPlaceholder(afterMediaKey string, limit int) ([]string, error) {
+	r.pages++
+	sort.Strings(r.imageKeys)
+	var page []string
+	for _, mediaKey := range r.imageKeys {
+		if _, ok := r.keyPlaceholders[mediaKey]; ok || mediaKey <= afterMediaKey {
+			continue
+		}
+		if page = append(page, mediaKey); len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+func (r *fakeBackfillRepository) UpdateImageKeyPlaceholder(mediaKey string, width, height int, placeholder imageproc.Placeholder) error {
+	r.keyPlaceholders[mediaKey] = placeholder
+	r.keySizes[mediaKey] = [2]int{width, height}
+	return nil
+}
+
+func (r *fakeBackfillRepository) OpenObject(bucketName, objectName string) (io.ReadCloser, error) {
+	data, ok := r.objects[mediaurl.JoinKey(bucketName, objectName)]
+	if !ok {
+		return nil, errors.New("object doesn't exist")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (r *fakeBackfillRepository) putImage(t *testing.T, bucketName, objectName string, width, height int) {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:i+4], []byte{30, 120, 200, 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode image: %s", err)
+	}
+	r.objects[mediaurl.JoinKey(bucketName, objectName)] = buf.Bytes()
+}
+
+func TestPlaceholderBackfillRegistry(t *testing.T) {
+	repo := newFakeBackfillRepository()
+	for mediaID := uint64(1); mediaID <= 5; mediaID++ {
+		objectName := string(rune('a'+mediaID-1)) + ".png"
+		repo.media = append(repo.media, &models.Media{MediaID: mediaID, BucketName: "images", ObjectKey: objectName})
+		if mediaID != 3 {
+			repo.putImage(t, "images", objectName, 20, 10)
+		}
+	}
+
+	result, err := usecase.NewPlaceholderBackfill(repo, 2).Run()
+	assert.Error(t, err, "the missing object is reported")
+	assert.Equal(t, usecase.BackfillResult{Updated: 4, Failed: 1}, result)
+	assert.Len(t, repo.mediaPlaceholders, 4)
+	assert.NotContains(t, repo.mediaPlaceholders, uint64(3))
+	assert.Equal(t, "#1e78c8", repo.mediaPlaceholders[1].DominantColor)
+	assert.Equal(t, 4+1, repo.pages, "3 pages of media and 1 empty page of image keys")
+}
+
+func TestPlaceholderBackfillImageKeys(t *testing.T) {
+	repo := newFakeBackfillRepository()
+	repo.putImage(t, "images", "legacy.png", 30, 40)
+	repo.objects[mediaurl.JoinKey("images", "broken.png")] = []byte("not an image")
+	repo.imageKeys = []string{
+		mediaurl.JoinKey("images", "legacy.png"),
+		mediaurl.JoinKey("images", "broken.png"),
+		"not a media key",
+	}
+
+	result, err := usecase.NewPlaceholderBackfill(repo, 10).Run()
+	assert.ErrorIs(t, err, imageproc.ErrUnsupportedFormat)
+	assert.Equal(t, usecase.BackfillResult{Updated: 1, Failed: 2}, result)
+	assert.Equal(t, [2]int{30, 40}, repo.keySizes[mediaurl.JoinKey("images", "legacy.png")])
+	assert.NotEmpty(t, repo.keyPlaceholders[mediaurl.JoinKey("images", "legacy.png")].Blurhash)
+
+	result, err = usecase.NewPlaceholderBackfill(repo, 10).Run()
+	assert.Error(t, err, "failed images are retried by the next run")
+	assert.Equal(t, usecase.BackfillResult{Failed: 2}, result)
+}
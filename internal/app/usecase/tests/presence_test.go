@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	UserOnlineRepository "pinset/internal/app/repository/user_online_repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisconnectUserKeepsReconnectedUserOnline(t *testing.T) {
+	online := UserOnlineRepository.NewUserOnlineRepository()
+	users := newFakeUserRepository(1)
+	muc := usecase.NewMessageUsecase(online, newFakeChatRepository(), users)
+
+	stale := &models.ChatUser{ID: 1}
+	active := &models.ChatUser{ID: 1}
+	assert.NoError(t, muc.ConnectUser(stale))
+	assert.NoError(t, muc.ConnectUser(active))
+	assert.Contains(t, users.lastSeen, uint64(1))
+
+	wentOffline, err := muc.DisconnectUser(stale)
+	assert.NoError(t, err)
+	assert.False(t, wentOffline, "the stale connection closes after the user reconnected")
+	assert.True(t, online.IsOnlineUser(1))
+
+	wentOffline, err = muc.DisconnectUser(active)
+	assert.NoError(t, err)
+	assert.True(t, wentOffline)
+	assert.False(t, online.IsOnlineUser(1))
+}
+
+func TestGetPresenceReceivers(t *testing.T) {
+	online := UserOnlineRepository.NewUserOnlineRepository()
+	users := newFakeUserRepository(1, 2, 3, 4)
+	chats := newFakeChatRepository()
+	muc := usecase.NewMessageUsecase(online, chats, users)
+
+	for _, companionID := range []uint64{2, 3} {
+		_, err := muc.CreateChat(&models.ChatCreateRequest{UserID: 1, CompanionID: companionID})
+		assert.NoError(t, err)
+	}
+	for _, userID := range []uint64{1, 2, 4} {
+		assert.NoError(t, muc.ConnectUser(&models.ChatUser{ID: userID}))
+	}
+
+	presence, receiverIDs, err := muc.GetPresenceReceivers(1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, presence) {
+		assert.True(t, presence.Online)
+		assert.NotNil(t, presence.LastSeen)
+	}
+	assert.Equal(t, []uint64{2}, receiverIDs, "only online companions are notified")
+
+	assert.NoError(t, muc.UpdatePresenceSettings(1, &models.PresenceSettings{HidePresence: true}))
+	presence, receiverIDs, err = muc.GetPresenceReceivers(1)
+	assert.NoError(t, err)
+	assert.Nil(t, presence)
+	assert.Empty(t, receiverIDs)
+}
+
+func TestGetUsersPresence(t *testing.T) {
+	online := UserOnlineRepository.NewUserOnlineRepository()
+	users := newFakeUserRepository(1, 2, 3)
+	muc := usecase.NewMessageUsecase(online, newFakeChatRepository(), users)
+
+	assert.NoError(t, muc.ConnectUser(&models.ChatUser{ID: 2}))
+	assert.NoError(t, muc.ConnectUser(&models.ChatUser{ID: 3}))
+	assert.NoError(t, muc.UpdatePresenceSettings(3, &models.PresenceSettings{HidePresence: true}))
+
+	presences, err := muc.GetUsersPresence(1, []uint64{3, 2, 5, 2})
+	assert.NoError(t, err)
+	if assert.Len(t, presences, 2, "unknown users are skipped, duplicates are answered once") {
+		assert.Equal(t, &models.Presence{UserID: 3}, presences[0], "hidden presence is shown offline")
+		assert.Equal(t, uint64(2), presences[1].UserID)
+		assert.True(t, presences[1].Online)
+		assert.NotNil(t, presences[1].LastSeen)
+	}
+
+	presences, err = muc.GetUsersPresence(3, []uint64{3})
+	assert.NoError(t, err)
+	if assert.Len(t, presences, 1) {
+		assert.True(t, presences[0].Online, "users see their own hidden presence")
+	}
+}
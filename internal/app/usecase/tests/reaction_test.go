@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeReactionRepository keeps the reaction of every user on pins with toggle semantics.
+type fakeReactionRepository struct {
+	*fakePinRepository
+
+	// reactions by pin, then user
+	reactions map[uint64]map[uint64]string
+}
+
+func newFakeReactionRepository(pins ...*models.Pin) *fakeReactionRepository {
+	return &fakeReactionRepository{
+		fakePinRepository: newFakePinRepository(pins...),
+		reactions:         make(map[uint64]map[uint64]string),
+	}
+}
+
+func (r *fakeReactionRepository) TogglePinReaction(pinID, userID uint64, reactionType string) (*string, error) {
+	if r.reactions[pinID] == nil {
+		r.reactions[pinID] = make(map[uint64]string)
+	}
+	if r.reactions[pinID][userID] == reactionType {
+		delete(r.reactions[pinID], userID)
+		return nil, nil
+	}
+	r.reactions[pinID][userID] = reactionType
+	return &reactionType, nil
+}
+
+func (r *fakeReactionRepository) GetPinsReactions(pinIDs []uint64, viewerID uint64) (map[uint64]*models.PinReactions, error) {
+	reactions := make(map[uint64]*models.PinReactions, len(pinIDs))
+	for _, pinID := range pinIDs {
+		pinReactions := &models.PinReactions{Counts: map[string]uint64{}}
+		for userID, reactionType := range r.reactions[pinID] {
+			pinReactions.Counts[reactionType]++
+			if userID == viewerID {
+				pinReactions.MyReaction = &reactionType
+			}
+		}
+		reactions[pinID] = pinReactions
+	}
+	return reactions, nil
+}
+
+func TestTogglePinReaction(t *testing.T) {
+	repo := newFakeReactionRepository(&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusPublished})
+	muc := usecase.NewMediaUsecase(repo, newFakeUserRepository(1, 2, 3), nil, nil, nil)
+
+	toggle := func(userID uint64, reactionType string) *models.PinReactions {
+		t.Helper()
+		reactions, err := muc.TogglePinReaction(1, userID, &models.PinReactionRequest{Type: reactionType})
+		assert.NoError(t, err)
+		return reactions
+	}
+
+	reactions := toggle(2, models.ReactionLike)
+	assert.Equal(t, map[string]uint64{models.ReactionLike: 1}, reactions.Counts)
+	if assert.NotNil(t, reactions.MyReaction) {
+		assert.Equal(t, models.ReactionLike, *reactions.MyReaction)
+	}
+
+	reactions = toggle(3, models.ReactionLike)
+	assert.Equal(t, map[string]uint64{models.ReactionLike: 2}, reactions.Counts)
+
+	reactions = toggle(2, models.ReactionWow)
+	assert.Equal(t, map[string]uint64{models.ReactionLike: 1, models.ReactionWow: 1}, reactions.Counts, "another type replaces the reaction")
+	if assert.NotNil(t, reactions.MyReaction) {
+		assert.Equal(t, models.ReactionWow, *reactions.MyReaction)
+	}
+
+	reactions = toggle(2, models.ReactionWow)
+	assert.Equal(t, map[string]uint64{models.ReactionLike: 1}, reactions.Counts, "the same type removes the reaction")
+	assert.Nil(t, reactions.MyReaction)
+
+	pin, err := muc.GetPinPageInfo(1, 3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]uint64{models.ReactionLike: 1}, pin.Counts)
+		if assert.NotNil(t, pin.MyReaction) {
+			assert.Equal(t, models.ReactionLike, *pin.MyReaction, "the page shows the reaction of the viewer")
+		}
+	}
+}
+
+func TestTogglePinReactionOnHiddenPins(t *testing.T) {
+	repo := newFakeReactionRepository(
+		&models.Pin{PinID: 1, AuthorID: 1, Status: models.PinStatusDraft},
+		&models.Pin{PinID: 2, AuthorID: 2, Status: models.PinStatusPublished},
+	)
+	users := newFakeUserRepository(1, 2, 3)
+	users.Block(3, 2)
+	muc := usecase.NewMediaUsecase(repo, users, nil, nil, nil)
+
+	like := &models.PinReactionRequest{Type: models.ReactionLike}
+	_, err := muc.TogglePinReaction(1, 1, like)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists, "drafts get no reactions, even from their author")
+	_, err = muc.TogglePinReaction(2, 3, like)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists)
+	_, err = muc.TogglePinReaction(9, 3, like)
+	assert.ErrorIs(t, err, internal_errors.ErrPinDoesntExists)
+	assert.Empty(t, repo.reactions)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"image/color"
+	"pinset/internal/app/models"
+	"pinset/internal/app/usecase"
+	"pinset/pkg/mediaurl"
+	"testing"
+
+	internal_errors "pinset/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// putUpload stores the content of the upload as if the client has sent it
+func putUpload(t *testing.T, repo *fakeMediaRepository, uploadID string, content []byte) *models.Upload {
+	t.Helper()
+
+	upload, err := repo.GetUpload(uploadID)
+	if err != nil {
+		t.Fatalf("get upload: %s", err)
+	}
+	if err := repo.Store.Put(upload.BucketName, upload.ObjectName, bytes.NewReader(content), int64(len(content)), upload.ContentType); err != nil {
+		t.Fatalf("put upload: %s", err)
+	}
+	return upload
+}
+
+func TestPresignUpload(t *testing.T) {
+	useMemoryBackend(t)
+	t.Setenv("MEDIA_MAX_IMAGE_SIZE", "1024")
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+
+	presigned, err := muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "image/png", Size: 1024})
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, presigned.UploadID)
+		assert.NotEmpty(t, presigned.Url)
+		assert.Equal(t, "POST", presigned.Method)
+		assert.Equal(t, "image/png", presigned.Fields["Content-Type"], "the declared type is a field of the signed form")
+		if upload, err := repo.GetUpload(presigned.UploadID); assert.NoError(t, err) {
+			assert.Equal(t, upload.ObjectName, presigned.Fields["key"])
+		}
+	}
+
+	_, err = muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "image/png", Size: 1025})
+	assert.ErrorIs(t, err, internal_errors.ErrMediaTooLarge)
+
+	_, err = muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "text/html", Size: 10})
+	assert.ErrorIs(t, err, internal_errors.ErrWrongMediaContentType)
+
+	_, err = muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "image/png"})
+	assert.ErrorIs(t, err, internal_errors.ErrBadRequest)
+}
+
+func TestCompleteUploadProcessesImage(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+	content := testPNG(t, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+
+	presigned, err := muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "image/png", Size: int64(len(content))})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = muc.CompleteUpload(1, presigned.UploadID)
+	assert.ErrorIs(t, err, internal_errors.ErrUploadNotFinished)
+
+	raw := putUpload(t, repo, presigned.UploadID, content)
+
+	_, err = muc.CompleteUpload(2, presigned.UploadID)
+	assert.ErrorIs(t, err, internal_errors.ErrUploadDoesntExists, "uploads of other users look missing")
+
+	upload, err := muc.CompleteUpload(1, presigned.UploadID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.UploadStatusCompleted, upload.Status)
+	if assert.NotNil(t, upload.MediaKey) {
+		bucketName, objectName, ok := mediaurl.SplitKey(*upload.MediaKey)
+		assert.True(t, ok)
+		_, err := repo.Store.Stat(bucketName, objectName)
+		assert.NoError(t, err, "the processed image is stored")
+	}
+	_, err = repo.Store.Stat(raw.BucketName, raw.ObjectName)
+	assert.Error(t, err, "the raw object is replaced by the processed one")
+
+	again, err := muc.CompleteUpload(1, presigned.UploadID)
+	assert.NoError(t, err)
+	assert.Equal(t, upload.MediaKey, again.MediaKey, "completing twice returns the same media")
+	assert.Equal(t, 1, repo.MediaCount())
+}
+
+func TestCompleteUploadRemovesWrongObjects(t *testing.T) {
+	useMemoryBackend(t)
+	repo := newFakeMediaRepository(t)
+	muc := usecase.NewMediaUsecase(repo, nil, nil, nil, nil)
+	content := testPNG(t, color.RGBA{B: 90, A: 255})
+
+	tests := []struct {
+		name    string
+		size    int64
+		content []byte
+		err     error
+	}{
+		{name: "size mismatch", size: int64(len(content)) + 1, content: content, err: internal_errors.ErrUploadSizeMismatch},
+		{name: "not an image", size: 18, content: []byte("plain text content"), err: internal_errors.ErrWrongMediaContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presigned, err := muc.PresignUpload(1, &models.UploadPresignRequest{ContentType: "image/png", Size: tt.size})
+			if !assert.NoError(t, err) {
+				return
+			}
+			raw := putUpload(t, repo, presigned.UploadID, tt.content)
+
+			_, err = muc.CompleteUpload(1, presigned.UploadID)
+			assert.ErrorIs(t, err, tt.err)
+			_, err = repo.Store.Stat(raw.BucketName, raw.ObjectName)
+			assert.Error(t, err, "the object is removed")
+			assert.Zero(t, repo.MediaCount())
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"pinset/internal/app/models"
+	"time"
+
+	internal_errors "pinset/internal/errors"
+)
+
+const presignedUploadMethod = "POST"
+
+// PresignUpload reserves an object key for the declared media and returns a form to upload it directly into the storage.
+func (muc *MediaUsecaseController) PresignUpload(userID uint64, req *models.UploadPresignRequest) (*models.PresignedUpload, error) {
+	if err := req.Valid(); err != nil {
+		return nil, err
+	}
+	if !muc.repo.HasCorrectContentType(req.ContentType) {
+		return nil, internal_errors.ErrWrongMediaContentType
+	}
+	if req.Size > muc.uploadParams.MaxSizeForContentType(req.ContentType) {
+		return nil, internal_errors.ErrMediaTooLarge
+	}
+
+	upload := &models.Upload{
+		UserID:      userID,
+		BucketName:  muc.repo.GetBucketNameForContentType(req.ContentType),
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		ExpiresAt:   time.Now().Add(muc.uploadParams.PresignExpiration),
+	}
+	if err := muc.repo.CreateUpload(upload); err != nil {
+		return nil, fmt.Errorf("presignUpload usecase: %w", err)
+	}
+
+	url, fields, err := muc.repo.PresignUploadForm(upload, muc.uploadParams.PresignExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("presignUpload usecase: %w", err)
+	}
+
+	return &models.PresignedUpload{
+		UploadID:  upload.UploadID,
+		Url:       url,
+		Method:    presignedUploadMethod,
+		Fields:    fields,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload checks the object put by the client and registers it as media.
+// Objects with a size other than declared or a real type of another media kind are removed.
+// Images go through the same processing as the ones uploaded through the API.
+// Completing an already completed upload returns it unchanged.
+func (muc *MediaUsecaseController) CompleteUpload(userID uint64, uploadID string) (*models.Upload, error) {
+	upload, err := muc.repo.GetUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.UserID != userID {
+		return nil, internal_errors.ErrUploadDoesntExists
+	}
+	if upload.Status == models.UploadStatusCompleted {
+		return upload, nil
+	}
+
+	size, err := muc.repo.GetObjectSize(upload.BucketName, upload.ObjectName)
+	if err != nil {
+		return nil, err
+	}
+	if size != upload.Size {
+		muc.removeUploadedObject(upload)
+		return nil, internal_errors.ErrUploadSizeMismatch
+	}
+
+	fileType, err := muc.sniffUploadedObject(upload)
+	if err != nil {
+		return nil, err
+	}
+	if !muc.repo.HasCorrectContentType(fileType) || muc.repo.GetBucketNameForContentType(fileType) != upload.BucketName {
+		muc.removeUploadedObject(upload)
+		return nil, internal_errors.ErrWrongMediaContentType
+	}
+
+	var mediaKey string
+	if muc.repo.HasImageContentType(fileType) {
+		mediaKey, err = muc.processUploadedImage(upload)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// The object is not hashed: reading it back would bring the traffic the presigned upload avoids
+		media := newMedia(upload.UserID, upload.BucketName)
+		media.ObjectKey = upload.ObjectName
+		media.ContentType = fileType
+		media.Size = size
+		if muc.repo.HasVideoContentType(fileType) || muc.repo.HasAudioContentType(fileType) {
+			if err := muc.probeUploadedMedia(upload, media); err != nil {
+				return nil, err
+			}
+		}
+		if err := muc.repo.CreateMedia(media); err != nil {
+			return nil, fmt.Errorf("completeUpload usecase: %w", err)
+		}
+		mediaKey = media.MediaKey
+	}
+
+	upload.ContentType = fileType
+	upload.MediaKey = &mediaKey
+	if err := muc.repo.CompleteUpload(upload); err != nil {
+		return nil, fmt.Errorf("completeUpload usecase: %w", err)
+	}
+
+	return upload, nil
+}
+
+func (muc *MediaUsecaseController) sniffUploadedObject(upload *models.Upload) (string, error) {
+	object, err := muc.repo.OpenObject(upload.BucketName, upload.ObjectName)
+	if err != nil {
+		return "", fmt.Errorf("sniff uploaded object: %w", err)
+	}
+	defer object.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(object, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("sniff uploaded object: %w", err)
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// processUploadedImage replaces the raw object with the processed image and its variants.
+// The size of the object has already been checked against the image limit.
+func (muc *MediaUsecaseController) processUploadedImage(upload *models.Upload) (string, error) {
+	object, err := muc.repo.OpenObject(upload.BucketName, upload.ObjectName)
+	if err != nil {
+		return "", fmt.Errorf("read uploaded image: %w", err)
+	}
+	defer object.Close()
+
+	mediaBytes, err := io.ReadAll(object)
+	if err != nil {
+		return "", fmt.Errorf("read uploaded image: %w", err)
+	}
+
+	mediaKey, err := uploadImage(muc.repo, newMedia(upload.UserID, upload.BucketName), mediaBytes)
+	if err != nil {
+		if errors.Is(err, internal_errors.ErrWrongMediaContentType) {
+			muc.removeUploadedObject(upload)
+		}
+		return "", err
+	}
+
+	muc.removeUploadedObject(upload)
+	return mediaKey, nil
+}
+
+// probeUploadedMedia reads a video up to the moov box, so a video not prepared for streaming
+// (moov at the end) is read whole. Audio is always read whole.
+func (muc *MediaUsecaseController) probeUploadedMedia(upload *models.Upload, media *models.Media) error {
+	object, err := muc.repo.OpenObject(upload.BucketName, upload.ObjectName)
+	if err != nil {
+		return fmt.Errorf("read uploaded media: %w", err)
+	}
+	defer object.Close()
+
+	if err := probeMedia(muc.repo, media, media.ContentType, object); err != nil {
+		if errors.Is(err, internal_errors.ErrWrongMediaContentType) {
+			muc.removeUploadedObject(upload)
+		}
+		return err
+	}
+	return nil
+}
+
+// removeUploadedObject is best effort: a leftover object is harmless, it is never referenced.
+func (muc *MediaUsecaseController) removeUploadedObject(upload *models.Upload) {
+	_ = muc.repo.RemoveObject(upload.BucketName, upload.ObjectName)
+}
@@ -6,6 +6,9 @@ import (
 	"pinset/internal/app/models"
 	"pinset/internal/app/models/response"
 	"pinset/internal/app/session"
+	"pinset/pkg/imageproc"
+	"pinset/pkg/linkpreview"
+	"time"
 )
 
 //go:generate mockgen -source=usecase.go -destination=mocks/usecase_mock.go
@@ -24,7 +27,7 @@ type (
 		UpdateUserPassword(*models.User) error
 		DeleteUserByID(uint64) error
 
-		GetUsersByParams(*models.UserSearchParams) ([]*models.UserInfo, error)
+		GetUsersByParams(callerID uint64, userParams *models.UserSearchParams) ([]*models.UserInfo, error)
 
 		FollowUser(uint64, uint64) error
 		UnfollowUser(uint64, uint64) error
@@ -33,27 +36,47 @@ type (
 		GetFollowingsCount(uint64) (uint64, error)
 		GetSubsriptionsCount(uint64) (uint64, error)
 
+		BlockUser(blockerID, blockedID uint64) error
+		UnblockUser(blockerID, blockedID uint64) error
+		GetBlockedUsers(blockerID uint64) ([]*models.BlockedUser, error)
+		GetBlockedUserIDs(blockerID uint64) ([]uint64, error)
+		GetBlockerUserIDs(blockedID uint64) ([]uint64, error)
+		GetBlockRelatedUserIDs(userID uint64) ([]uint64, error)
+		IsBlockedBetween(firstUserID, secondUserID uint64) (bool, error)
+
+		UpdateUserLastSeen(userID uint64) error
+		UpdateUserPresenceSettings(userID uint64, settings *models.PresenceSettings) error
+		GetUsersPresenceInfo(userIDs []uint64) ([]*models.UserPresenceInfo, error)
+
 		UserHasActiveSession(string) bool
 		Session() *session.SessionsManager
 	}
 
 	MediaRepository interface {
+		UnitOfWork
+
 		CreatePin(pin *models.Pin) error
 		GetAllPins(uint64) ([]*models.Pin, error)
+		GetUnpublishedPinsByAuthorID(authorID uint64) ([]*models.Pin, error)
+		PublishDuePins(limit int) ([]uint64, error)
 		GetPinPreviewInfoByPinID(pinID uint64) (*models.Pin, error)
 		GetPinPageInfoByPinID(pinID uint64) (*models.Pin, error)
 		GetPinAuthorNickNameByUserID(userID uint64) (*models.UserPin, error)
 		UpdatePinInfoByPinID(pin *models.Pin) error
-		UpdatePinViewsByPinID(pinID uint64) error
 		UpdatePinUpdateTimeByPinID() error
 		DeletePinByPinID(pinID uint64) error
 		GetAllCommentariesByPinID(pinID uint64) ([]*models.Comment, error)
 		GetPinBookmarksNumberByPinID(pinID uint64) (uint64, error)
 		GetBookmarkOnUserPin(ownerID, pinID uint64) (uint64, error)
-		CreatePinBookmark(bookmark *models.Bookmark) error
-		DeletePinBookmarkByOwnerIDAndPinID(bookmark models.Bookmark) error
-		UpdateBookmarksCountIncrease(pinID uint64) error
-		UpdateBookmarksCountDecrease(pinID uint64) error
+		GetBookmarkedPins(params *models.BookmarksPageParams) ([]*models.BookmarkedPin, error)
+		GetBookmarkedPinIDs(ownerID uint64, pinIDs []uint64) (map[uint64]bool, error)
+
+		RollupPinStats(since time.Time) (int64, error)
+		SnapshotFollowers(day time.Time) (int64, error)
+		GetPinStatsSeries(pinID uint64, statsRange models.AnalyticsRange) ([]*models.DailyPinStats, error)
+		GetBoardStatsSeries(boardID, ownerID uint64, statsRange models.AnalyticsRange) ([]*models.DailyPinStats, error)
+		GetTopPins(params *models.TopPinsParams) ([]*models.TopPin, error)
+		GetFollowerSnapshots(userID uint64, statsRange models.AnalyticsRange) ([]*models.FollowerSnapshot, error)
 
 		GetBoardPinsByBoardID(boardID uint64) ([]uint64, error)
 		AddPinToBoard(boardID uint64, pinID uint64) error
@@ -67,18 +90,98 @@ type (
 
 		GetBucketNameForContentType(fileType string) string
 		HasCorrectContentType(string) bool
-		UploadMedia(string, string, io.Reader, int64) (string, error)
-
-		CreateChat() (*models.ChatCreateInfo, error)
-		AddUserToChat(chatID uint64, userID uint64) error
+		HasImageContentType(string) bool
+		HasVideoContentType(string) bool
+		HasAudioContentType(string) bool
+		IsImageMediaUrl(string) bool
+		MediaUrlType(string) string
+		UploadMedia(media *models.Media, content io.Reader) error
+		UploadImage(media *models.Media, img *imageproc.Result) error
+		PublicMediaUrl(mediaKey string) string
+
+		CreateMedia(media *models.Media) error
+		GetUnreferencedMedia(unreferencedBefore time.Time, limit int) ([]*models.Media, error)
+		DeleteUnreferencedMedia(mediaID uint64, unreferencedBefore time.Time) (bool, error)
+		ReuseMediaByChecksum(bucketName, checksum string) (*models.Media, error)
+		GetSimilarPins(pinID uint64, maxDistance, limit int) ([]uint64, error)
+		GetMediaWithoutPlaceholder(afterMediaID uint64, limit int) ([]*models.Media, error)
+		UpdateMediaPlaceholder(mediaID uint64, placeholder imageproc.Placeholder) error
+		GetImageKeysWithoutPlaceholder(afterMediaKey string, limit int) ([]string, error)
+		UpdateImageKeyPlaceholder(mediaKey string, width, height int, placeholder imageproc.Placeholder) error
+
+		CreateUpload(upload *models.Upload) error
+		GetUpload(uploadID string) (*models.Upload, error)
+		CompleteUpload(upload *models.Upload) error
+		PresignUploadForm(upload *models.Upload, expires time.Duration) (string, map[string]string, error)
+		GetObjectSize(bucketName, objectName string) (int64, error)
+		OpenObject(bucketName, objectName string) (io.ReadCloser, error)
+		RemoveObject(bucketName, objectName string) error
+		GetExpiredUploads(expiredBefore time.Time, limit int) ([]*models.Upload, error)
+		DeletePendingUpload(uploadID string) (bool, error)
+
+		CreateChat(chat *models.Chat) (*models.ChatCreateInfo, error)
+		CreateGroupChat(chat *models.Chat, memberIDs []uint64, msg *models.Message) (*models.ChatCreateInfo, *models.MessageCreateInfo, error)
+		CreateDirectChat(firstUserID, secondUserID uint64) (*models.ChatCreateInfo, error)
+		GetDirectChatID(firstUserID, secondUserID uint64) (uint64, error)
+		GetChatByChatID(chatID uint64) (*models.Chat, error)
+		UpdateChatInfo(chat *models.Chat) error
+		AddUserToChat(chatID uint64, userID uint64, role string) error
+		RemoveUserFromChat(chatID uint64, userID uint64) error
+		GetChatMemberRole(chatID uint64, userID uint64) (string, error)
+		UpdateChatMemberRole(chatID uint64, userID uint64, role string) error
 		GetChatUsers(chatID uint64) ([]uint64, error)
+		GetChatMembers(chatID uint64) ([]*models.ChatMember, error)
 		GetUserChats(userID uint64) ([]uint64, error)
+		GetUserCompanions(userID uint64) ([]uint64, error)
 		DeleteChat(chatID uint64) error
+		UpdateChatMembers(chatID uint64, fn func(repo ChatMembersRepository) error) error
 
 		CreateMessage(msg *models.Message) (*models.MessageCreateInfo, error)
 		DeleteMessage(messageID uint64) error
 		UpdateMessage(msg *models.MessageUpdate) error
-		GetChatMessages(chatID uint64) ([]*models.MessageInfo, error)
+		GetChatMessages(chatID uint64, params *models.MessagesPageParams) ([]*models.MessageInfo, error)
+		GetChatMessage(chatID, messageID uint64) (*models.MessageInfo, error)
+		SearchMessages(params *models.MessageSearchParams) ([]*models.MessageInfo, error)
+		GetMessagesAttachments(messageIDs []uint64) (map[uint64][]*models.MessageAttachment, error)
+		IsBoardSharedWithUser(boardID, userID uint64) (bool, error)
+
+		IsPinInBoard(boardID, pinID uint64) (bool, error)
+		SavePin(save *models.PinSave, notification *models.Notification) error
+		GetPinSavedFrom(pinID, boardID uint64, maxDepth int) ([]*models.PinSaveLink, error)
+		GetNotifications(recipientID uint64, limit int) ([]*models.Notification, error)
+
+		TogglePinReaction(pinID, userID uint64, reactionType string) (*string, error)
+		GetPinsReactions(pinIDs []uint64, viewerID uint64) (map[uint64]*models.PinReactions, error)
+	}
+
+	// ChatMembersRepository holds the statements run while the members of the chat are locked
+	ChatMembersRepository interface {
+		AddUserToChat(chatID uint64, userID uint64, role string) error
+		RemoveUserFromChat(chatID uint64, userID uint64) error
+		GetChatMemberRole(chatID uint64, userID uint64) (string, error)
+		UpdateChatMemberRole(chatID uint64, userID uint64, role string) error
+		GetChatMembers(chatID uint64) ([]*models.ChatMember, error)
+		DeleteChat(chatID uint64) error
+		CreateMessage(msg *models.Message) (*models.MessageCreateInfo, error)
+	}
+
+	// UnitOfWork runs fn in a transaction committed only if fn succeeds
+	UnitOfWork interface {
+		RunInTx(fn func(repo TxRepository) error) error
+	}
+
+	// TxRepository holds the statements that must run within a unit of work
+	TxRepository interface {
+		CreatePinBookmark(bookmark *models.Bookmark) (bool, error)
+		DeletePinBookmark(ownerID, pinID uint64) (bool, error)
+		UpdateBookmarksCountIncrease(pinID uint64) error
+		UpdateBookmarksCountDecrease(pinID uint64) error
+
+		LockPinCounters(afterPinID uint64, limit int) ([]uint64, error)
+		RecountPinBookmarks(pinIDs []uint64) (int, error)
+
+		AddPinViews(views *models.PinViews) error
+		AddPinLinkClicks(clicks *models.PinLinkClicks) error
 	}
 
 	UserOnlineRepo interface {
@@ -86,6 +189,7 @@ type (
 		GetOnlineUser(userID uint64) *models.ChatUser
 		AddOnlineUser(user *models.ChatUser)
 		DeleteOnlineUser(userID uint64)
+		DeleteOnlineUserConnection(user *models.ChatUser) bool
 		NumUsersOnline() int
 	}
 )
@@ -99,13 +203,19 @@ type (
 	}
 
 	MediaUsecaseController struct {
-		repo     MediaRepository
-		userRepo UserRepository
+		repo           MediaRepository
+		userRepo       UserRepository
+		userOnlineRepo UserOnlineRepo
+		uploadParams   configs.UploadParams
+		scraper        *linkpreview.Scraper
+		views          *PinCounter
+		linkClicks     *PinCounter
 	}
 
 	MessageUsecaseController struct {
 		mediaRepo      MediaRepository
 		userOnlineRepo UserOnlineRepo
 		userRepo       UserRepository
+		uploadParams   configs.UploadParams
 	}
 )
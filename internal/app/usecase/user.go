@@ -238,8 +238,9 @@ func (uuc *UserUsecaseController) GetUserInfoPublic(userID uint64) (*response.Us
 	return uuc.repo.GetUserInfoPublic(userID)
 }
 
-func (uuc *UserUsecaseController) GetUsersByParams(userParams *models.UserSearchParams) ([]*models.UserInfo, error) {
-	return uuc.repo.GetUsersByParams(userParams)
+// GetUsersByParams searches users the caller may see, users related to the caller by a block are excluded both ways.
+func (uuc *UserUsecaseController) GetUsersByParams(callerID uint64, userParams *models.UserSearchParams) ([]*models.UserInfo, error) {
+	return uuc.repo.GetUsersByParams(callerID, userParams)
 }
 
 func (uuc *UserUsecaseController) GetCompanionsForUser(userID uint64, userParams *models.UserSearchParams) ([]*models.UserInfo, error) {
@@ -247,10 +248,20 @@ func (uuc *UserUsecaseController) GetCompanionsForUser(userID uint64, userParams
 	if err != nil {
 		return nil, err
 	}
+	// The user and users related to the user by a block are not found by GetUsersByParams
 	prohibitedUsers := make(map[uint64]bool)
-	prohibitedUsers[userID] = true
 
 	for _, chatID := range userChats {
+		chat, err := uuc.mediaRepo.GetChatByChatID(chatID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only users with a direct chat are excluded from companion search
+		if chat.IsGroup {
+			continue
+		}
+
 		chatUsers, err := uuc.mediaRepo.GetChatUsers(chatID)
 		if err != nil {
 			return nil, err
@@ -261,7 +272,7 @@ func (uuc *UserUsecaseController) GetCompanionsForUser(userID uint64, userParams
 			}
 		}
 	}
-	foundUsers, err := uuc.GetUsersByParams(userParams)
+	foundUsers, err := uuc.GetUsersByParams(userID, userParams)
 	if err != nil {
 		return nil, err
 	}
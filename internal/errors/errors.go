@@ -22,6 +22,10 @@ var (
 	ErrPinDataInvalid     = errors.New("данные пина невалидны")
 	ErrCommentDataInvalid = errors.New("данные комментария невалидны")
 	ErrBoardDataInvalid   = errors.New("данные доски невалидны")
+	ErrChatDataInvalid    = errors.New("данные чата невалидны")
+	ErrMessageDataInvalid = errors.New("данные сообщения невалидны")
+
+	ErrMessagesPageInvalid = errors.New("параметры выборки сообщений невалидны")
 )
 
 // Handlers
@@ -51,6 +55,7 @@ var (
 	// Media
 	ErrExpectedMultipartContentType = errors.New("запрос имеет Content-Type не multipart")
 	ErrWrongMediaContentType        = errors.New("загружаемое медиа имеет некорректный Content-Type")
+	ErrMediaTooLarge                = errors.New("размер загружаемого медиа превышает допустимый")
 
 	// Postgres
 	ErrUserAlreadyExists = errors.New("пользователь уже существует")
@@ -70,6 +75,45 @@ var (
 	ErrBookmarkDoesntExists  = errors.New("закладка не существует")
 	ErrBookmarkAlreadyExists = errors.New("закладка уже существует")
 	ErrBadBookmarkInputData  = errors.New("передана некорректная информация о закладке")
+
+	ErrChatDoesntExists        = errors.New("чат не существует")
+	ErrUserIsNotChatMember     = errors.New("пользователь не состоит в чате")
+	ErrUserIsAlreadyChatMember = errors.New("пользователь уже состоит в чате")
+	ErrNotEnoughChatRights     = errors.New("недостаточно прав для изменения чата")
+	ErrChatIsNotGroup          = errors.New("чат не является групповым")
+	ErrChatMembersLimit        = errors.New("превышено количество участников чата")
+	ErrLastChatAdmin           = errors.New("в чате должен остаться хотя бы один администратор")
+	ErrMessageDoesntExists     = errors.New("сообщение не существует")
+
+	ErrUserIsBlocked     = errors.New("пользователь заблокирован")
+	ErrCantBlockYourself = errors.New("нельзя заблокировать самого себя")
+
+	ErrUploadDoesntExists = errors.New("загрузка не существует")
+	ErrUploadNotFinished  = errors.New("файл еще не загружен в хранилище")
+	ErrUploadSizeMismatch = errors.New("размер загруженного файла не совпадает с заявленным")
+	ErrUploadNotCompleted = errors.New("загрузка не подтверждена")
+
+	ErrTooManyCarouselItems = errors.New("в карусели пина слишком много медиа")
+
+	ErrLinkInvalid       = errors.New("ссылка должна быть абсолютным http или https адресом")
+	ErrLinkForbidden     = errors.New("ссылка ведет на адрес внутренней сети")
+	ErrLinkUnreachable   = errors.New("не удалось загрузить страницу по ссылке")
+	ErrLinkImageNotFound = errors.New("изображение не найдено на странице")
+
+	ErrPinStatusInvalid    = errors.New("неизвестный статус пина")
+	ErrPinPublishAtInvalid = errors.New("время публикации пина должно быть в будущем")
+	ErrUserIsNotPinAuthor  = errors.New("пин может изменять только его автор")
+
+	ErrPinAlreadySaved = errors.New("пин уже сохранен в эту доску")
+	ErrPinNotInBoard   = errors.New("пина нет в доске, из которой он сохраняется")
+
+	ErrReactionTypeInvalid = errors.New("неизвестный тип реакции")
+
+	ErrBookmarksPageInvalid = errors.New("некорректные параметры страницы закладок")
+
+	ErrAnalyticsParamsInvalid = errors.New("некорректный период или размер выборки статистики")
+	ErrAnalyticsMetricInvalid = errors.New("неизвестная метрика статистики")
+	ErrAnalyticsNotAuthor     = errors.New("статистика доступна только автору")
 )
 
 var ErrorMapping = map[error]struct {
@@ -126,6 +170,51 @@ var ErrorMapping = map[error]struct {
 
 	ErrBookmarkDoesntExists: {HttpCode: 400, InternalCode: 32},
 	ErrBadBookmarkInputData: {HttpCode: 400, InternalCode: 33},
+
+	ErrChatDataInvalid:         {HttpCode: 400, InternalCode: 34},
+	ErrChatDoesntExists:        {HttpCode: 404, InternalCode: 35},
+	ErrUserIsNotChatMember:     {HttpCode: 403, InternalCode: 36},
+	ErrUserIsAlreadyChatMember: {HttpCode: 400, InternalCode: 37},
+	ErrNotEnoughChatRights:     {HttpCode: 403, InternalCode: 38},
+	ErrChatIsNotGroup:          {HttpCode: 400, InternalCode: 39},
+	ErrChatMembersLimit:        {HttpCode: 400, InternalCode: 40},
+	ErrLastChatAdmin:           {HttpCode: 400, InternalCode: 41},
+	ErrMessageDataInvalid:      {HttpCode: 400, InternalCode: 42},
+	ErrMessagesPageInvalid:     {HttpCode: 400, InternalCode: 43},
+	ErrMessageDoesntExists:     {HttpCode: 404, InternalCode: 44},
+
+	ErrUserIsBlocked:     {HttpCode: 403, InternalCode: 45},
+	ErrCantBlockYourself: {HttpCode: 400, InternalCode: 46},
+
+	ErrMediaTooLarge: {HttpCode: 413, InternalCode: 47},
+
+	ErrUploadDoesntExists: {HttpCode: 404, InternalCode: 48},
+	ErrUploadNotFinished:  {HttpCode: 409, InternalCode: 49},
+	ErrUploadSizeMismatch: {HttpCode: 400, InternalCode: 50},
+	ErrUploadNotCompleted: {HttpCode: 400, InternalCode: 51},
+
+	ErrTooManyCarouselItems: {HttpCode: 400, InternalCode: 52},
+
+	ErrLinkInvalid:       {HttpCode: 400, InternalCode: 53},
+	ErrLinkForbidden:     {HttpCode: 400, InternalCode: 54},
+	ErrLinkUnreachable:   {HttpCode: 422, InternalCode: 55},
+	ErrLinkImageNotFound: {HttpCode: 422, InternalCode: 56},
+
+	ErrPinStatusInvalid:    {HttpCode: 400, InternalCode: 57},
+	ErrPinPublishAtInvalid: {HttpCode: 400, InternalCode: 58},
+
+	ErrPinAlreadySaved: {HttpCode: 409, InternalCode: 59},
+	ErrPinNotInBoard:   {HttpCode: 400, InternalCode: 60},
+
+	ErrReactionTypeInvalid: {HttpCode: 400, InternalCode: 61},
+
+	ErrBookmarksPageInvalid: {HttpCode: 400, InternalCode: 62},
+
+	ErrAnalyticsParamsInvalid: {HttpCode: 400, InternalCode: 63},
+	ErrAnalyticsMetricInvalid: {HttpCode: 400, InternalCode: 64},
+	ErrAnalyticsNotAuthor:     {HttpCode: 403, InternalCode: 65},
+
+	ErrUserIsNotPinAuthor: {HttpCode: 403, InternalCode: 66},
 }
 
 func IsInternal(err error) bool {
@@ -0,0 +1,157 @@
+package audioprobe
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	adtsHeaderSize   = 7
+	adtsCRCSize      = 2
+	aacFrameSamples  = 1024
+	aacEightShortSeq = 2
+	aacElementSCE    = 0
+	aacElementCPE    = 1
+	aacElementLFE    = 3
+)
+
+var adtsSampleRates = [13]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// probeADTS reads AAC in ADTS frames, the form of raw .aac files.
+func probeADTS(r io.Reader) (*Info, error) {
+	br := bufio.NewReaderSize(r, readBufferSize)
+
+	// AAC files may start with an ID3 tag too
+	cover, coverType, err := readID3v2(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		frameLevels levels
+		samples     int64
+		sampleRate  int
+		lastLevel   float64
+	)
+	for {
+		// The header and the start of the first raw block, shorter at the end of the file
+		header, _ := br.Peek(adtsHeaderSize + adtsCRCSize + 8)
+		if len(header) < adtsHeaderSize {
+			break
+		}
+		if header[0] != 0xFF || header[1]&0xF6 != 0xF0 {
+			_, _ = br.Discard(1)
+			continue
+		}
+
+		sampleRateIndex := int(header[2] >> 2 & 0xF)
+		frameSize := int(header[3]&3)<<11 | int(header[4])<<3 | int(header[5]>>5)
+		rawBlocks := int(header[6]&3) + 1
+		headerSize := adtsHeaderSize
+		if header[1]&1 == 0 {
+			headerSize += adtsCRCSize
+		}
+		if sampleRateIndex >= len(adtsSampleRates) || frameSize < headerSize ||
+			(sampleRate != 0 && adtsSampleRates[sampleRateIndex] != sampleRate) {
+			_, _ = br.Discard(1)
+			continue
+		}
+
+		// Gains of frames with several raw blocks are not read, their blocks are located by a CRC table
+		if rawBlocks == 1 && len(header) > headerSize {
+			if level, ok := aacLevel(header[headerSize:]); ok {
+				lastLevel = level
+			}
+		}
+
+		if _, err := br.Discard(frameSize); err != nil {
+			break
+		}
+		sampleRate = adtsSampleRates[sampleRateIndex]
+		samples += int64(rawBlocks * aacFrameSamples)
+		frameLevels = append(frameLevels, lastLevel)
+	}
+
+	if len(frameLevels) == 0 {
+		return nil, ErrInvalidFormat
+	}
+	return &Info{
+		Duration:  samplesDuration(samples, int64(sampleRate)),
+		Waveform:  frameLevels.waveform(),
+		Cover:     cover,
+		CoverType: coverType,
+	}, nil
+}
+
+// aacLevel reads the global gain of the first channel of a raw data block.
+// Only single and paired channel elements are understood.
+func aacLevel(block []byte) (float64, bool) {
+	br := bitReader{data: block}
+
+	element, ok := br.read(3)
+	if !ok {
+		return 0, false
+	}
+	// element_instance_tag
+	br.skip(4)
+
+	switch element {
+	case aacElementSCE, aacElementLFE:
+	case aacElementCPE:
+		commonWindow, ok := br.read(1)
+		if !ok {
+			return 0, false
+		}
+		if commonWindow == 1 && !skipCommonICSInfo(&br) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	globalGain, ok := br.read(8)
+	if !ok {
+		return 0, false
+	}
+	return gainLevel(globalGain), true
+}
+
+// skipCommonICSInfo skips ics_info and the M/S mask shared by the channels of a pair.
+func skipCommonICSInfo(br *bitReader) bool {
+	// ics_reserved_bit
+	br.skip(1)
+	windowSequence, _ := br.read(2)
+	// window_shape
+	br.skip(1)
+
+	windowGroups := 1
+	var maxSfb int
+	if windowSequence == aacEightShortSeq {
+		maxSfb, _ = br.read(4)
+		grouping, ok := br.read(7)
+		if !ok {
+			return false
+		}
+		for bit := 0; bit < 7; bit++ {
+			if grouping>>bit&1 == 0 {
+				windowGroups++
+			}
+		}
+	} else {
+		maxSfb, _ = br.read(6)
+		predictorDataPresent, ok := br.read(1)
+		// Prediction exists only in the Main profile
+		if !ok || predictorDataPresent == 1 {
+			return false
+		}
+	}
+
+	msMaskPresent, ok := br.read(2)
+	if !ok {
+		return false
+	}
+	if msMaskPresent == 1 {
+		br.skip(windowGroups * maxSfb)
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package audioprobe
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testADTSFrameSize = 200
+
+// testADTSFrame builds an AAC LC frame at 44100 Hz with a single channel element of the global gain
+func testADTSFrame(globalGain int) []byte {
+	frame := make([]byte, testADTSFrameSize)
+	header := bitWriter{data: frame[:adtsHeaderSize]}
+	// syncword, ID, layer, protection_absent
+	header.write(0xFFF, 12)
+	header.write(0, 3)
+	header.write(1, 1)
+	// profile, sampling_frequency_index, private_bit, channel_configuration, original_copy, home
+	header.write(1, 2)
+	header.write(4, 4)
+	header.write(0, 1)
+	header.write(1, 3)
+	header.write(0, 2)
+	// copyright bits, frame_length, buffer_fullness, number_of_raw_data_blocks_in_frame
+	header.write(0, 2)
+	header.write(testADTSFrameSize, 13)
+	header.write(0x7FF, 11)
+	header.write(0, 2)
+
+	block := bitWriter{data: frame[adtsHeaderSize:]}
+	block.write(aacElementSCE, 3)
+	block.write(0, 4)
+	block.write(globalGain, 8)
+	return frame
+}
+
+func TestProbeAAC(t *testing.T) {
+	var data bytes.Buffer
+	data.Write(testID3(id3PictureFrontCover, testJPEGCover))
+
+	const frames = 300
+	for i := 0; i < frames; i++ {
+		data.Write(testADTSFrame(80 + i/3))
+	}
+
+	info, err := Probe(bytes.NewReader(data.Bytes()), ContentTypeAAC)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, frames*1024*time.Second/44100, info.Duration)
+	if assert.Len(t, info.Waveform, WaveformPoints) {
+		assert.Less(t, info.Waveform[0], info.Waveform[WaveformPoints-1])
+		assert.Equal(t, WaveformMax, info.Waveform[WaveformPoints-1])
+	}
+	assert.Equal(t, testJPEGCover, info.Cover, "aac files may have an id3 tag too")
+}
+
+func TestProbeAACErrors(t *testing.T) {
+	_, err := Probe(bytes.NewReader([]byte("not an aac file")), ContentTypeAAC)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	// A frame shorter than its header is garbage
+	frame := testADTSFrame(100)
+	frame[3], frame[4], frame[5] = frame[3]&^3, 0, frame[5]&0x1F
+	_, err = Probe(bytes.NewReader(frame), ContentTypeAAC)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestLevelsWaveform(t *testing.T) {
+	assert.Nil(t, levels{}.waveform())
+	assert.Equal(t, []int{0, 0}, levels{0, 0}.waveform(), "silence is flat")
+	assert.Equal(t, []int{50, 100, 25}, levels{0.5, 1, 0.25}.waveform(), "short audio has a point per level")
+
+	long := make(levels, 2*WaveformPoints)
+	long[len(long)-1] = 2
+	waveform := long.waveform()
+	if assert.Len(t, waveform, WaveformPoints) {
+		assert.Equal(t, WaveformMax, waveform[WaveformPoints-1], "the peak of merged levels is kept")
+	}
+}
@@ -0,0 +1,346 @@
+package audioprobe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	readBufferSize = 64 << 10
+
+	id3HeaderSize = 10
+	// Covers are a few hundred kilobytes, larger tags are skipped without reading the cover
+	maxID3TagSize = 16 << 20
+
+	id3FlagUnsynchronisation = 0x80
+	id3FlagExtendedHeader    = 0x40
+	id3FlagFooter            = 0x10
+
+	id3PictureFrontCover = 3
+)
+
+// Layer III bitrates in kbit/s by the index of the header for MPEG-1 and MPEG-2/2.5
+var mp3Bitrates = [2][16]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+}
+
+// MPEG-1 sample rates, MPEG-2 halves them and MPEG-2.5 quarters
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+type mp3Header struct {
+	mpeg1      bool
+	sampleRate int
+	frameSize  int
+	samples    int
+	channels   int
+	crcSize    int
+	sideInfo   int
+}
+
+func probeMP3(r io.Reader) (*Info, error) {
+	br := bufio.NewReaderSize(r, readBufferSize)
+	info := &Info{}
+
+	var err error
+	info.Cover, info.CoverType, err = readID3v2(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		frameLevels levels
+		samples     int64
+		sampleRate  int
+	)
+	for {
+		headerBytes, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+		header, ok := parseMP3Header(headerBytes)
+		// A frame with another sample rate is garbage that looks like a header
+		if !ok || (sampleRate != 0 && header.sampleRate != sampleRate) {
+			_, _ = br.Discard(1)
+			continue
+		}
+
+		sideInfoEnd := 4 + header.crcSize + header.sideInfo
+		frame, _ := br.Peek(sideInfoEnd + 4)
+		if len(frame) < sideInfoEnd {
+			break
+		}
+		sideInfo := frame[4+header.crcSize : sideInfoEnd]
+		// The first frame may be a Xing or LAME tag without audio
+		isTag := len(frameLevels) == 0 && len(frame) == sideInfoEnd+4 &&
+			(string(frame[sideInfoEnd:]) == "Xing" || string(frame[sideInfoEnd:]) == "Info")
+
+		if _, err := br.Discard(header.frameSize); err != nil {
+			// The last frame is truncated
+			break
+		}
+		if isTag {
+			continue
+		}
+
+		sampleRate = header.sampleRate
+		samples += int64(header.samples)
+		frameLevels = append(frameLevels, header.level(sideInfo))
+	}
+
+	if len(frameLevels) == 0 {
+		return nil, ErrInvalidFormat
+	}
+	info.Duration = samplesDuration(samples, int64(sampleRate))
+	info.Waveform = frameLevels.waveform()
+	return info, nil
+}
+
+func parseMP3Header(h []byte) (mp3Header, bool) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Header{}, false
+	}
+
+	// 0 - MPEG-2.5, 1 - reserved, 2 - MPEG-2, 3 - MPEG-1
+	version := h[1] >> 3 & 3
+	// 1 - Layer III
+	layer := h[1] >> 1 & 3
+	bitrateIndex := h[2] >> 4
+	sampleRateIndex := h[2] >> 2 & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3Header{}, false
+	}
+
+	header := mp3Header{
+		mpeg1:      version == 3,
+		sampleRate: mp3SampleRates[sampleRateIndex],
+		channels:   2,
+	}
+	if h[3]>>6 == 3 {
+		header.channels = 1
+	}
+	if h[1]&1 == 0 {
+		header.crcSize = 2
+	}
+	padding := int(h[2] >> 1 & 1)
+
+	if header.mpeg1 {
+		bitrate := mp3Bitrates[0][bitrateIndex] * 1000
+		header.frameSize = 144*bitrate/header.sampleRate + padding
+		header.samples = 1152
+		header.sideInfo = 32
+		if header.channels == 1 {
+			header.sideInfo = 17
+		}
+	} else {
+		header.sampleRate /= 2
+		if version == 0 {
+			header.sampleRate /= 2
+		}
+		bitrate := mp3Bitrates[1][bitrateIndex] * 1000
+		header.frameSize = 72*bitrate/header.sampleRate + padding
+		header.samples = 576
+		header.sideInfo = 17
+		if header.channels == 1 {
+			header.sideInfo = 9
+		}
+	}
+	return header, true
+}
+
+// level is the loudest global gain of the granules of the frame that have audio data.
+func (h mp3Header) level(sideInfo []byte) float64 {
+	br := bitReader{data: sideInfo}
+
+	granules := 1
+	// Bits of a granule of a channel after global_gain
+	granuleRest := 63 - 29
+	if h.mpeg1 {
+		granules = 2
+		granuleRest = 59 - 29
+		// main_data_begin, private_bits, scfsi
+		br.skip(9)
+		if h.channels == 1 {
+			br.skip(5)
+		} else {
+			br.skip(3)
+		}
+		br.skip(4 * h.channels)
+	} else {
+		br.skip(8)
+		if h.channels == 1 {
+			br.skip(1)
+		} else {
+			br.skip(2)
+		}
+	}
+
+	level := 0.0
+	for granule := 0; granule < granules; granule++ {
+		for channel := 0; channel < h.channels; channel++ {
+			part23Length, _ := br.read(12)
+			br.skip(9)
+			globalGain, ok := br.read(8)
+			if !ok {
+				return level
+			}
+			// A granule without Huffman data is silent
+			if part23Length > 0 {
+				level = max(level, gainLevel(globalGain))
+			}
+			br.skip(granuleRest)
+		}
+	}
+	return level
+}
+
+// readID3v2 skips the ID3v2 tag in front of the audio and returns the picture it holds.
+func readID3v2(br *bufio.Reader) ([]byte, string, error) {
+	header, err := br.Peek(id3HeaderSize)
+	if err != nil || string(header[:3]) != "ID3" {
+		return nil, "", nil
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	tagSize := id3HeaderSize + size
+	if flags&id3FlagFooter != 0 {
+		tagSize += id3HeaderSize
+	}
+
+	if size > maxID3TagSize || flags&(id3FlagUnsynchronisation|id3FlagExtendedHeader) != 0 {
+		if _, err := br.Discard(tagSize); err != nil {
+			return nil, "", ErrInvalidFormat
+		}
+		return nil, "", nil
+	}
+
+	tag := make([]byte, tagSize)
+	if _, err := io.ReadFull(br, tag); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, "", ErrInvalidFormat
+		}
+		return nil, "", err
+	}
+
+	cover := findID3Picture(tag[id3HeaderSize:id3HeaderSize+size], version)
+	coverType := pictureType(cover)
+	if coverType == "" {
+		return nil, "", nil
+	}
+	return cover, coverType, nil
+}
+
+// findID3Picture returns the front cover or the first picture of the tag.
+func findID3Picture(frames []byte, version byte) []byte {
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	var picture []byte
+	for len(frames) >= headerSize && frames[0] != 0 {
+		id := string(frames[:idSize])
+		var size int
+		switch version {
+		case 2:
+			size = int(frames[3])<<16 | int(frames[4])<<8 | int(frames[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(frames[4:8]))
+		default:
+			size = syncsafe(frames[4:8])
+		}
+		if size < 0 || headerSize+size > len(frames) {
+			break
+		}
+		body := frames[headerSize : headerSize+size]
+		frames = frames[headerSize+size:]
+
+		if id != "APIC" && id != "PIC" {
+			continue
+		}
+		data, pictureKind, ok := parsePictureFrame(body, version == 2)
+		if !ok {
+			continue
+		}
+		if pictureKind == id3PictureFrontCover {
+			return data
+		}
+		if picture == nil {
+			picture = data
+		}
+	}
+	return picture
+}
+
+// parsePictureFrame splits APIC (or PIC of ID3v2.2): encoding, MIME type (image format in v2.2),
+// picture type, description, data.
+func parsePictureFrame(body []byte, v22 bool) (data []byte, pictureKind byte, ok bool) {
+	if len(body) < 2 {
+		return nil, 0, false
+	}
+	encoding := body[0]
+	body = body[1:]
+
+	if v22 {
+		if len(body) < 3 {
+			return nil, 0, false
+		}
+		body = body[3:]
+	} else {
+		end := bytes.IndexByte(body, 0)
+		if end < 0 {
+			return nil, 0, false
+		}
+		body = body[end+1:]
+	}
+
+	if len(body) < 1 {
+		return nil, 0, false
+	}
+	pictureKind = body[0]
+	body = body[1:]
+
+	// The description is terminated by a zero of the text encoding: UTF-16 uses two bytes
+	if encoding == 1 || encoding == 2 {
+		end := -1
+		for i := 0; i+1 < len(body); i += 2 {
+			if body[i] == 0 && body[i+1] == 0 {
+				end = i + 2
+				break
+			}
+		}
+		if end < 0 {
+			return nil, 0, false
+		}
+		body = body[end:]
+	} else {
+		end := bytes.IndexByte(body, 0)
+		if end < 0 {
+			return nil, 0, false
+		}
+		body = body[end+1:]
+	}
+
+	return body, pictureKind, len(body) > 0
+}
+
+// pictureType recognizes the formats covers are processed from.
+func pictureType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	default:
+		return ""
+	}
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
@@ -0,0 +1,144 @@
+package audioprobe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bitWriter sets big-endian bit fields of frames
+type bitWriter struct {
+	data []byte
+	pos  int
+}
+
+func (b *bitWriter) write(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if value>>i&1 == 1 {
+			b.data[b.pos/8] |= 1 << (7 - b.pos%8)
+		}
+		b.pos++
+	}
+}
+
+const (
+	// MPEG-1 Layer III, 128 kbit/s, 44100 Hz, mono, without CRC
+	testMP3FrameSize = 144 * 128000 / 44100
+	testMP3SideInfo  = 17
+)
+
+// testMP3Frame builds a frame whose granules have the global gain
+func testMP3Frame(globalGain int) []byte {
+	frame := make([]byte, testMP3FrameSize)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0xC0})
+
+	side := bitWriter{data: frame[4 : 4+testMP3SideInfo]}
+	// main_data_begin, private_bits, scfsi
+	side.write(0, 9+5+4)
+	for granule := 0; granule < 2; granule++ {
+		// part2_3_length, big_values, global_gain and the rest of the granule
+		side.write(100, 12)
+		side.write(0, 9)
+		side.write(globalGain, 8)
+		side.write(0, 30)
+	}
+	return frame
+}
+
+// testID3 builds an ID3v2.3 tag with a picture of the kind
+func testID3(pictureKind byte, picture []byte) []byte {
+	var body bytes.Buffer
+	body.WriteByte(0)
+	body.WriteString("image/jpeg\x00")
+	body.WriteByte(pictureKind)
+	body.WriteString("cover\x00")
+	body.Write(picture)
+
+	var frames bytes.Buffer
+	frames.WriteString("TIT2")
+	binary.Write(&frames, binary.BigEndian, uint32(6))
+	frames.Write([]byte{0, 0, 0})
+	frames.WriteString("title")
+	frames.WriteString("APIC")
+	binary.Write(&frames, binary.BigEndian, uint32(body.Len()))
+	frames.Write([]byte{0, 0})
+	frames.Write(body.Bytes())
+	// Padding
+	frames.Write(make([]byte, 16))
+
+	size := frames.Len()
+	tag := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, frames.Bytes()...)
+}
+
+var testJPEGCover = []byte{0xFF, 0xD8, 0xFF, 0xE0, 'c', 'o', 'v', 'e', 'r'}
+
+func TestProbeMP3(t *testing.T) {
+	var data bytes.Buffer
+	data.Write(testID3(id3PictureFrontCover, testJPEGCover))
+
+	// The Xing tag frame carries no audio
+	xing := testMP3Frame(0)
+	copy(xing[4+testMP3SideInfo:], "Xing")
+	data.Write(xing)
+
+	const frames = 200
+	for i := 0; i < frames; i++ {
+		data.Write(testMP3Frame(100 + i/2))
+	}
+
+	info, err := Probe(bytes.NewReader(data.Bytes()), ContentTypeMP3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, frames*1152*time.Second/44100, info.Duration)
+	if assert.Len(t, info.Waveform, WaveformPoints) {
+		assert.Less(t, info.Waveform[0], info.Waveform[WaveformPoints-1], "the waveform follows the gain")
+		assert.Equal(t, WaveformMax, info.Waveform[WaveformPoints-1])
+	}
+	assert.Equal(t, testJPEGCover, info.Cover)
+	assert.Equal(t, "image/jpeg", info.CoverType)
+}
+
+func TestProbeMP3SkipsGarbage(t *testing.T) {
+	var data bytes.Buffer
+	data.WriteString("garbage before the first frame")
+	for i := 0; i < 10; i++ {
+		data.Write(testMP3Frame(120))
+	}
+	// A truncated last frame is not counted
+	data.Write(testMP3Frame(120)[:100])
+
+	info, err := Probe(bytes.NewReader(data.Bytes()), ContentTypeMP3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10*1152*time.Second/44100, info.Duration)
+		assert.Len(t, info.Waveform, 10)
+		assert.Nil(t, info.Cover)
+	}
+}
+
+func TestProbeMP3Errors(t *testing.T) {
+	_, err := Probe(strings.NewReader("not an mp3 file at all"), ContentTypeMP3)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	truncatedTag := testID3(id3PictureFrontCover, testJPEGCover)
+	_, err = Probe(bytes.NewReader(truncatedTag[:len(truncatedTag)-10]), ContentTypeMP3)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = Probe(strings.NewReader("fLaC"), "audio/flac")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestFindID3Picture(t *testing.T) {
+	other := testID3(0, []byte("\x89PNG\r\n\x1a\nother"))
+	front := testID3(id3PictureFrontCover, testJPEGCover)
+
+	// Frames of both tags, the front cover goes second
+	frames := append(bytes.TrimRight(other[id3HeaderSize:], "\x00"), front[id3HeaderSize:]...)
+	assert.Equal(t, testJPEGCover, findID3Picture(frames, 3), "the front cover is preferred")
+	assert.Equal(t, []byte("\x89PNG\r\n\x1a\nother"), findID3Picture(other[id3HeaderSize:], 3), "any picture is better than none")
+}
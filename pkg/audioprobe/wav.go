@@ -0,0 +1,181 @@
+package audioprobe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	maxWavFormatSize = 1024
+	// Streams written without knowing the length leave the size of the data chunk unset
+	wavUnknownSize = 0xFFFFFFFF
+
+	// Headers are not trusted with the amount of memory to allocate, frames are at most 32*8 bytes
+	maxWavChannels = 32
+
+	// Every 20ms of audio gives a level
+	wavLevelsPerSecond = 50
+	wavReadSize        = 64 * 1024
+)
+
+type wavFormat struct {
+	format     int
+	channels   int
+	sampleRate int
+	blockAlign int
+	sampleSize int
+}
+
+func probeWAV(r io.Reader) (*Info, error) {
+	br := bufio.NewReaderSize(r, readBufferSize)
+
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, ErrInvalidFormat
+	}
+
+	var format *wavFormat
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(br, chunkHeader[:]); err != nil {
+			return nil, ErrInvalidFormat
+		}
+		chunkID := string(chunkHeader[:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:]))
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize > maxWavFormatSize {
+				return nil, ErrInvalidFormat
+			}
+			chunk := make([]byte, chunkSize+chunkSize&1)
+			if _, err := io.ReadFull(br, chunk); err != nil {
+				return nil, ErrInvalidFormat
+			}
+			var err error
+			if format, err = parseWavFormat(chunk[:chunkSize]); err != nil {
+				return nil, err
+			}
+		case "data":
+			if format == nil {
+				return nil, ErrInvalidFormat
+			}
+			return format.probeData(br, chunkSize)
+		default:
+			// Chunks are padded to an even size
+			if _, err := br.Discard(int(chunkSize + chunkSize&1)); err != nil {
+				return nil, ErrInvalidFormat
+			}
+		}
+	}
+}
+
+func parseWavFormat(chunk []byte) (*wavFormat, error) {
+	if len(chunk) < 16 {
+		return nil, ErrInvalidFormat
+	}
+
+	format := &wavFormat{
+		format:     int(binary.LittleEndian.Uint16(chunk[0:2])),
+		channels:   int(binary.LittleEndian.Uint16(chunk[2:4])),
+		sampleRate: int(binary.LittleEndian.Uint32(chunk[4:8])),
+		blockAlign: int(binary.LittleEndian.Uint16(chunk[12:14])),
+		sampleSize: (int(binary.LittleEndian.Uint16(chunk[14:16])) + 7) / 8,
+	}
+	// The real format of the extensible one is the first field of its subformat GUID
+	if format.format == wavFormatExtensible && len(chunk) >= 26 {
+		format.format = int(binary.LittleEndian.Uint16(chunk[24:26]))
+	}
+
+	if format.channels == 0 || format.channels > maxWavChannels || format.sampleRate == 0 ||
+		format.sampleSize == 0 || format.blockAlign != format.channels*format.sampleSize {
+		return nil, ErrInvalidFormat
+	}
+	switch {
+	case format.format == wavFormatPCM && format.sampleSize <= 4:
+	case format.format == wavFormatFloat && (format.sampleSize == 4 || format.sampleSize == 8):
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return format, nil
+}
+
+// probeData reads samples to the end of the data chunk, or of the file when its size is unset.
+func (f *wavFormat) probeData(r io.Reader, dataSize int64) (*Info, error) {
+	if dataSize != wavUnknownSize {
+		r = io.LimitReader(r, dataSize)
+	}
+
+	framesPerLevel := max(f.sampleRate/wavLevelsPerSecond, 1)
+	// The buffer holds whole frames, parseWavFormat keeps a frame far below wavReadSize
+	buf := make([]byte, wavReadSize/f.blockAlign*f.blockAlign)
+
+	var (
+		frameLevels levels
+		frames      int64
+		peak        float64
+	)
+	for {
+		n, err := io.ReadFull(r, buf)
+		// An incomplete frame at the end is dropped
+		for offset := 0; offset+f.blockAlign <= n; offset += f.blockAlign {
+			for channel := 0; channel < f.channels; channel++ {
+				sampleOffset := offset + channel*f.sampleSize
+				// NaN of a broken float sample would spread through max
+				if sample := math.Abs(f.sample(buf[sampleOffset : sampleOffset+f.sampleSize])); !math.IsNaN(sample) {
+					peak = max(peak, sample)
+				}
+			}
+			frames++
+			if frames%int64(framesPerLevel) == 0 {
+				frameLevels = append(frameLevels, peak)
+				peak = 0
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+	}
+	if frames%int64(framesPerLevel) != 0 {
+		frameLevels = append(frameLevels, peak)
+	}
+
+	return &Info{
+		Duration: samplesDuration(frames, int64(f.sampleRate)),
+		Waveform: frameLevels.waveform(),
+	}, nil
+}
+
+// sample scales a little-endian sample to [-1, 1].
+func (f *wavFormat) sample(b []byte) float64 {
+	if f.format == wavFormatFloat {
+		if len(b) == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+
+	// 8-bit samples are unsigned, wider ones are signed
+	if len(b) == 1 {
+		return (float64(b[0]) - 128) / 128
+	}
+	var value int32
+	for i := len(b) - 1; i >= 0; i-- {
+		value = value<<8 | int32(b[i])
+	}
+	// Sign extension of 16 and 24-bit samples
+	shift := 32 - 8*len(b)
+	value = value << shift >> shift
+	return float64(value) / float64(int64(1)<<(8*len(b)-1))
+}
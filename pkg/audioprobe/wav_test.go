@@ -0,0 +1,104 @@
+package audioprobe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type wavHeader struct {
+	format        uint16
+	channels      uint16
+	sampleRate    uint32
+	blockAlign    uint16
+	bitsPerSample uint16
+}
+
+// testWAV builds a file with the header and the data chunk
+func testWAV(header wavHeader, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+16+8+len(data)))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, header.format)
+	binary.Write(&buf, binary.LittleEndian, header.channels)
+	binary.Write(&buf, binary.LittleEndian, header.sampleRate)
+	binary.Write(&buf, binary.LittleEndian, header.sampleRate*uint32(header.blockAlign))
+	binary.Write(&buf, binary.LittleEndian, header.blockAlign)
+	binary.Write(&buf, binary.LittleEndian, header.bitsPerSample)
+
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// sineSamples returns 16-bit mono samples of a sine with the amplitude growing to the full scale
+func sineSamples(count int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < count; i++ {
+		amplitude := float64(i) / float64(count)
+		binary.Write(&buf, binary.LittleEndian, int16(amplitude*math.MaxInt16*math.Sin(float64(i)/10)))
+	}
+	return buf.Bytes()
+}
+
+func TestProbeWAV(t *testing.T) {
+	header := wavHeader{format: wavFormatPCM, channels: 1, sampleRate: 8000, blockAlign: 2, bitsPerSample: 16}
+
+	info, err := Probe(bytes.NewReader(testWAV(header, sineSamples(16000))), ContentTypeWAV)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, info.Duration)
+	if assert.Len(t, info.Waveform, WaveformPoints) {
+		assert.Less(t, info.Waveform[0], info.Waveform[WaveformPoints-1])
+		assert.Equal(t, WaveformMax, info.Waveform[WaveformPoints-1])
+	}
+}
+
+func TestProbeWAVRejectsBrokenHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header wavHeader
+		err    error
+	}{
+		{
+			name:   "block align is not the size of a frame",
+			header: wavHeader{format: wavFormatPCM, channels: 1, sampleRate: 8000, blockAlign: 65535, bitsPerSample: 16},
+			err:    ErrInvalidFormat,
+		},
+		{
+			name:   "too many channels",
+			header: wavHeader{format: wavFormatPCM, channels: 1024, sampleRate: 8000, blockAlign: 2048, bitsPerSample: 16},
+			err:    ErrInvalidFormat,
+		},
+		{
+			name:   "no channels",
+			header: wavHeader{format: wavFormatPCM, channels: 0, sampleRate: 8000, blockAlign: 2, bitsPerSample: 16},
+			err:    ErrInvalidFormat,
+		},
+		{
+			name:   "no sample size",
+			header: wavHeader{format: wavFormatPCM, channels: 1, sampleRate: 8000, blockAlign: 0, bitsPerSample: 0},
+			err:    ErrInvalidFormat,
+		},
+		{
+			name:   "unsupported float size",
+			header: wavHeader{format: wavFormatFloat, channels: 1, sampleRate: 8000, blockAlign: 2, bitsPerSample: 16},
+			err:    ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Probe(bytes.NewReader(testWAV(tt.header, sineSamples(100))), ContentTypeWAV)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package imageproc prepares uploaded images for serving: it decodes them,
+// drops metadata, fixes orientation and renders downscaled variants.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+
+	jpegQuality = 85
+
+	// Protects from decompression bombs: a small file may declare a huge canvas
+	maxPixels = 50_000_000
+)
+
+// VariantWidths are the widths of the masonry grid columns for 1x, 2x and 3x screens.
+var VariantWidths = []int{236, 474, 736}
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrImageTooLarge     = errors.New("image dimensions are too large")
+)
+
+type Rendition struct {
+	Width       int
+	Height      int
+	Format      string
+	ContentType string
+	Data        []byte
+}
+
+type Variant struct {
+	Image Rendition
+	WebP  Rendition
+}
+
+type Result struct {
+	// Width and Height of the upright original
+	Width  int
+	Height int
+
+	// PerceptualHash is the DifferenceHash of the upright original
+	PerceptualHash uint64
+	Placeholder    Placeholder
+
+	Original Rendition
+	Variants []Variant
+}
+
+// Process decodes the image and re-encodes it without EXIF and other metadata.
+// Variants narrower than the original are rendered for every VariantWidths entry,
+// each one in the original format and in lossless WebP.
+// Animated GIFs are kept as is, their variants show the first frame.
+func Process(data []byte) (*Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	var original Rendition
+	switch format {
+	case FormatJPEG:
+		img = applyOrientation(img, jpegOrientation(data))
+		original, err = encode(img, FormatJPEG)
+	case FormatPNG:
+		original, err = encode(img, FormatPNG)
+	case FormatGIF:
+		// Re-encoding would drop animation, GIF carries no EXIF anyway
+		bounds := img.Bounds()
+		original = Rendition{Width: bounds.Dx(), Height: bounds.Dy(), Format: FormatGIF, ContentType: contentType(FormatGIF), Data: data}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Width:          original.Width,
+		Height:         original.Height,
+		PerceptualHash: DifferenceHash(img),
+		Placeholder:    NewPlaceholder(img),
+		Original:       original,
+	}
+
+	// Variants of GIF frames are stored as PNG
+	variantFormat := format
+	if format == FormatGIF {
+		variantFormat = FormatPNG
+	}
+
+	for _, width := range VariantWidths {
+		if width >= result.Width {
+			break
+		}
+
+		resized := resize(img, width)
+		variantImage, err := encode(resized, variantFormat)
+		if err != nil {
+			return nil, err
+		}
+		variantWebP, err := encode(resized, FormatWebP)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Variants = append(result.Variants, Variant{Image: variantImage, WebP: variantWebP})
+	}
+
+	return result, nil
+}
+
+// Extension returns the file extension for the format, including the leading dot.
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+func contentType(format string) string {
+	return "image/" + format
+}
+
+// resize scales the image to the given width keeping the aspect ratio.
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	height := max(bounds.Dy()*width/bounds.Dx(), 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
+
+func encode(img image.Image, format string) (Rendition, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatWebP:
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return Rendition{}, fmt.Errorf("encode %s: %w", format, err)
+	}
+
+	bounds := img.Bounds()
+	return Rendition{
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Format:      format,
+		ContentType: contentType(format),
+		Data:        buf.Bytes(),
+	}, nil
+}
@@ -0,0 +1,137 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// halves returns the image with the left half of the first color and the right half of the second
+func halves(width, height int, left, right color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode jpeg: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessPNG(t *testing.T) {
+	result, err := Process(encodePNG(t, halves(500, 250, color.White, color.Black)))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 500, result.Width)
+	assert.Equal(t, 250, result.Height)
+	assert.Equal(t, FormatPNG, result.Original.Format)
+	assert.Equal(t, "image/png", result.Original.ContentType)
+
+	if assert.Len(t, result.Variants, 2, "variants are not wider than the original") {
+		for i, variant := range result.Variants {
+			assert.Equal(t, VariantWidths[i], variant.Image.Width)
+			assert.Equal(t, VariantWidths[i]/2, variant.Image.Height, "the aspect ratio is kept")
+			assert.Equal(t, FormatPNG, variant.Image.Format)
+			assert.Equal(t, FormatWebP, variant.WebP.Format)
+			assert.Equal(t, variant.Image.Width, variant.WebP.Width)
+
+			_, format, err := image.DecodeConfig(bytes.NewReader(variant.Image.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, FormatPNG, format)
+		}
+	}
+}
+
+func TestProcessKeepsGIF(t *testing.T) {
+	palette := color.Palette{color.White, color.Black}
+	frame := image.NewPaletted(image.Rect(0, 0, 300, 300), palette)
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}})
+	if err != nil {
+		t.Fatalf("encode gif: %s", err)
+	}
+
+	result, err := Process(buf.Bytes())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, buf.Bytes(), result.Original.Data, "animated gifs are stored as is")
+	if assert.Len(t, result.Variants, 1) {
+		assert.Equal(t, FormatPNG, result.Variants[0].Image.Format, "gif variants are png")
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, halves(64, 32, color.White, color.Black)), 6)
+
+	result, err := Process(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 32, result.Width, "orientation 6 swaps width and height")
+	assert.Equal(t, 64, result.Height)
+
+	img, err := jpeg.Decode(bytes.NewReader(result.Original.Data))
+	if !assert.NoError(t, err) {
+		return
+	}
+	top, _, _, _ := img.At(16, 8).RGBA()
+	bottom, _, _, _ := img.At(16, 56).RGBA()
+	assert.Greater(t, top, uint32(0xe000), "the left half is rotated to the top")
+	assert.Less(t, bottom, uint32(0x2000))
+	assert.Equal(t, 1, jpegOrientation(result.Original.Data), "metadata is dropped")
+}
+
+func TestProcessRejectsImages(t *testing.T) {
+	_, err := Process([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// The header declares a canvas of 10000x10000 pixels
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], 10000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 10000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	_, err = Process(data)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, ".jpg", Extension(FormatJPEG))
+	assert.Equal(t, ".png", Extension(FormatPNG))
+	assert.Equal(t, ".webp", Extension(FormatWebP))
+}
@@ -0,0 +1,74 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withOrientation inserts an APP1 segment with the EXIF orientation right after the SOI marker
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0x00, 0x2A, 0, 0, 0, 8}
+	ifd := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(ifd[0:2], 1)
+	binary.BigEndian.PutUint16(ifd[2:4], exifOrientationTag)
+	binary.BigEndian.PutUint16(ifd[4:6], 3)
+	binary.BigEndian.PutUint32(ifd[6:10], 1)
+	binary.BigEndian.PutUint16(ifd[10:12], orientation)
+
+	payload := append(append(append([]byte{}, exifHeader...), tiff...), ifd...)
+	segment := []byte{0xFF, jpegAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := append([]byte{}, jpegData[:2]...)
+	data = append(data, segment...)
+	return append(data, jpegData[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpegData := []byte{0xFF, jpegSOI, 0xFF, jpegSOS, 0, 2}
+
+	assert.Equal(t, 1, jpegOrientation(jpegData), "no exif")
+	assert.Equal(t, 6, jpegOrientation(withOrientation(jpegData, 6)))
+	assert.Equal(t, 8, jpegOrientation(withOrientation(jpegData, 8)))
+	assert.Equal(t, 1, jpegOrientation(withOrientation(jpegData, 9)), "out of range")
+	assert.Equal(t, 1, jpegOrientation([]byte("GIF89a")), "not a jpeg")
+
+	truncated := withOrientation(jpegData, 6)
+	assert.Equal(t, 1, jpegOrientation(truncated[:20]))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 3x2 image with a marked top left pixel
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	marked := color.NRGBA{R: 255, A: 255}
+	src.SetNRGBA(0, 0, marked)
+
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		x, y        int
+	}{
+		{orientation: 1, width: 3, height: 2, x: 0, y: 0},
+		{orientation: 2, width: 3, height: 2, x: 2, y: 0},
+		{orientation: 3, width: 3, height: 2, x: 2, y: 1},
+		{orientation: 4, width: 3, height: 2, x: 0, y: 1},
+		{orientation: 5, width: 2, height: 3, x: 0, y: 0},
+		{orientation: 6, width: 2, height: 3, x: 1, y: 0},
+		{orientation: 7, width: 2, height: 3, x: 1, y: 2},
+		{orientation: 8, width: 2, height: 3, x: 0, y: 2},
+	}
+
+	for _, tt := range tests {
+		dst := applyOrientation(src, tt.orientation)
+		bounds := dst.Bounds()
+		assert.Equal(t, tt.width, bounds.Dx(), "orientation %d", tt.orientation)
+		assert.Equal(t, tt.height, bounds.Dy(), "orientation %d", tt.orientation)
+		assert.Equal(t, marked, color.NRGBAModel.Convert(dst.At(tt.x, tt.y)), "orientation %d", tt.orientation)
+	}
+}
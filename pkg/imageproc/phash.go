@@ -0,0 +1,31 @@
+package imageproc
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// DifferenceHash computes the 64-bit dHash of the image: every bit tells whether a pixel
+// of the 9x8 grayscale thumbnail is darker than its right neighbour.
+// Re-encoded, resized or slightly edited copies differ from the original in a few bits only.
+func DifferenceHash(img image.Image) uint64 {
+	thumb := image.NewGray(image.Rect(0, 0, dHashWidth, dHashHeight))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < dHashHeight; y++ {
+		for x := 0; x < dHashWidth-1; x++ {
+			hash <<= 1
+			if thumb.GrayAt(x, y).Y < thumb.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
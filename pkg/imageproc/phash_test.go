@@ -0,0 +1,37 @@
+package imageproc
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gradient returns the image getting lighter from left to right with a dark square in the middle
+func gradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := uint8(x * 255 / width)
+			if x > width/3 && x < width*2/3 && y > height/3 && y < height*2/3 {
+				value = 0
+			}
+			img.Set(x, y, color.Gray{Y: value})
+		}
+	}
+	return img
+}
+
+func TestDifferenceHash(t *testing.T) {
+	original := DifferenceHash(gradient(300, 200))
+
+	resized := DifferenceHash(resize(gradient(300, 200), 120))
+	assert.LessOrEqual(t, bits.OnesCount64(original^resized), 6, "a resized copy is a near duplicate")
+
+	mirrored := DifferenceHash(applyOrientation(gradient(300, 200), 2))
+	assert.Greater(t, bits.OnesCount64(original^mirrored), 6, "a different image is not")
+
+	assert.Zero(t, DifferenceHash(image.NewGray(image.Rect(0, 0, 50, 50))), "a flat image has no darker neighbours")
+}
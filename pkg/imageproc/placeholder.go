@@ -0,0 +1,209 @@
+package imageproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// Colors of a larger thumbnail differ only in details the placeholder doesn't show
+	placeholderThumbSize = 32
+
+	// Components of the longer side of the blurhash, the shorter one gets blurhashMinorComponents
+	blurhashMajorComponents = 4
+	blurhashMinorComponents = 3
+
+	// Pixels more transparent than this don't count towards the dominant color
+	opaqueAlpha = 128
+
+	base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// Placeholder is shown in place of an image until it loads
+type Placeholder struct {
+	// DominantColor is "#rrggbb", empty for fully transparent images
+	DominantColor string
+	Blurhash      string
+}
+
+// NewPlaceholder computes the placeholder of the image from a small thumbnail.
+func NewPlaceholder(img image.Image) Placeholder {
+	thumb := image.NewNRGBA(image.Rect(0, 0, placeholderThumbSize, placeholderThumbSize))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	bounds := img.Bounds()
+	xComponents, yComponents := blurhashMajorComponents, blurhashMinorComponents
+	if bounds.Dy() > bounds.Dx() {
+		xComponents, yComponents = yComponents, xComponents
+	}
+
+	return Placeholder{
+		DominantColor: dominantColor(thumb),
+		Blurhash:      blurhash(thumb, xComponents, yComponents),
+	}
+}
+
+// DecodePlaceholder computes the placeholder of an encoded image, upright as it is shown,
+// and returns the upright size of the image.
+func DecodePlaceholder(data []byte) (placeholder Placeholder, width, height int, err error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Placeholder{}, 0, 0, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return Placeholder{}, 0, 0, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Placeholder{}, 0, 0, fmt.Errorf("decode image: %w", err)
+	}
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	bounds := img.Bounds()
+	return NewPlaceholder(img), bounds.Dx(), bounds.Dy(), nil
+}
+
+// dominantColor averages the most populated cell of the color cube with 16 levels per channel.
+func dominantColor(thumb *image.NRGBA) string {
+	type cell struct {
+		count   int
+		r, g, b int
+	}
+	var cells [16 * 16 * 16]cell
+	dominant := -1
+
+	for i := 0; i < len(thumb.Pix); i += 4 {
+		r, g, b, a := int(thumb.Pix[i]), int(thumb.Pix[i+1]), int(thumb.Pix[i+2]), thumb.Pix[i+3]
+		if a < opaqueAlpha {
+			continue
+		}
+
+		index := r>>4<<8 | g>>4<<4 | b>>4
+		c := &cells[index]
+		c.count++
+		c.r += r
+		c.g += g
+		c.b += b
+		if dominant < 0 || c.count > cells[dominant].count {
+			dominant = index
+		}
+	}
+
+	if dominant < 0 {
+		return ""
+	}
+	c := cells[dominant]
+	return fmt.Sprintf("#%02x%02x%02x", c.r/c.count, c.g/c.count, c.b/c.count)
+}
+
+// blurhash encodes the image as described by https://github.com/woltapp/blurhash.
+// Transparent pixels are blended over white.
+func blurhash(thumb *image.NRGBA, xComponents, yComponents int) string {
+	width, height := thumb.Bounds().Dx(), thumb.Bounds().Dy()
+
+	linear := make([][3]float64, width*height)
+	for i := range linear {
+		pixel := thumb.Pix[i*4 : i*4+4]
+		alpha := float64(pixel[3]) / 255
+		for channel := 0; channel < 3; channel++ {
+			value := float64(pixel[channel])*alpha + 255*(1-alpha)
+			linear[i][channel] = srgbToLinear(value)
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i*x)/float64(width)) * math.Cos(math.Pi*float64(j*y)/float64(height))
+					for channel := 0; channel < 3; channel++ {
+						factor[channel] += basis * linear[y*width+x][channel]
+					}
+				}
+			}
+			scale := normalisation / float64(width*height)
+			for channel := range factor {
+				factor[channel] *= scale
+			}
+			factors = append(factors, factor)
+		}
+	}
+
+	var hash strings.Builder
+	encodeBase83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, factor := range ac {
+			for _, value := range factor {
+				actualMaximum = max(actualMaximum, math.Abs(value))
+			}
+		}
+		quantisedMaximum := clamp(int(math.Floor(actualMaximum*166-0.5)), 0, 82)
+		maximumValue = float64(quantisedMaximum+1) / 166
+		encodeBase83(&hash, quantisedMaximum, 1)
+	} else {
+		encodeBase83(&hash, 0, 1)
+	}
+
+	encodeBase83(&hash, linearToSrgb(dc[0])<<16|linearToSrgb(dc[1])<<8|linearToSrgb(dc[2]), 4)
+	for _, factor := range ac {
+		value := 0
+		for _, component := range factor {
+			quantised := clamp(int(math.Floor(signPow(component/maximumValue, 0.5)*9+9.5)), 0, 18)
+			value = value*19 + quantised
+		}
+		encodeBase83(&hash, value, 2)
+	}
+	return hash.String()
+}
+
+func encodeBase83(sb *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		divisor := 1
+		for k := 0; k < length-i; k++ {
+			divisor *= 83
+		}
+		sb.WriteByte(base83Chars[value/divisor%83])
+	}
+}
+
+func srgbToLinear(value float64) float64 {
+	v := value / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+func clamp(value, low, high int) int {
+	return max(low, min(value, high))
+}
@@ -0,0 +1,69 @@
+package imageproc
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uniform(width, height int, fill color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	return img
+}
+
+func decodeBase83(s string) int {
+	value := 0
+	for _, c := range s {
+		value = value*83 + strings.IndexRune(base83Chars, c)
+	}
+	return value
+}
+
+func TestNewPlaceholder(t *testing.T) {
+	placeholder := NewPlaceholder(uniform(120, 80, color.NRGBA{R: 200, G: 40, B: 90, A: 255}))
+	assert.Equal(t, "#c8285a", placeholder.DominantColor)
+
+	hash := placeholder.Blurhash
+	if assert.Len(t, hash, 6+2*(blurhashMajorComponents*blurhashMinorComponents-1)) {
+		assert.Equal(t, 3+2*9, decodeBase83(hash[:1]), "landscape images have more horizontal components")
+		assert.Equal(t, 200<<16|40<<8|90, decodeBase83(hash[2:6]), "the average color")
+	}
+
+	portrait := NewPlaceholder(uniform(80, 120, color.White))
+	assert.Equal(t, 2+3*9, decodeBase83(portrait.Blurhash[:1]))
+}
+
+func TestNewPlaceholderDominantColor(t *testing.T) {
+	img := uniform(100, 100, color.NRGBA{B: 255, A: 255})
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 30; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	assert.Equal(t, "#0000ff", NewPlaceholder(img).DominantColor, "the most common color wins, not the average")
+
+	transparent := NewPlaceholder(uniform(10, 10, color.NRGBA{}))
+	assert.Empty(t, transparent.DominantColor)
+	assert.Equal(t, 255<<16|255<<8|255, decodeBase83(transparent.Blurhash[2:6]), "transparent pixels are blended over white")
+}
+
+func TestDecodePlaceholder(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, uniform(64, 32, color.White)), 6)
+
+	placeholder, width, height, err := DecodePlaceholder(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 32, width, "the size is upright")
+	assert.Equal(t, 64, height)
+	assert.NotEmpty(t, placeholder.Blurhash)
+
+	_, _, _, err = DecodePlaceholder([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
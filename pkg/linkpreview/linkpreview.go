@@ -0,0 +1,273 @@
+// Package linkpreview fetches web pages and extracts what a pin can be made of:
+// the title, the description and candidate images of Open Graph and Twitter card metadata.
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// MaxImages bounds the candidates of a page
+	MaxImages = 10
+
+	userAgent = "PinsetBot/1.0 (+link preview)"
+)
+
+var (
+	ErrInvalidUrl  = errors.New("linkpreview: only absolute http and https urls are allowed")
+	ErrBadStatus   = errors.New("linkpreview: unexpected response status")
+	ErrNotHTML     = errors.New("linkpreview: page is not html")
+	ErrNotImage    = errors.New("linkpreview: resource is not an image")
+	ErrTooLarge    = errors.New("linkpreview: response is too large")
+	errHeadScanned = errors.New("head scanned")
+)
+
+type Preview struct {
+	// Url is the address of the page after redirects
+	Url         string
+	Title       string
+	Description string
+	SiteName    string
+	// Images are absolute urls of candidate images, the most specific first
+	Images []string
+}
+
+// Scraper fetches pages and images with the given client, which is expected to be
+// a NewSafeClient in production and a plain client of a local test server in tests.
+type Scraper struct {
+	client      *http.Client
+	maxPageSize int64
+}
+
+func NewScraper(client *http.Client, maxPageSize int64) *Scraper {
+	return &Scraper{
+		client:      client,
+		maxPageSize: maxPageSize,
+	}
+}
+
+// ParseUrl accepts absolute http and https urls only.
+func ParseUrl(rawUrl string) (*url.URL, error) {
+	pageUrl, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || pageUrl.Host == "" || pageUrl.User != nil {
+		return nil, ErrInvalidUrl
+	}
+	if err := checkScheme(pageUrl.Scheme); err != nil {
+		return nil, err
+	}
+	return pageUrl, nil
+}
+
+func checkScheme(scheme string) error {
+	if scheme != "http" && scheme != "https" {
+		return ErrInvalidUrl
+	}
+	return nil
+}
+
+// Scrape reads the metadata of the page. Only the head of the document is parsed.
+func (s *Scraper) Scrape(ctx context.Context, rawUrl string) (*Preview, error) {
+	resp, err := s.get(ctx, rawUrl, "text/html,application/xhtml+xml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	meta, err := parseHead(io.LimitReader(resp.Body, s.maxPageSize))
+	if err != nil {
+		return nil, err
+	}
+	return meta.preview(resp.Request.URL), nil
+}
+
+// FetchImage downloads an image of up to maxSize bytes.
+func (s *Scraper) FetchImage(ctx context.Context, rawUrl string, maxSize int64) ([]byte, error) {
+	resp, err := s.get(ctx, rawUrl, "image/*")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return nil, ErrNotImage
+	}
+	if resp.ContentLength > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("linkpreview: read image: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func (s *Scraper) get(ctx context.Context, rawUrl, accept string) (*http.Response, error) {
+	target, err := ParseUrl(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidUrl
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("linkpreview: get %s: %w", target.Host, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// headMeta collects metadata of the head, keys are property or name attributes of meta tags
+type headMeta struct {
+	title string
+	base  string
+	meta  map[string][]string
+	// imageSrc is <link rel="image_src">, an older convention for the page image
+	imageSrc string
+}
+
+func parseHead(r io.Reader) (*headMeta, error) {
+	meta := &headMeta{meta: make(map[string][]string)}
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	err := func() error {
+		for {
+			tokenType := tokenizer.Next()
+			switch tokenType {
+			case html.ErrorToken:
+				if errors.Is(tokenizer.Err(), io.EOF) {
+					return nil
+				}
+				return tokenizer.Err()
+			case html.TextToken:
+				if inTitle && meta.title == "" {
+					meta.title = strings.TrimSpace(string(tokenizer.Text()))
+				}
+			case html.EndTagToken:
+				name, _ := tokenizer.TagName()
+				switch atom.Lookup(name) {
+				case atom.Title:
+					inTitle = false
+				case atom.Head:
+					return errHeadScanned
+				}
+			case html.StartTagToken, html.SelfClosingTagToken:
+				name, hasAttr := tokenizer.TagName()
+				tag := atom.Lookup(name)
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+
+				switch tag {
+				case atom.Title:
+					inTitle = tokenType == html.StartTagToken
+				case atom.Base:
+					if meta.base == "" {
+						meta.base = attrs["href"]
+					}
+				case atom.Meta:
+					key := attrs["property"]
+					if key == "" {
+						key = attrs["name"]
+					}
+					if key != "" && attrs["content"] != "" {
+						key = strings.ToLower(key)
+						meta.meta[key] = append(meta.meta[key], strings.TrimSpace(attrs["content"]))
+					}
+				case atom.Link:
+					if strings.EqualFold(attrs["rel"], "image_src") && meta.imageSrc == "" {
+						meta.imageSrc = attrs["href"]
+					}
+				case atom.Body:
+					return errHeadScanned
+				}
+			}
+		}
+	}()
+	if err != nil && !errors.Is(err, errHeadScanned) {
+		return nil, fmt.Errorf("linkpreview: parse page: %w", err)
+	}
+	return meta, nil
+}
+
+func (m *headMeta) first(keys ...string) string {
+	for _, key := range keys {
+		if values := m.meta[key]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func (m *headMeta) preview(pageUrl *url.URL) *Preview {
+	base := pageUrl
+	if m.base != "" {
+		if baseUrl, err := pageUrl.Parse(m.base); err == nil {
+			base = baseUrl
+		}
+	}
+
+	preview := &Preview{
+		Url:         pageUrl.String(),
+		Title:       m.first("og:title", "twitter:title"),
+		Description: m.first("og:description", "twitter:description", "description"),
+		SiteName:    m.first("og:site_name"),
+	}
+	if preview.Title == "" {
+		preview.Title = m.title
+	}
+
+	seen := map[string]bool{}
+	candidates := [][]string{
+		m.meta["og:image:secure_url"],
+		m.meta["og:image:url"],
+		m.meta["og:image"],
+		m.meta["twitter:image"],
+		m.meta["twitter:image:src"],
+		{m.imageSrc},
+	}
+	for _, values := range candidates {
+		for _, value := range values {
+			imageUrl, err := base.Parse(value)
+			if value == "" || err != nil || checkScheme(imageUrl.Scheme) != nil || seen[imageUrl.String()] {
+				continue
+			}
+			seen[imageUrl.String()] = true
+			if len(preview.Images) < MaxImages {
+				preview.Images = append(preview.Images, imageUrl.String())
+			}
+		}
+	}
+	return preview
+}
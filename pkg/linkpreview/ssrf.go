@@ -0,0 +1,92 @@
+package linkpreview
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const maxRedirects = 5
+
+// ErrForbiddenAddress is returned for hosts that resolve to addresses of the internal network
+var ErrForbiddenAddress = errors.New("linkpreview: address is not public")
+
+// Ranges missed by the net/netip predicates
+var nonPublicPrefixes = []netip.Prefix{
+	// "This network"
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// Carrier-grade NAT
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// IETF protocol assignments and documentation
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	// Benchmarking
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// Reserved and broadcast
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64, 6to4 and Teredo may map to internal IPv4 addresses
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("2001::/32"),
+	// IPv6 documentation
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// NewSafeClient returns a client that connects only to public addresses.
+// The address is checked after DNS resolution right before connecting, so neither
+// a hostname pointing inside nor a redirect to an internal address gets through.
+func NewSafeClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			if !IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy from the environment would be dialed instead of the target
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("linkpreview: stopped after %d redirects", maxRedirects)
+			}
+			return checkScheme(req.URL.Scheme)
+		},
+	}
+}
+
+// IsPublicAddr reports whether the address is routable on the internet.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
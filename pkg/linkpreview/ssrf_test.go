@@ -0,0 +1,76 @@
+package linkpreview
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{addr: "127.0.0.1", public: false},
+		{addr: "::1", public: false},
+		{addr: "::ffff:127.0.0.1", public: false},
+		{addr: "10.1.2.3", public: false},
+		{addr: "172.16.0.1", public: false},
+		{addr: "192.168.1.1", public: false},
+		{addr: "169.254.169.254", public: false},
+		{addr: "fe80::1", public: false},
+		{addr: "fc00::1", public: false},
+		{addr: "0.0.0.0", public: false},
+		{addr: "100.64.0.1", public: false},
+		{addr: "198.18.0.1", public: false},
+		{addr: "255.255.255.255", public: false},
+		{addr: "224.0.0.1", public: false},
+		{addr: "64:ff9b::7f00:1", public: false},
+		{addr: "2002:7f00:1::1", public: false},
+		{addr: "2001:0:4136:e378:8000:63bf:3fff:fdd2", public: false},
+		{addr: "2001:db8::1", public: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.public, IsPublicAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestSafeClientRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the internal server must not be reached")
+	}))
+	defer server.Close()
+
+	_, err := NewSafeClient(time.Second).Get(server.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestSafeClientRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The test server is local, so only the redirect policy of the safe client is used
+	client := &http.Client{CheckRedirect: NewSafeClient(time.Second).CheckRedirect}
+
+	_, err := client.Get(server.URL + "/loop")
+	assert.ErrorContains(t, err, "stopped after 5 redirects")
+
+	_, err = client.Get(server.URL + "/ftp")
+	assert.ErrorIs(t, err, ErrInvalidUrl)
+}
@@ -0,0 +1,82 @@
+// Package mediaurl converts between media keys stored in the database and URLs given to clients.
+// A media key is "<bucket>/<object>". Media hosted elsewhere is stored as an absolute URL and passed through as is.
+package mediaurl
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type Builder struct {
+	baseUrl string
+	buckets []string
+}
+
+// NewBuilder creates a builder for URLs with the base, e.g. "http://localhost:9000" or a CDN prefix.
+// Buckets are used to recognize our media in URLs with another base, e.g. issued before the storage moved.
+func NewBuilder(baseUrl string, buckets ...string) *Builder {
+	return &Builder{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		buckets: buckets,
+	}
+}
+
+func JoinKey(bucketName, objectName string) string {
+	return bucketName + "/" + objectName
+}
+
+func SplitKey(key string) (bucketName, objectName string, ok bool) {
+	return strings.Cut(key, "/")
+}
+
+func IsAbsolute(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// Url returns the public URL of the stored reference.
+func (b *Builder) Url(ref string) string {
+	if ref == "" || IsAbsolute(ref) {
+		return ref
+	}
+	return b.baseUrl + "/" + ref
+}
+
+// OptionalUrl is Url for nullable columns.
+func (b *Builder) OptionalUrl(ref *string) *string {
+	if ref == nil {
+		return nil
+	}
+	mediaUrl := b.Url(*ref)
+	return &mediaUrl
+}
+
+// Key returns the reference to store for the URL given by a client:
+// the media key for our media, the URL itself for external media.
+func (b *Builder) Key(mediaUrl string) string {
+	if key, ok := strings.CutPrefix(mediaUrl, b.baseUrl+"/"); ok {
+		return key
+	}
+	if !IsAbsolute(mediaUrl) {
+		return mediaUrl
+	}
+
+	parsed, err := url.Parse(mediaUrl)
+	if err != nil {
+		return mediaUrl
+	}
+	key := strings.TrimPrefix(parsed.Path, "/")
+	if bucketName, _, ok := SplitKey(key); ok && slices.Contains(b.buckets, bucketName) {
+		return key
+	}
+	return mediaUrl
+}
+
+// OptionalKey is Key for nullable columns.
+func (b *Builder) OptionalKey(mediaUrl *string) *string {
+	if mediaUrl == nil {
+		return nil
+	}
+	key := b.Key(*mediaUrl)
+	return &key
+}
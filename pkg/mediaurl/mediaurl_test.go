@@ -0,0 +1,61 @@
+package mediaurl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeys(t *testing.T) {
+	key := JoinKey("images", "2024/cover.png")
+	assert.Equal(t, "images/2024/cover.png", key)
+
+	bucketName, objectName, ok := SplitKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, "images", bucketName)
+	assert.Equal(t, "2024/cover.png", objectName, "objects may have slashes in their names")
+
+	_, _, ok = SplitKey("cover.png")
+	assert.False(t, ok)
+}
+
+func TestBuilderUrl(t *testing.T) {
+	builder := NewBuilder("https://cdn.pinset.ru/", "images", "videos")
+
+	assert.Equal(t, "https://cdn.pinset.ru/images/cover.png", builder.Url("images/cover.png"))
+	assert.Equal(t, "https://example.com/cover.png", builder.Url("https://example.com/cover.png"), "external media is passed through")
+	assert.Empty(t, builder.Url(""))
+
+	assert.Nil(t, builder.OptionalUrl(nil))
+	key := "videos/clip.mp4"
+	assert.Equal(t, "https://cdn.pinset.ru/videos/clip.mp4", *builder.OptionalUrl(&key))
+}
+
+func TestBuilderKey(t *testing.T) {
+	builder := NewBuilder("https://cdn.pinset.ru", "images", "videos")
+
+	tests := []struct {
+		name     string
+		mediaUrl string
+		key      string
+	}{
+		{name: "our url", mediaUrl: "https://cdn.pinset.ru/images/cover.png", key: "images/cover.png"},
+		{name: "key", mediaUrl: "images/cover.png", key: "images/cover.png"},
+		{name: "url with an old base", mediaUrl: "http://localhost:9000/videos/clip.mp4", key: "videos/clip.mp4"},
+		{name: "external url", mediaUrl: "https://example.com/cover.png", key: "https://example.com/cover.png"},
+		{name: "external url with a bucket like path", mediaUrl: "https://example.com/audios/song.mp3", key: "https://example.com/audios/song.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.key, builder.Key(tt.mediaUrl))
+		})
+	}
+
+	assert.Nil(t, builder.OptionalKey(nil))
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	builder := NewBuilder("https://cdn.pinset.ru", "images")
+	assert.Equal(t, "images/cover.png", builder.Key(builder.Url("images/cover.png")))
+}
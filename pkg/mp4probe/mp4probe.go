@@ -0,0 +1,351 @@
+// Package mp4probe reads the metadata of MP4 videos from the moov box: duration, display size
+// of the video track and the embedded cover art. Media data isn't decoded.
+package mp4probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	boxHeaderSize      = 8
+	largeBoxHeaderSize = 16
+
+	// moov holds sample tables of every track, for hours of video it is a few megabytes
+	maxMoovSize = 64 << 20
+
+	// Types of the data box of iTunes metadata
+	dataTypeJPEG = 13
+	dataTypePNG  = 14
+
+	unknownDuration32 = 0xFFFFFFFF
+)
+
+var (
+	ErrInvalidFormat = errors.New("mp4probe: invalid mp4 container")
+	ErrNoMoov        = errors.New("mp4probe: moov box not found")
+	ErrMoovTooLarge  = errors.New("mp4probe: moov box is too large")
+)
+
+type Info struct {
+	Duration time.Duration
+	// Width and Height are the display size of the first video track, zero for audio-only files
+	Width  int
+	Height int
+	// CoverArt is the embedded cover image, CoverArtType is "image/jpeg" or "image/png"
+	CoverArt     []byte
+	CoverArtType string
+}
+
+// Probe reads the container up to the end of the moov box. Boxes before it are skipped
+// without buffering, so files with moov in front ("fast start") are read only partially.
+func Probe(r io.Reader) (*Info, error) {
+	for {
+		boxType, payloadSize, err := readBoxHeader(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, ErrNoMoov
+			}
+			return nil, err
+		}
+
+		if boxType != "moov" {
+			if payloadSize < 0 {
+				// The box extends to the end of the file
+				return nil, ErrNoMoov
+			}
+			if _, err := io.CopyN(io.Discard, r, payloadSize); err != nil {
+				return nil, fmt.Errorf("%w: truncated %s box", ErrInvalidFormat, boxType)
+			}
+			continue
+		}
+
+		if payloadSize > maxMoovSize {
+			return nil, ErrMoovTooLarge
+		}
+		var moov []byte
+		if payloadSize < 0 {
+			moov, err = io.ReadAll(io.LimitReader(r, maxMoovSize+1))
+			if err == nil && len(moov) > maxMoovSize {
+				return nil, ErrMoovTooLarge
+			}
+		} else {
+			moov = make([]byte, payloadSize)
+			_, err = io.ReadFull(r, moov)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: truncated moov box", ErrInvalidFormat)
+		}
+		return parseMoov(moov)
+	}
+}
+
+// readBoxHeader returns -1 as the size of a box that extends to the end of the file.
+func readBoxHeader(r io.Reader) (boxType string, payloadSize int64, err error) {
+	var header [largeBoxHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:boxHeaderSize]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return "", 0, fmt.Errorf("%w: truncated box header", ErrInvalidFormat)
+		}
+		return "", 0, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	boxType = string(header[4:8])
+	switch size {
+	case 0:
+		return boxType, -1, nil
+	case 1:
+		if _, err := io.ReadFull(r, header[boxHeaderSize:]); err != nil {
+			return "", 0, fmt.Errorf("%w: truncated box header", ErrInvalidFormat)
+		}
+		largeSize := binary.BigEndian.Uint64(header[boxHeaderSize:])
+		if largeSize < largeBoxHeaderSize || largeSize > 1<<62 {
+			return "", 0, fmt.Errorf("%w: bad size of %s box", ErrInvalidFormat, boxType)
+		}
+		return boxType, int64(largeSize) - largeBoxHeaderSize, nil
+	default:
+		if size < boxHeaderSize {
+			return "", 0, fmt.Errorf("%w: bad size of %s box", ErrInvalidFormat, boxType)
+		}
+		return boxType, size - boxHeaderSize, nil
+	}
+}
+
+// box is a child box of a buffered box
+type box struct {
+	boxType string
+	payload []byte
+}
+
+// children splits the payload of a container box.
+func children(data []byte) ([]box, error) {
+	var boxes []box
+	for len(data) > 0 {
+		if len(data) < boxHeaderSize {
+			return nil, fmt.Errorf("%w: truncated box header", ErrInvalidFormat)
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(boxHeaderSize)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < largeBoxHeaderSize {
+				return nil, fmt.Errorf("%w: truncated box header", ErrInvalidFormat)
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = largeBoxHeaderSize
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: bad size of %s box", ErrInvalidFormat, boxType)
+		}
+
+		boxes = append(boxes, box{boxType: boxType, payload: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+func parseMoov(moov []byte) (*Info, error) {
+	boxes, err := children(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	hasHeader := false
+	for _, child := range boxes {
+		switch child.boxType {
+		case "mvhd":
+			info.Duration, err = parseMvhd(child.payload)
+			if err != nil {
+				return nil, err
+			}
+			hasHeader = true
+		case "trak":
+			// Only the first video track is described
+			if info.Width != 0 {
+				continue
+			}
+			info.Width, info.Height, err = parseVideoTrak(child.payload)
+			if err != nil {
+				return nil, err
+			}
+		case "udta":
+			// Malformed metadata doesn't make the video invalid
+			info.CoverArt, info.CoverArtType = findCoverArt(child.payload)
+		}
+	}
+
+	if !hasHeader {
+		return nil, fmt.Errorf("%w: mvhd box not found", ErrInvalidFormat)
+	}
+	return info, nil
+}
+
+func parseMvhd(payload []byte) (time.Duration, error) {
+	if len(payload) < 4 {
+		return 0, fmt.Errorf("%w: truncated mvhd box", ErrInvalidFormat)
+	}
+
+	var timescale, duration uint64
+	switch version := payload[0]; version {
+	case 0:
+		if len(payload) < 20 {
+			return 0, fmt.Errorf("%w: truncated mvhd box", ErrInvalidFormat)
+		}
+		timescale = uint64(binary.BigEndian.Uint32(payload[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(payload[16:20]))
+		if duration == unknownDuration32 {
+			duration = 0
+		}
+	case 1:
+		if len(payload) < 32 {
+			return 0, fmt.Errorf("%w: truncated mvhd box", ErrInvalidFormat)
+		}
+		timescale = uint64(binary.BigEndian.Uint32(payload[20:24]))
+		duration = binary.BigEndian.Uint64(payload[24:32])
+		if duration == ^uint64(0) {
+			duration = 0
+		}
+	default:
+		return 0, fmt.Errorf("%w: unknown mvhd version %d", ErrInvalidFormat, version)
+	}
+
+	if timescale == 0 {
+		return 0, fmt.Errorf("%w: zero timescale", ErrInvalidFormat)
+	}
+	seconds := duration / timescale
+	if seconds > uint64(time.Duration(1<<63-1)/time.Second) {
+		return 0, fmt.Errorf("%w: duration overflow", ErrInvalidFormat)
+	}
+	remainder := duration % timescale
+	return time.Duration(seconds)*time.Second + time.Duration(remainder*uint64(time.Second)/timescale), nil
+}
+
+// parseVideoTrak returns the display size of a video track and zeros for other tracks.
+func parseVideoTrak(trak []byte) (width, height int, err error) {
+	boxes, err := children(trak)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var tkhd []byte
+	isVideo := false
+	for _, child := range boxes {
+		switch child.boxType {
+		case "tkhd":
+			tkhd = child.payload
+		case "mdia":
+			isVideo, err = isVideoMedia(child.payload)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	if !isVideo || tkhd == nil {
+		return 0, 0, nil
+	}
+	return parseTkhd(tkhd)
+}
+
+func isVideoMedia(mdia []byte) (bool, error) {
+	boxes, err := children(mdia)
+	if err != nil {
+		return false, err
+	}
+
+	for _, child := range boxes {
+		if child.boxType != "hdlr" {
+			continue
+		}
+		// version and flags, pre_defined, handler_type
+		if len(child.payload) < 12 {
+			return false, fmt.Errorf("%w: truncated hdlr box", ErrInvalidFormat)
+		}
+		return string(child.payload[8:12]) == "vide", nil
+	}
+	return false, nil
+}
+
+// parseTkhd returns the size of the track after the transformation matrix:
+// width and height are swapped for videos rotated by 90 or 270 degrees.
+func parseTkhd(payload []byte) (width, height int, err error) {
+	if len(payload) < 4 {
+		return 0, 0, fmt.Errorf("%w: truncated tkhd box", ErrInvalidFormat)
+	}
+
+	// version and flags, times, track_ID, reserved and duration of the version
+	offset := 4 + 20
+	if payload[0] == 1 {
+		offset = 4 + 32
+	}
+	// reserved, layer, alternate_group, volume, reserved
+	offset += 16
+
+	const matrixSize = 36
+	if len(payload) < offset+matrixSize+8 {
+		return 0, 0, fmt.Errorf("%w: truncated tkhd box", ErrInvalidFormat)
+	}
+	matrix := payload[offset : offset+matrixSize]
+	a := int32(binary.BigEndian.Uint32(matrix[0:4]))
+	d := int32(binary.BigEndian.Uint32(matrix[16:20]))
+
+	// 16.16 fixed point
+	width = int(binary.BigEndian.Uint32(payload[offset+matrixSize:]) >> 16)
+	height = int(binary.BigEndian.Uint32(payload[offset+matrixSize+4:]) >> 16)
+	if a == 0 && d == 0 {
+		width, height = height, width
+	}
+	return width, height, nil
+}
+
+// findCoverArt looks for udta/meta/ilst/covr, the cover of iTunes metadata.
+func findCoverArt(udta []byte) ([]byte, string) {
+	meta := findChild(udta, "meta")
+	if meta == nil {
+		return nil, ""
+	}
+	// meta is a full box in MP4 but a plain container in QuickTime files
+	if len(meta) >= 4 && binary.BigEndian.Uint32(meta[:4]) == 0 {
+		meta = meta[4:]
+	}
+
+	covr := findChild(findChild(meta, "ilst"), "covr")
+	data := findChild(covr, "data")
+	// type indicator, locale
+	if len(data) <= 8 {
+		return nil, ""
+	}
+
+	switch binary.BigEndian.Uint32(data[:4]) & 0xFFFFFF {
+	case dataTypeJPEG:
+		return data[8:], "image/jpeg"
+	case dataTypePNG:
+		return data[8:], "image/png"
+	default:
+		return nil, ""
+	}
+}
+
+func findChild(container []byte, boxType string) []byte {
+	if container == nil {
+		return nil
+	}
+	boxes, err := children(container)
+	if err != nil {
+		return nil
+	}
+	for _, child := range boxes {
+		if child.boxType == boxType {
+			return child.payload
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package mp4probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBox(boxType string, payload ...[]byte) []byte {
+	content := bytes.Join(payload, nil)
+	data := binary.BigEndian.AppendUint32(nil, uint32(boxHeaderSize+len(content)))
+	data = append(data, boxType...)
+	return append(data, content...)
+}
+
+func testMvhd(timescale, duration uint32) []byte {
+	payload := make([]byte, 100)
+	binary.BigEndian.PutUint32(payload[12:16], timescale)
+	binary.BigEndian.PutUint32(payload[16:20], duration)
+	return testBox("mvhd", payload)
+}
+
+func testMvhdV1(timescale uint32, duration uint64) []byte {
+	payload := make([]byte, 112)
+	payload[0] = 1
+	binary.BigEndian.PutUint32(payload[20:24], timescale)
+	binary.BigEndian.PutUint64(payload[24:32], duration)
+	return testBox("mvhd", payload)
+}
+
+// testTrak builds a track of the handler type with the size in the tkhd box,
+// rotated tracks have the matrix of a 90 degrees rotation
+func testTrak(handlerType string, width, height int, rotated bool) []byte {
+	tkhd := make([]byte, 4+20+16+36+8)
+	matrix := tkhd[40:76]
+	if rotated {
+		binary.BigEndian.PutUint32(matrix[4:8], 0x00010000)
+		binary.BigEndian.PutUint32(matrix[12:16], 0xFFFF0000)
+	} else {
+		binary.BigEndian.PutUint32(matrix[0:4], 0x00010000)
+		binary.BigEndian.PutUint32(matrix[16:20], 0x00010000)
+	}
+	binary.BigEndian.PutUint32(matrix[32:36], 0x40000000)
+	binary.BigEndian.PutUint32(tkhd[76:80], uint32(width)<<16)
+	binary.BigEndian.PutUint32(tkhd[80:84], uint32(height)<<16)
+
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:12], handlerType)
+
+	return testBox("trak", testBox("tkhd", tkhd), testBox("mdia", testBox("hdlr", hdlr)))
+}
+
+func testCover(dataType uint32, image []byte) []byte {
+	data := binary.BigEndian.AppendUint32(nil, dataType)
+	data = append(data, 0, 0, 0, 0)
+	data = append(data, image...)
+
+	ilst := testBox("ilst", testBox("covr", testBox("data", data)))
+	return testBox("udta", testBox("meta", []byte{0, 0, 0, 0}, testBox("hdlr", make([]byte, 24)), ilst))
+}
+
+var (
+	ftyp = testBox("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	mdat = testBox("mdat", bytes.Repeat([]byte{0xAB}, 1000))
+)
+
+// failingReader fails the test when the probe reads past the data
+type failingReader struct {
+	t *testing.T
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	r.t.Error("the probe read past the moov box")
+	return 0, errors.New("read past moov")
+}
+
+func TestProbe(t *testing.T) {
+	moov := testBox("moov",
+		testMvhd(1000, 12_345),
+		testTrak("soun", 0, 0, false),
+		testTrak("vide", 1920, 1080, false),
+		testTrak("vide", 640, 480, false),
+		testCover(dataTypeJPEG, []byte("jpeg data")),
+	)
+
+	info, err := Probe(bytes.NewReader(bytes.Join([][]byte{ftyp, mdat, moov}, nil)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 12*time.Second+345*time.Millisecond, info.Duration)
+		assert.Equal(t, 1920, info.Width, "the first video track is described")
+		assert.Equal(t, 1080, info.Height)
+		assert.Equal(t, []byte("jpeg data"), info.CoverArt)
+		assert.Equal(t, "image/jpeg", info.CoverArtType)
+	}
+}
+
+func TestProbeFastStart(t *testing.T) {
+	moov := testBox("moov", testMvhd(600, 1200), testTrak("vide", 720, 1280, false))
+
+	info, err := Probe(io.MultiReader(bytes.NewReader(ftyp), bytes.NewReader(moov), failingReader{t: t}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2*time.Second, info.Duration)
+		assert.Equal(t, 720, info.Width)
+		assert.Nil(t, info.CoverArt)
+	}
+}
+
+func TestProbeRotatedVideo(t *testing.T) {
+	moov := testBox("moov", testMvhd(1, 5), testTrak("vide", 1920, 1080, true))
+
+	info, err := Probe(bytes.NewReader(moov))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1080, info.Width, "videos rotated by 90 degrees swap the display size")
+		assert.Equal(t, 1920, info.Height)
+	}
+}
+
+func TestProbeAudioOnly(t *testing.T) {
+	moov := testBox("moov", testMvhdV1(44100, 44100*90), testTrak("soun", 0, 0, false), testCover(dataTypePNG, []byte("png data")))
+
+	info, err := Probe(bytes.NewReader(moov))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 90*time.Second, info.Duration)
+		assert.Zero(t, info.Width)
+		assert.Equal(t, "image/png", info.CoverArtType)
+	}
+}
+
+func TestProbeLargeBoxes(t *testing.T) {
+	// mdat with a 64-bit size
+	largeMdat := binary.BigEndian.AppendUint32(nil, 1)
+	largeMdat = append(largeMdat, "mdat"...)
+	largeMdat = binary.BigEndian.AppendUint64(largeMdat, largeBoxHeaderSize+4)
+	largeMdat = append(largeMdat, 1, 2, 3, 4)
+
+	moov := testBox("moov", testMvhd(1, 3))
+	info, err := Probe(bytes.NewReader(bytes.Join([][]byte{ftyp, largeMdat, moov}, nil)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3*time.Second, info.Duration)
+	}
+}
+
+func TestProbeErrors(t *testing.T) {
+	moov := testBox("moov", testMvhd(1, 3))
+	hugeMoov := binary.BigEndian.AppendUint32(nil, maxMoovSize+boxHeaderSize+1)
+	hugeMoov = append(hugeMoov, "moov"...)
+	// mdat extending to the end of the file
+	openMdat := append(binary.BigEndian.AppendUint32(nil, 0), "mdat"...)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "no moov", data: bytes.Join([][]byte{ftyp, mdat}, nil), err: ErrNoMoov},
+		{name: "mdat to the end of the file", data: bytes.Join([][]byte{ftyp, openMdat, moov}, nil), err: ErrNoMoov},
+		{name: "moov too large", data: hugeMoov, err: ErrMoovTooLarge},
+		{name: "truncated moov", data: moov[:len(moov)-4], err: ErrInvalidFormat},
+		{name: "truncated header", data: ftyp[:5], err: ErrInvalidFormat},
+		{name: "bad box size", data: append(binary.BigEndian.AppendUint32(nil, 4), "ftyp"...), err: ErrInvalidFormat},
+		{name: "no mvhd", data: testBox("moov", testTrak("vide", 1, 1, false)), err: ErrInvalidFormat},
+		{name: "zero timescale", data: testBox("moov", testMvhd(0, 3)), err: ErrInvalidFormat},
+		{name: "not mp4", data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), err: ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Probe(bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
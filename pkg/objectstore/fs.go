@@ -0,0 +1,146 @@
+package objectstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileStore keeps objects as files under <root>/<bucket>/<object>.
+// The content type isn't stored, it is guessed by the extension of the object.
+type FileStore struct {
+	root   string
+	signer *URLSigner
+}
+
+func NewFileStore(root string, signer *URLSigner) *FileStore {
+	return &FileStore{
+		root:   root,
+		signer: signer,
+	}
+}
+
+func (s *FileStore) EnsureBucket(bucketName string) error {
+	if !validName(bucketName, ".keep") {
+		return ErrInvalidName
+	}
+	if err := os.MkdirAll(filepath.Join(s.root, bucketName), 0o755); err != nil {
+		return fmt.Errorf("fs EnsureBucket: %w", err)
+	}
+	return nil
+}
+
+// Put writes the object to a temporary file first, so readers never see a partial object.
+func (s *FileStore) Put(bucketName, objectName string, content io.Reader, size int64, contentType string) error {
+	objectPath, err := s.objectPath(bucketName, objectName)
+	if err != nil {
+		return err
+	}
+
+	bucketPath := filepath.Join(s.root, bucketName)
+	if _, err := os.Stat(bucketPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrUnknownBucket
+		}
+		return fmt.Errorf("fs Put: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		return fmt.Errorf("fs Put: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(bucketPath, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("fs Put: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("fs Put: %w", err)
+	}
+	if size >= 0 && written != size {
+		return ErrSizeMismatch
+	}
+
+	if err := os.Rename(tmp.Name(), objectPath); err != nil {
+		return fmt.Errorf("fs Put: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(bucketName, objectName string) (io.ReadCloser, error) {
+	objectPath, err := s.objectPath(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(objectPath)
+	if err != nil {
+		return nil, toFileStoreError(err)
+	}
+	return file, nil
+}
+
+func (s *FileStore) Stat(bucketName, objectName string) (ObjectInfo, error) {
+	objectPath, err := s.objectPath(bucketName, objectName)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(objectPath)
+	if err != nil {
+		return ObjectInfo{}, toFileStoreError(err)
+	}
+	if info.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return ObjectInfo{Size: info.Size(), ContentType: contentTypeByName(objectName)}, nil
+}
+
+func (s *FileStore) Remove(bucketName, objectName string) error {
+	objectPath, err := s.objectPath(bucketName, objectName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(objectPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("fs Remove: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) PresignGet(bucketName, objectName string, expires time.Duration) (string, error) {
+	if !validName(bucketName, objectName) {
+		return "", ErrInvalidName
+	}
+	return s.signer.Sign(http.MethodGet, bucketName, objectName, expires), nil
+}
+
+func (s *FileStore) PresignPost(bucketName, objectName string, policy UploadPolicy) (*PresignedPost, error) {
+	if !validName(bucketName, objectName) {
+		return nil, ErrInvalidName
+	}
+	return s.signer.SignPost(bucketName, objectName, policy), nil
+}
+
+func (s *FileStore) objectPath(bucketName, objectName string) (string, error) {
+	if !validName(bucketName, objectName) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(s.root, bucketName, filepath.FromSlash(objectName)), nil
+}
+
+func toFileStoreError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return fmt.Errorf("fs: %w", err)
+}
@@ -0,0 +1,157 @@
+package objectstore
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxPostFields    = 16
+	maxPostFieldSize = 4 << 10
+)
+
+type handler struct {
+	store  Store
+	signer *URLSigner
+}
+
+// NewHandler serves the objects of a local backend with the URLs of the signer:
+// GET /<bucket>/<object> downloads an object, POST /<bucket> uploads one with a presigned form.
+// Like a public-read bucket, anyone knowing the key may download the object,
+// a signature is checked only when the URL has one.
+// The handler expects the base path of the signer to be stripped.
+func NewHandler(store Store, signer *URLSigner) http.Handler {
+	return &handler{
+		store:  store,
+		signer: signer,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if r.Method == http.MethodPost {
+		h.post(w, r, path)
+		return
+	}
+
+	bucketName, objectName, ok := strings.Cut(path, "/")
+	if !ok || !validName(bucketName, objectName) {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if query.Has(signatureParam) && h.signer.Verify(http.MethodGet, bucketName, objectName, query) != nil {
+			http.Error(w, ErrBadSignature.Error(), http.StatusForbidden)
+			return
+		}
+		h.get(w, r, bucketName, objectName)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	info, err := h.store.Stat(bucketName, objectName)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	object, err := h.store.Get(bucketName, objectName)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	defer object.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, object)
+}
+
+// post stores the file of a form signed by URLSigner.SignPost. Like S3 does, it reads the fields up to the file,
+// which must be the last one, and stores the file only if its size is within the signed range.
+func (h *handler) post(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if !validName(bucketName, ".keep") {
+		http.NotFound(w, r)
+		return
+	}
+
+	form, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := make(map[string]string)
+	for len(fields) <= maxPostFields {
+		part, err := form.NextPart()
+		if err != nil {
+			http.Error(w, "form has no file", http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() != fileField {
+			value, err := io.ReadAll(io.LimitReader(part, maxPostFieldSize+1))
+			if err != nil || len(value) > maxPostFieldSize {
+				http.Error(w, "form field is too large", http.StatusBadRequest)
+				return
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+
+		policy, err := h.signer.VerifyPost(bucketName, fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		content := &sizeRangeReader{reader: part, minSize: policy.MinSize, maxSize: policy.MaxSize}
+		if err := h.store.Put(bucketName, policy.Key, content, -1, policy.ContentType); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Error(w, "form has too many fields", http.StatusBadRequest)
+}
+
+// sizeRangeReader fails the read that goes past maxSize or ends before minSize,
+// so the store never keeps an object of another size.
+type sizeRangeReader struct {
+	reader           io.Reader
+	read             int64
+	minSize, maxSize int64
+}
+
+func (r *sizeRangeReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.maxSize || errors.Is(err, io.EOF) && r.read < r.minSize {
+		return n, ErrSizeMismatch
+	}
+	return n, err
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUnknownBucket):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSizeMismatch), errors.Is(err, ErrInvalidName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
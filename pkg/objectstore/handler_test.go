@@ -0,0 +1,155 @@
+package objectstore
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandler(t *testing.T) (*httptest.Server, *MemoryStore) {
+	t.Helper()
+
+	store := NewMemoryStore(testSigner)
+	if err := store.EnsureBucket("images"); err != nil {
+		t.Fatalf("ensure bucket: %s", err)
+	}
+	server := httptest.NewServer(http.StripPrefix("/media", NewHandler(store, testSigner)))
+	t.Cleanup(server.Close)
+	return server, store
+}
+
+// localUrl points the presigned URL to the test server
+func localUrl(server *httptest.Server, presigned string) string {
+	return server.URL + strings.TrimPrefix(presigned, "http://pinset.test")
+}
+
+func do(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %s", err)
+	}
+	req.Header.Set("Content-Type", "image/png")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %s", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// postForm sends the form the way a browser does, the fields in the given order and the file last
+func postForm(t *testing.T, url string, fields [][2]string, content string) *http.Response {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, field := range fields {
+		form.WriteField(field[0], field[1])
+	}
+	file, _ := form.CreateFormFile(fileField, "cover.png")
+	file.Write([]byte(content))
+	form.Close()
+
+	resp, err := http.Post(url, form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("POST %s: %s", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func presignedFields(presigned *PresignedPost) [][2]string {
+	fields := make([][2]string, 0, len(presigned.Fields))
+	for _, name := range []string{keyField, contentTypeField, policyField, signatureParam} {
+		fields = append(fields, [2]string{name, presigned.Fields[name]})
+	}
+	return fields
+}
+
+func TestHandlerPostAndGet(t *testing.T) {
+	server, store := newTestHandler(t)
+
+	presigned, err := store.PresignPost("images", "cover.png", UploadPolicy{ContentType: "image/png", MinSize: 8, MaxSize: 8, Expires: time.Minute})
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp := postForm(t, localUrl(server, presigned.Url), presignedFields(presigned), "png data")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(t, http.MethodGet, server.URL+"/media/images/cover.png", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "objects are readable by key like in a public-read bucket")
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	data, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "png data", string(data))
+
+	signedGet, err := store.PresignGet("images", "cover.png", -time.Minute)
+	if assert.NoError(t, err) {
+		resp = do(t, http.MethodGet, localUrl(server, signedGet), "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "a signature is checked when present")
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/media/images/missing.png", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHandlerEnforcesUploadPolicy(t *testing.T) {
+	server, store := newTestHandler(t)
+
+	presigned, err := store.PresignPost("images", "cover.png", UploadPolicy{ContentType: "image/png", MinSize: 4, MaxSize: 8, Expires: time.Minute})
+	if !assert.NoError(t, err) {
+		return
+	}
+	url := localUrl(server, presigned.Url)
+	withField := func(name, value string) [][2]string {
+		fields := presignedFields(presigned)
+		for i := range fields {
+			if fields[i][0] == name {
+				fields[i][1] = value
+			}
+		}
+		return fields
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		fields  [][2]string
+		content string
+		status  int
+	}{
+		{name: "too large", url: url, fields: presignedFields(presigned), content: "png data!", status: http.StatusBadRequest},
+		{name: "too small", url: url, fields: presignedFields(presigned), content: "png", status: http.StatusBadRequest},
+		{name: "other type", url: url, fields: withField(contentTypeField, "image/gif"), content: "png data", status: http.StatusForbidden},
+		{name: "other key", url: url, fields: withField(keyField, "other.png"), content: "png data", status: http.StatusForbidden},
+		{name: "other bucket", url: server.URL + "/media/videos", fields: presignedFields(presigned), content: "png data", status: http.StatusForbidden},
+		{name: "unsigned", url: url, fields: withField(signatureParam, ""), content: "png data", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := postForm(t, tt.url, tt.fields, tt.content)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			_, err := store.Stat("images", "cover.png")
+			assert.ErrorIs(t, err, ErrNotFound, "nothing is stored outside of the policy")
+		})
+	}
+
+	expired, err := store.PresignPost("images", "cover.png", UploadPolicy{ContentType: "image/png", MinSize: 4, MaxSize: 8, Expires: -time.Minute})
+	if assert.NoError(t, err) {
+		resp := postForm(t, localUrl(server, expired.Url), presignedFields(expired), "png data")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+
+	resp := do(t, http.MethodPut, server.URL+"/media/images/cover.png", "png data")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, "objects are uploaded only with a presigned form")
+}
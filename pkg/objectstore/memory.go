@@ -0,0 +1,127 @@
+package objectstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+}
+
+// MemoryStore keeps objects in memory, they are lost when the process exits.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string]memoryObject
+	signer  *URLSigner
+}
+
+func NewMemoryStore(signer *URLSigner) *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string]memoryObject),
+		signer:  signer,
+	}
+}
+
+func (s *MemoryStore) EnsureBucket(bucketName string) error {
+	if !validName(bucketName, ".keep") {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucketName]; !ok {
+		s.buckets[bucketName] = make(map[string]memoryObject)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Put(bucketName, objectName string, content io.Reader, size int64, contentType string) error {
+	if !validName(bucketName, objectName) {
+		return ErrInvalidName
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("memory Put: %w", err)
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return ErrSizeMismatch
+	}
+	if contentType == "" {
+		contentType = contentTypeByName(objectName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		return ErrUnknownBucket
+	}
+	bucket[objectName] = memoryObject{data: data, contentType: contentType}
+	return nil
+}
+
+// Get returns a reader of the object as it was when Get was called.
+func (s *MemoryStore) Get(bucketName, objectName string) (io.ReadCloser, error) {
+	object, err := s.object(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+func (s *MemoryStore) Stat(bucketName, objectName string) (ObjectInfo, error) {
+	object, err := s.object(bucketName, objectName)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Size: int64(len(object.data)), ContentType: object.contentType}, nil
+}
+
+func (s *MemoryStore) Remove(bucketName, objectName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bucket, ok := s.buckets[bucketName]; ok {
+		delete(bucket, objectName)
+	}
+	return nil
+}
+
+func (s *MemoryStore) PresignGet(bucketName, objectName string, expires time.Duration) (string, error) {
+	if !validName(bucketName, objectName) {
+		return "", ErrInvalidName
+	}
+	return s.signer.Sign(http.MethodGet, bucketName, objectName, expires), nil
+}
+
+func (s *MemoryStore) PresignPost(bucketName, objectName string, policy UploadPolicy) (*PresignedPost, error) {
+	if !validName(bucketName, objectName) {
+		return nil, ErrInvalidName
+	}
+	return s.signer.SignPost(bucketName, objectName, policy), nil
+}
+
+// object is safe to read after unlocking: Put replaces objects and never changes their data.
+func (s *MemoryStore) object(bucketName, objectName string) (memoryObject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		return memoryObject{}, ErrUnknownBucket
+	}
+	object, ok := bucket[objectName]
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return object, nil
+}
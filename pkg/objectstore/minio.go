@@ -0,0 +1,137 @@
+package objectstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+const (
+	minioNoSuchKeyCode    = "NoSuchKey"
+	minioNoSuchBucketCode = "NoSuchBucket"
+)
+
+// MinioStore keeps objects in MinIO or another S3 compatible storage.
+type MinioStore struct {
+	client *minio.Client
+	// presignClient only signs URLs for the endpoint clients reach the storage at
+	presignClient *minio.Client
+}
+
+// NewMinioStore uses the client for the storage calls and presignClient for signing.
+// The region of presignClient must be fixed, otherwise it looks up bucket locations at the storage.
+func NewMinioStore(client, presignClient *minio.Client) *MinioStore {
+	return &MinioStore{
+		client:        client,
+		presignClient: presignClient,
+	}
+}
+
+// CheckBucket returns ErrUnknownBucket for a missing bucket.
+// Buckets of S3 are provisioned with their policies outside the service, so they are never created here.
+func (s *MinioStore) CheckBucket(bucketName string) error {
+	exists, err := s.client.BucketExists(bucketName)
+	if err != nil {
+		return fmt.Errorf("minio BucketExists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("minio bucket %s: %w", bucketName, ErrUnknownBucket)
+	}
+	return nil
+}
+
+// Put sends objects larger than 64MiB as a multipart upload,
+// in parallel parts when content implements io.ReaderAt (e.g. a spooled multipart file).
+func (s *MinioStore) Put(bucketName, objectName string, content io.Reader, size int64, contentType string) error {
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	_, err := s.client.PutObject(bucketName, objectName, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("minio PutObject: %w", toStoreError(err))
+	}
+	return nil
+}
+
+func (s *MinioStore) Get(bucketName, objectName string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("minio GetObject: %w", toStoreError(err))
+	}
+	return &minioObject{object}, nil
+}
+
+func (s *MinioStore) Stat(bucketName, objectName string) (ObjectInfo, error) {
+	info, err := s.client.StatObject(bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("minio StatObject: %w", toStoreError(err))
+	}
+	return ObjectInfo{Size: info.Size, ContentType: info.ContentType}, nil
+}
+
+func (s *MinioStore) Remove(bucketName, objectName string) error {
+	if err := s.client.RemoveObject(bucketName, objectName); err != nil {
+		return fmt.Errorf("minio RemoveObject: %w", toStoreError(err))
+	}
+	return nil
+}
+
+// PresignGet is local, it fails only for malformed names.
+func (s *MinioStore) PresignGet(bucketName, objectName string, expires time.Duration) (string, error) {
+	signedUrl, err := s.presignClient.PresignedGetObject(bucketName, objectName, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("minio PresignedGetObject: %w", err)
+	}
+	return signedUrl.String(), nil
+}
+
+// PresignPost signs the type and the size range of the object, so MinIO itself rejects other uploads.
+func (s *MinioStore) PresignPost(bucketName, objectName string, policy UploadPolicy) (*PresignedPost, error) {
+	postPolicy := minio.NewPostPolicy()
+	err := errors.Join(
+		postPolicy.SetBucket(bucketName),
+		postPolicy.SetKey(objectName),
+		postPolicy.SetExpires(time.Now().UTC().Add(policy.Expires)),
+		postPolicy.SetContentType(policy.ContentType),
+		postPolicy.SetContentLengthRange(policy.MinSize, policy.MaxSize),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("minio PostPolicy: %w", err)
+	}
+
+	signedUrl, fields, err := s.presignClient.PresignedPostPolicy(postPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("minio PresignedPostPolicy: %w", err)
+	}
+	return &PresignedPost{Url: signedUrl.String(), Fields: fields}, nil
+}
+
+// minioObject translates the missing object error, which minio-go reports only on the first read.
+type minioObject struct {
+	*minio.Object
+}
+
+func (o *minioObject) Read(p []byte) (int, error) {
+	n, err := o.Object.Read(p)
+	if err != nil && err != io.EOF {
+		return n, toStoreError(err)
+	}
+	return n, err
+}
+
+func toStoreError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case minioNoSuchKeyCode:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case minioNoSuchBucketCode:
+		return fmt.Errorf("%w: %w", ErrUnknownBucket, err)
+	default:
+		return err
+	}
+}
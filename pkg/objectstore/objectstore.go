@@ -0,0 +1,73 @@
+// Package objectstore hides the storage of media objects behind a small interface.
+// MinIO (or any S3 compatible storage) is used in production, the local filesystem
+// and memory backends let the service and its tests run without it.
+package objectstore
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"time"
+)
+
+const defaultContentType = "application/octet-stream"
+
+var (
+	ErrNotFound      = errors.New("object not found")
+	ErrInvalidName   = errors.New("invalid bucket or object name")
+	ErrSizeMismatch  = errors.New("object size differs from the declared one")
+	ErrBadSignature  = errors.New("invalid or expired signature")
+	ErrUnknownBucket = errors.New("bucket doesn't exist")
+)
+
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+type Store interface {
+	// Put stores the object, size is -1 when unknown
+	Put(bucketName, objectName string, content io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound for a missing object, though MinIO reports it only on the first read
+	Get(bucketName, objectName string) (io.ReadCloser, error)
+	Stat(bucketName, objectName string) (ObjectInfo, error)
+	// Remove succeeds for a missing object
+	Remove(bucketName, objectName string) error
+
+	// PresignGet returns a URL to download the object without credentials until it expires
+	PresignGet(bucketName, objectName string, expires time.Duration) (string, error)
+	// PresignPost returns a form to upload the object with a POST request within the policy until it expires
+	PresignPost(bucketName, objectName string, policy UploadPolicy) (*PresignedPost, error)
+}
+
+// UploadPolicy limits the object a presigned POST may upload, the storage rejects any other one.
+type UploadPolicy struct {
+	ContentType string
+	MinSize     int64
+	MaxSize     int64
+	Expires     time.Duration
+}
+
+// PresignedPost is a multipart/form-data upload like the one of S3:
+// the fields go first and the content is the last field, named "file".
+type PresignedPost struct {
+	Url    string
+	Fields map[string]string
+}
+
+// LocalStore keeps the objects itself, so unlike S3 it creates its own buckets.
+type LocalStore interface {
+	Store
+
+	// EnsureBucket creates the bucket unless it exists
+	EnsureBucket(bucketName string) error
+}
+
+// contentTypeByName is used by the backends that don't keep the type given on Put.
+func contentTypeByName(objectName string) string {
+	if contentType := mime.TypeByExtension(path.Ext(objectName)); contentType != "" {
+		return contentType
+	}
+	return defaultContentType
+}
@@ -0,0 +1,89 @@
+package objectstore
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSigner = NewURLSigner("http://pinset.test/media", []byte("test signing key"))
+
+// testStore checks the behaviour every local backend shares
+func testStore(t *testing.T, store LocalStore, keepsContentType bool) {
+	assert.NoError(t, store.EnsureBucket("images"))
+	assert.NoError(t, store.EnsureBucket("images"), "ensuring an existing bucket is fine")
+	assert.ErrorIs(t, store.EnsureBucket("../images"), ErrInvalidName)
+
+	err := store.Put("images", "2024/cover.png", strings.NewReader("png data"), 8, "image/png")
+	assert.NoError(t, err)
+
+	info, err := store.Stat("images", "2024/cover.png")
+	assert.NoError(t, err)
+	assert.Equal(t, ObjectInfo{Size: 8, ContentType: "image/png"}, info)
+
+	object, err := store.Get("images", "2024/cover.png")
+	if assert.NoError(t, err) {
+		data, err := io.ReadAll(object)
+		object.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "png data", string(data))
+	}
+
+	err = store.Put("images", "unknown", strings.NewReader("data"), -1, "application/x-custom")
+	assert.NoError(t, err, "the size may be unknown")
+	info, err = store.Stat("images", "unknown")
+	assert.NoError(t, err)
+	if keepsContentType {
+		assert.Equal(t, "application/x-custom", info.ContentType)
+	} else {
+		assert.Equal(t, defaultContentType, info.ContentType, "the type is guessed by the extension")
+	}
+
+	assert.ErrorIs(t, store.Put("images", "short.png", strings.NewReader("data"), 5, ""), ErrSizeMismatch)
+	_, err = store.Stat("images", "short.png")
+	assert.ErrorIs(t, err, ErrNotFound, "a mismatched object is not stored")
+
+	assert.ErrorIs(t, store.Put("videos", "clip.mp4", strings.NewReader("data"), 4, ""), ErrUnknownBucket)
+	assert.ErrorIs(t, store.Put("images", "../escape.png", strings.NewReader("data"), 4, ""), ErrInvalidName)
+
+	assert.NoError(t, store.Remove("images", "2024/cover.png"))
+	assert.NoError(t, store.Remove("images", "2024/cover.png"), "removing a missing object is fine")
+	_, err = store.Stat("images", "2024/cover.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get("images", "2024/cover.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	presigned, err := store.PresignPost("images", "cover.png", UploadPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 8, Expires: time.Minute})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://pinset.test/media/images", presigned.Url)
+		assert.Equal(t, "cover.png", presigned.Fields[keyField])
+		assert.Equal(t, "image/png", presigned.Fields[contentTypeField])
+	}
+	_, err = store.PresignPost("images", "../cover.png", UploadPolicy{ContentType: "image/png", MaxSize: 8, Expires: time.Minute})
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = store.PresignGet("images", "/etc/passwd", time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(testSigner), true)
+}
+
+func TestFileStore(t *testing.T) {
+	testStore(t, NewFileStore(t.TempDir(), testSigner), false)
+}
+
+func TestValidName(t *testing.T) {
+	assert.True(t, validName("images", "cover.png"))
+	assert.True(t, validName("images", "2024/10/cover.png"))
+	assert.False(t, validName("", "cover.png"))
+	assert.False(t, validName("..", "cover.png"))
+	assert.False(t, validName("images/2024", "cover.png"))
+	assert.False(t, validName("images", ""))
+	assert.False(t, validName("images", "../cover.png"))
+	assert.False(t, validName("images", "/cover.png"))
+	assert.False(t, validName("images", `2024\cover.png`))
+}
@@ -0,0 +1,144 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	expiresParam   = "X-Expires"
+	signatureParam = "X-Signature"
+
+	keyField         = "key"
+	contentTypeField = "Content-Type"
+	policyField      = "policy"
+	fileField        = "file"
+)
+
+// postPolicy is the part of a presigned POST covered by the signature
+type postPolicy struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	MinSize     int64  `json:"min_size"`
+	MaxSize     int64  `json:"max_size"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// URLSigner issues and checks the presigned URLs of the local backends, which are served by Handler.
+type URLSigner struct {
+	baseUrl string
+	key     []byte
+}
+
+// NewURLSigner signs URLs under the base, where Handler is mounted, e.g. "http://localhost:8080/media".
+func NewURLSigner(baseUrl string, key []byte) *URLSigner {
+	return &URLSigner{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		key:     key,
+	}
+}
+
+func (s *URLSigner) Sign(method, bucketName, objectName string, expires time.Duration) string {
+	expiresAt := time.Now().Add(expires).Unix()
+
+	query := url.Values{}
+	query.Set(expiresParam, strconv.FormatInt(expiresAt, 10))
+	query.Set(signatureParam, s.signature(method, bucketName, objectName, expiresAt))
+
+	return s.baseUrl + "/" + url.PathEscape(bucketName) + "/" + escapeObjectName(objectName) + "?" + query.Encode()
+}
+
+func (s *URLSigner) Verify(method, bucketName, objectName string, query url.Values) error {
+	expiresAt, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrBadSignature
+	}
+
+	expected := s.signature(method, bucketName, objectName, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(signatureParam))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// SignPost returns the form of an upload to the bucket limited by the policy.
+func (s *URLSigner) SignPost(bucketName, objectName string, policy UploadPolicy) *PresignedPost {
+	encoded, _ := json.Marshal(postPolicy{
+		Bucket:      bucketName,
+		Key:         objectName,
+		ContentType: policy.ContentType,
+		MinSize:     policy.MinSize,
+		MaxSize:     policy.MaxSize,
+		ExpiresAt:   time.Now().Add(policy.Expires).Unix(),
+	})
+	encodedPolicy := base64.StdEncoding.EncodeToString(encoded)
+
+	return &PresignedPost{
+		Url: s.baseUrl + "/" + url.PathEscape(bucketName),
+		Fields: map[string]string{
+			keyField:         objectName,
+			contentTypeField: policy.ContentType,
+			policyField:      encodedPolicy,
+			signatureParam:   s.policySignature(encodedPolicy),
+		},
+	}
+}
+
+// VerifyPost returns the policy of the form fields signed for the bucket.
+// The key and the type sent in the form must be the signed ones, like S3 requires.
+func (s *URLSigner) VerifyPost(bucketName string, fields map[string]string) (postPolicy, error) {
+	encodedPolicy := fields[policyField]
+	if !hmac.Equal([]byte(s.policySignature(encodedPolicy)), []byte(fields[signatureParam])) {
+		return postPolicy{}, ErrBadSignature
+	}
+
+	var policy postPolicy
+	decoded, err := base64.StdEncoding.DecodeString(encodedPolicy)
+	if err != nil || json.Unmarshal(decoded, &policy) != nil {
+		return postPolicy{}, ErrBadSignature
+	}
+	if time.Now().Unix() > policy.ExpiresAt || policy.Bucket != bucketName ||
+		fields[keyField] != policy.Key || fields[contentTypeField] != policy.ContentType {
+		return postPolicy{}, ErrBadSignature
+	}
+	return policy, nil
+}
+
+func (s *URLSigner) policySignature(encodedPolicy string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(http.MethodPost + "\n" + encodedPolicy))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *URLSigner) signature(method, bucketName, objectName string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(method + "\n" + bucketName + "/" + objectName + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func escapeObjectName(objectName string) string {
+	segments := strings.Split(objectName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// validName keeps the local backends inside their root: buckets are a single path segment,
+// objects are relative paths without "..".
+func validName(bucketName, objectName string) bool {
+	if bucketName == "" || bucketName == "." || bucketName == ".." || strings.ContainsAny(bucketName, `/\`) {
+		return false
+	}
+	return objectName != "" && !strings.Contains(objectName, `\`) && filepath.IsLocal(filepath.FromSlash(objectName))
+}
@@ -0,0 +1,65 @@
+package objectstore
+
+import (
+	"encoding/base64"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signedQuery(t *testing.T, signedUrl string) url.Values {
+	t.Helper()
+
+	parsed, err := url.Parse(signedUrl)
+	if err != nil {
+		t.Fatalf("parse signed url: %s", err)
+	}
+	return parsed.Query()
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("http://pinset.test/media/", []byte("key"))
+
+	signed := signer.Sign(http.MethodPut, "images", "2024/my cover.png", time.Minute)
+	assert.True(t, strings.HasPrefix(signed, "http://pinset.test/media/images/2024/my%20cover.png?"), signed)
+
+	query := signedQuery(t, signed)
+	assert.NoError(t, signer.Verify(http.MethodPut, "images", "2024/my cover.png", query))
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "images", "2024/my cover.png", query), ErrBadSignature, "the method is signed")
+	assert.ErrorIs(t, signer.Verify(http.MethodPut, "images", "2024/other.png", query), ErrBadSignature, "the object is signed")
+	assert.ErrorIs(t, NewURLSigner("http://pinset.test/media", []byte("other key")).Verify(http.MethodPut, "images", "2024/my cover.png", query), ErrBadSignature)
+
+	expired := signedQuery(t, signer.Sign(http.MethodPut, "images", "cover.png", -time.Minute))
+	assert.ErrorIs(t, signer.Verify(http.MethodPut, "images", "cover.png", expired), ErrBadSignature)
+
+	assert.ErrorIs(t, signer.Verify(http.MethodPut, "images", "cover.png", url.Values{}), ErrBadSignature)
+}
+
+func TestURLSignerPost(t *testing.T) {
+	signer := NewURLSigner("http://pinset.test/media/", []byte("key"))
+
+	presigned := signer.SignPost("images", "cover.png", UploadPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 1024, Expires: time.Minute})
+	assert.Equal(t, "http://pinset.test/media/images", presigned.Url)
+
+	policy, err := signer.VerifyPost("images", presigned.Fields)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "cover.png", policy.Key)
+		assert.Equal(t, int64(1), policy.MinSize)
+		assert.Equal(t, int64(1024), policy.MaxSize)
+	}
+
+	_, err = signer.VerifyPost("videos", presigned.Fields)
+	assert.ErrorIs(t, err, ErrBadSignature, "the bucket is signed")
+	_, err = NewURLSigner("http://pinset.test/media", []byte("other key")).VerifyPost("images", presigned.Fields)
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	tampered := maps.Clone(presigned.Fields)
+	tampered[policyField] = base64.StdEncoding.EncodeToString([]byte(`{"bucket":"images","key":"cover.png","max_size":1099511627776}`))
+	_, err = signer.VerifyPost("images", tampered)
+	assert.ErrorIs(t, err, ErrBadSignature, "the policy is signed")
+}